@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // IANA timezones of users even without system zoneinfo
 
+	"github.com/SashaMalcev/pr-reviewer-service/internal/codehost"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/config"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/http/router"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/repository/postgres"
@@ -21,7 +24,7 @@ import (
 /*
 
 Main application entry point with graceful shutdown.
-Initializes logger, config, database, services and HTTP server.
+Initializes logger, config, database, services, background workers and HTTP server.
 Handles OS signals for clean shutdown.
 
 */
@@ -75,15 +78,77 @@ func main() {
 	teamRepo := postgres.NewTeamRepository(pool)
 	userRepo := postgres.NewUserRepository(pool)
 	prRepo := postgres.NewPRRepository(pool)
+	absenceRepo := postgres.NewAbsenceRepository(pool)
+	traceRepo := postgres.NewAssignmentTraceRepository(pool)
+	slaRepo := postgres.NewSLARepository(pool)
+	loginRepo := postgres.NewLoginRepository(pool)
+	webhookRepo := postgres.NewWebhookRepository(pool)
+	outboxRepo := postgres.NewOutboxRepository(pool)
+	auditRepo := postgres.NewAuditRepository(pool)
 
 	// Init services
-	teamService := service.NewTeamService(teamRepo, userRepo)
-	userService := service.NewUserService(userRepo, prRepo)
-	prService := service.NewPRService(prRepo, userRepo, teamRepo)
-	statsService := service.NewStatsService(prRepo, userRepo)
+	clock := service.SystemClock{}
+	auditService := service.NewAuditService(auditRepo)
+	outboundService := service.NewOutboundWebhookService(webhookRepo, nil, clock)
+
+	// Event sinks the outbox is dispatched to
+	sinks := service.EventPublishers{}
+
+	for _, name := range cfg.EventSinks {
+		switch name {
+		case "log":
+			sinks = append(sinks, service.LogEventPublisher{})
+		case "webhook":
+			sinks = append(sinks, outboundService)
+		case "file":
+			fileSink, err := service.NewFileEventSink(cfg.EventFilePath)
+
+			if err != nil {
+				log.Fatal().Err(err).Msg("Failed to open event file")
+			}
+
+			defer fileSink.Close()
+			sinks = append(sinks, fileSink)
+		}
+	}
+
+	outboxService := service.NewOutboxService(outboxRepo, sinks, clock, cfg.OutboxMaxAttempts)
+	prService := service.NewPRService(prRepo, userRepo, teamRepo, traceRepo, outboxService, clock)
+	teamService := service.NewTeamService(teamRepo, userRepo, prService, clock)
+	userService := service.NewUserService(userRepo, prRepo, prService, prService, outboxService, clock)
+	statsService := service.NewStatsService(prRepo, userRepo, teamRepo, clock)
+	availabilityService := service.NewAvailabilityService(absenceRepo, userRepo, clock, prService, auditService)
+	slaService := service.NewSLAService(slaRepo, userRepo, teamRepo, prService, outboxService, clock)
+	webhookService := service.NewWebhookService(loginRepo, userRepo, prService, clock,
+		codehost.NewGitHub(cfg.GitHubWebhookSecret),
+		codehost.NewGitLab(cfg.GitLabWebhookToken),
+		codehost.NewGitea(cfg.GiteaWebhookSecret),
+	)
+
+	// Start background workers, on shutdown they are stopped and waited for
+	// before the pool is closed, so none of them runs a query on a closed pool
+	var workers sync.WaitGroup
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer workers.Wait()
+	defer stopWorkers()
+
+	runWorker := func(run func(context.Context)) {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	runWorker(service.NewAvailabilityWorker(availabilityService, cfg.AvailabilitySyncInterval).Run)
+	runWorker(service.NewPendingAssignmentWorker(prService, cfg.PendingRetryInterval).Run)
+	runWorker(service.NewSLAWorker(slaService, cfg.SLACheckInterval).Run)
+	runWorker(service.NewWebhookDeliveryWorker(outboundService, cfg.WebhookDeliveryInterval).Run)
+	runWorker(service.NewOutboxDispatcher(outboxService, cfg.OutboxDispatchInterval).Run)
 
 	// Init HTTP router
-	r := router.New(teamService, userService, prService, statsService)
+	r := router.New(teamService, userService, prService, statsService, availabilityService, slaService, webhookService, outboundService, outboxService, auditService)
 
 	// Create HTTP server
 	server := &http.Server{
@@ -111,6 +176,8 @@ func main() {
 	<-quit
 
 	log.Info().Msg("Shutting down server...")
+	stopWorkers()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -120,5 +187,7 @@ func main() {
 		log.Error().Err(err).Msg("Server forced to shutdown")
 	}
 
+	workers.Wait()
+
 	log.Info().Msg("Server exited")
 }
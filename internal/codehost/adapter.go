@@ -0,0 +1,100 @@
+package codehost
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+Code host adapters.
+An Adapter knows how one code host delivers webhooks: how a delivery proves it comes
+from the host (a signature or a shared token) and how its merge request payload reads.
+It turns a delivery into a Change the webhook service applies to the PR, so the service
+itself does not depend on any code host. Adapters:
+
+- GitHub (see github.go) - pull_request events signed with X-Hub-Signature-256
+- GitLab (see gitlab.go) - Merge Request Hook events carrying X-Gitlab-Token
+- Gitea  (see gitea.go)  - pull_request events signed with X-Gitea-Signature
+
+Adapters are created with the secret of their host, without it every delivery is rejected.
+
+*/
+
+// Adapter translates webhook deliveries of a code host
+type Adapter interface {
+	Host() models.CodeHost
+	// Verify returns ErrInvalidSignature unless the delivery comes from the code host
+	Verify(header http.Header, body []byte) error
+	// Parse reads a verified delivery, deliveries about anything but PRs become ChangeIgnored
+	Parse(header http.Header, body []byte) (*Change, error)
+}
+
+// ChangeKind is what happened to a PR on the code host
+type ChangeKind string
+
+const (
+	ChangeOpened   ChangeKind = "OPENED"
+	ChangeReady    ChangeKind = "READY"
+	ChangeEdited   ChangeKind = "EDITED"
+	ChangeMerged   ChangeKind = "MERGED"
+	ChangeClosed   ChangeKind = "CLOSED"
+	ChangeReopened ChangeKind = "REOPENED"
+	ChangeIgnored  ChangeKind = "IGNORED"
+)
+
+// Change is a webhook delivery in terms of the service. Event and Action are named
+// the way the code host names them, the PR fields are set for every kind but ChangeIgnored
+type Change struct {
+	Kind   ChangeKind
+	Event  string
+	Action string
+
+	PullRequestID string
+	Title         string
+	// Login of the PR author on the code host, set for ChangeOpened
+	AuthorLogin string
+	Draft       bool
+	Metadata    models.PRMetadata
+}
+
+// builds the metadata of a PR, a description too long for the service is cut
+func newMetadata(repository, url, baseBranch string, labels []string, description string) models.PRMetadata {
+
+	if len(description) > models.MaxDescriptionLength {
+		// a rune cut in half is dropped
+		description = strings.ToValidUTF8(description[:models.MaxDescriptionLength], "")
+	}
+
+	if labels == nil {
+		labels = []string{}
+	}
+
+	return models.PRMetadata{
+		Repository:  repository,
+		URL:         url,
+		BaseBranch:  baseBranch,
+		Labels:      labels,
+		Description: description,
+	}
+}
+
+// tells whether signature is the hex HMAC-SHA256 of body keyed with secret
+func validHMAC(secret []byte, body []byte, signature string) bool {
+
+	sum, err := hex.DecodeString(signature)
+
+	if err != nil || len(secret) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return hmac.Equal(sum, mac.Sum(nil))
+}
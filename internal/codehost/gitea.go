@@ -0,0 +1,128 @@
+package codehost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+Gitea adapter.
+A delivery is trusted only when its X-Gitea-Signature is the hex HMAC-SHA256
+of the raw body keyed with the shared secret. Actions of pull_request
+and pull_request_label events:
+
+- opened                                     - ChangeOpened
+- edited, label_updated, label_cleared       - ChangeEdited
+- closed                                     - ChangeMerged for a merged PR, ChangeClosed otherwise
+- reopened                                   - ChangeReopened
+
+Gitea reports no ready for review action, so its PRs open for review right away.
+The PR id is "gitea:<owner>/<repo>#<number>": a mirror of a GitHub repository has
+the same owner, name and PR numbers, the prefix keeps its PRs apart from GitHub ones.
+
+*/
+
+// giteaIDPrefix starts the ids of Gitea PRs, GitHub repository names cannot contain a colon
+const giteaIDPrefix = "gitea"
+
+type giteaPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+type Gitea struct {
+	secret []byte
+}
+
+func NewGitea(secret string) *Gitea {
+	return &Gitea{secret: []byte(secret)}
+}
+
+func (a *Gitea) Host() models.CodeHost {
+	return models.CodeHostGitea
+}
+
+func (a *Gitea) Verify(header http.Header, body []byte) error {
+
+	if len(a.secret) == 0 {
+		return fmt.Errorf("%w: no Gitea webhook secret is configured", apperrors.ErrInvalidSignature)
+	}
+
+	if !validHMAC(a.secret, body, header.Get("X-Gitea-Signature")) {
+		return apperrors.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (a *Gitea) Parse(header http.Header, body []byte) (*Change, error) {
+
+	change := &Change{Kind: ChangeIgnored, Event: header.Get("X-Gitea-Event")}
+
+	if change.Event != "pull_request" && change.Event != "pull_request_label" {
+		return change, nil
+	}
+
+	var payload giteaPullRequestEvent
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidWebhook, err)
+	}
+
+	if payload.Repository.FullName == "" || payload.Number <= 0 {
+		return nil, fmt.Errorf("%w: pull_request event without repository or number", apperrors.ErrInvalidWebhook)
+	}
+
+	pr := payload.PullRequest
+	labels := make([]string, 0, len(pr.Labels))
+
+	for _, label := range pr.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	change.Action = payload.Action
+	change.PullRequestID = fmt.Sprintf("%s:%s#%d", giteaIDPrefix, payload.Repository.FullName, payload.Number)
+	change.Title = pr.Title
+	change.AuthorLogin = pr.User.Login
+	change.Metadata = newMetadata(payload.Repository.FullName, pr.HTMLURL, pr.Base.Ref, labels, pr.Body)
+
+	switch payload.Action {
+	case "opened":
+		change.Kind = ChangeOpened
+	case "edited", "label_updated", "label_cleared":
+		change.Kind = ChangeEdited
+	case "closed":
+		change.Kind = ChangeClosed
+
+		if pr.Merged {
+			change.Kind = ChangeMerged
+		}
+	case "reopened":
+		change.Kind = ChangeReopened
+	}
+
+	return change, nil
+}
@@ -0,0 +1,132 @@
+package codehost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+GitHub adapter.
+A delivery is trusted only when its X-Hub-Signature-256 is "sha256=" and the HMAC-SHA256
+of the raw body keyed with the shared secret. Actions of pull_request events:
+
+- opened                     - ChangeOpened (a draft PR opens as a DRAFT)
+- ready_for_review           - ChangeReady
+- edited, labeled, unlabeled - ChangeEdited
+- closed                     - ChangeMerged for a merged PR, ChangeClosed otherwise
+- reopened                   - ChangeReopened
+
+The PR id is "<owner>/<repo>#<number>".
+
+*/
+
+const githubSignaturePrefix = "sha256="
+
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		Draft   bool   `json:"draft"`
+		Merged  bool   `json:"merged"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+type GitHub struct {
+	secret []byte
+}
+
+func NewGitHub(secret string) *GitHub {
+	return &GitHub{secret: []byte(secret)}
+}
+
+func (a *GitHub) Host() models.CodeHost {
+	return models.CodeHostGitHub
+}
+
+func (a *GitHub) Verify(header http.Header, body []byte) error {
+
+	if len(a.secret) == 0 {
+		return fmt.Errorf("%w: no GitHub webhook secret is configured", apperrors.ErrInvalidSignature)
+	}
+
+	signature, ok := strings.CutPrefix(header.Get("X-Hub-Signature-256"), githubSignaturePrefix)
+
+	if !ok || !validHMAC(a.secret, body, signature) {
+		return apperrors.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (a *GitHub) Parse(header http.Header, body []byte) (*Change, error) {
+
+	change := &Change{Kind: ChangeIgnored, Event: header.Get("X-GitHub-Event")}
+
+	if change.Event != "pull_request" {
+		return change, nil
+	}
+
+	var payload githubPullRequestEvent
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidWebhook, err)
+	}
+
+	if payload.Repository.FullName == "" || payload.Number <= 0 {
+		return nil, fmt.Errorf("%w: pull_request event without repository or number", apperrors.ErrInvalidWebhook)
+	}
+
+	pr := payload.PullRequest
+	labels := make([]string, 0, len(pr.Labels))
+
+	for _, label := range pr.Labels {
+		labels = append(labels, label.Name)
+	}
+
+	change.Action = payload.Action
+	change.PullRequestID = fmt.Sprintf("%s#%d", payload.Repository.FullName, payload.Number)
+	change.Title = pr.Title
+	change.AuthorLogin = pr.User.Login
+	change.Draft = pr.Draft
+	change.Metadata = newMetadata(payload.Repository.FullName, pr.HTMLURL, pr.Base.Ref, labels, pr.Body)
+
+	switch payload.Action {
+	case "opened":
+		change.Kind = ChangeOpened
+	case "ready_for_review":
+		change.Kind = ChangeReady
+	case "edited", "labeled", "unlabeled":
+		change.Kind = ChangeEdited
+	case "closed":
+		change.Kind = ChangeClosed
+
+		if pr.Merged {
+			change.Kind = ChangeMerged
+		}
+	case "reopened":
+		change.Kind = ChangeReopened
+	}
+
+	return change, nil
+}
@@ -0,0 +1,134 @@
+package codehost
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+GitLab adapter.
+GitLab does not sign deliveries, it sends the secret token of the webhook
+in X-Gitlab-Token, which must equal the configured one. Actions of Merge Request Hook events:
+
+- open    - ChangeOpened (a draft merge request opens as a DRAFT)
+- update  - ChangeReady when the draft flag was just cleared, ChangeEdited otherwise
+- merge   - ChangeMerged
+- close   - ChangeClosed
+- reopen  - ChangeReopened
+
+Approvals and other actions are ignored. The PR id is "<namespace>/<project>!<iid>".
+GitLab reports the author only by numeric id, so the author login is the username
+of the user who opened the merge request.
+
+*/
+
+type gitlabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		URL          string `json:"url"`
+		TargetBranch string `json:"target_branch"`
+		Action       string `json:"action"`
+		Draft        bool   `json:"draft"`
+	} `json:"object_attributes"`
+	Labels []struct {
+		Title string `json:"title"`
+	} `json:"labels"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+type GitLab struct {
+	token []byte
+}
+
+func NewGitLab(token string) *GitLab {
+	return &GitLab{token: []byte(token)}
+}
+
+func (a *GitLab) Host() models.CodeHost {
+	return models.CodeHostGitLab
+}
+
+func (a *GitLab) Verify(header http.Header, body []byte) error {
+
+	if len(a.token) == 0 {
+		return fmt.Errorf("%w: no GitLab webhook token is configured", apperrors.ErrInvalidSignature)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), a.token) != 1 {
+		return apperrors.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (a *GitLab) Parse(header http.Header, body []byte) (*Change, error) {
+
+	change := &Change{Kind: ChangeIgnored, Event: header.Get("X-Gitlab-Event")}
+
+	if change.Event != "Merge Request Hook" {
+		return change, nil
+	}
+
+	var payload gitlabMergeRequestEvent
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidWebhook, err)
+	}
+
+	if payload.Project.PathWithNamespace == "" || payload.ObjectAttributes.IID <= 0 {
+		return nil, fmt.Errorf("%w: merge request event without project or iid", apperrors.ErrInvalidWebhook)
+	}
+
+	mr := payload.ObjectAttributes
+	labels := make([]string, 0, len(payload.Labels))
+
+	for _, label := range payload.Labels {
+		labels = append(labels, label.Title)
+	}
+
+	change.Action = mr.Action
+	change.PullRequestID = fmt.Sprintf("%s!%d", payload.Project.PathWithNamespace, mr.IID)
+	change.Title = mr.Title
+	change.AuthorLogin = payload.User.Username
+	change.Draft = mr.Draft
+	change.Metadata = newMetadata(payload.Project.PathWithNamespace, mr.URL, mr.TargetBranch, labels, mr.Description)
+
+	switch mr.Action {
+	case "open":
+		change.Kind = ChangeOpened
+	case "update":
+		change.Kind = ChangeEdited
+
+		if draft := payload.Changes.Draft; draft != nil && draft.Previous && !draft.Current {
+			change.Kind = ChangeReady
+		}
+	case "merge":
+		change.Kind = ChangeMerged
+	case "close":
+		change.Kind = ChangeClosed
+	case "reopen":
+		change.Kind = ChangeReopened
+	}
+
+	return change, nil
+}
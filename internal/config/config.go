@@ -1,7 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 /*
@@ -11,6 +16,16 @@ Config struct contains settings for database connection and server operation:
 - DBHost, DBPort, DBUser, DBPassword, DBName - database connection parameters
 - ServerPort - port for HTTP server
 - LogLevel - logging level (e.g., debug, info, error)
+- AvailabilitySyncInterval - how often absences are applied to is_active flags (1m by default)
+- PendingRetryInterval - how often PRs waiting for reviewers are retried without a trigger (5m by default)
+- SLACheckInterval - how often reviews are checked against the teams' review SLA (5m by default)
+- WebhookDeliveryInterval - how often outbound webhook retries are sent when nothing new is queued (15s by default)
+- EventSinks - where outbox events are dispatched, a comma separated list of log, webhook and file ("log,webhook" by default)
+- EventFilePath - file the file sink appends events to, required when the file sink is on
+- OutboxDispatchInterval - how often outbox retries are dispatched when nothing new is saved (5s by default)
+- OutboxMaxAttempts - failed dispatches of an event before it is dead-lettered (10 by default)
+- GitHubWebhookSecret, GiteaWebhookSecret - secrets GitHub and Gitea sign webhook deliveries with,
+  GitLabWebhookToken - secret token GitLab sends with them; without one every delivery of the host is rejected
 
 Load() function creates a config by reading values from environment variables.
 
@@ -24,9 +39,72 @@ type Config struct {
 	DBName     string
 	ServerPort string
 	LogLevel   string
+
+	AvailabilitySyncInterval time.Duration
+	PendingRetryInterval     time.Duration
+	SLACheckInterval         time.Duration
+	WebhookDeliveryInterval  time.Duration
+	OutboxDispatchInterval   time.Duration
+
+	EventSinks        []string
+	EventFilePath     string
+	OutboxMaxAttempts int
+
+	GitHubWebhookSecret string
+	GitLabWebhookToken  string
+	GiteaWebhookSecret  string
 }
 
 func Load() (*Config, error) {
+
+	availabilitySyncInterval, err := durationEnv("AVAILABILITY_SYNC_INTERVAL", time.Minute)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pendingRetryInterval, err := durationEnv("PENDING_RETRY_INTERVAL", 5*time.Minute)
+
+	if err != nil {
+		return nil, err
+	}
+
+	slaCheckInterval, err := durationEnv("SLA_CHECK_INTERVAL", 5*time.Minute)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhookDeliveryInterval, err := durationEnv("WEBHOOK_DELIVERY_INTERVAL", 15*time.Second)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outboxDispatchInterval, err := durationEnv("OUTBOX_DISPATCH_INTERVAL", 5*time.Second)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outboxMaxAttempts, err := positiveIntEnv("OUTBOX_MAX_ATTEMPTS", 10)
+
+	if err != nil {
+		return nil, err
+	}
+
+	eventSinks, err := eventSinksEnv("EVENT_SINKS")
+
+	if err != nil {
+		return nil, err
+	}
+
+	eventFilePath := os.Getenv("EVENT_FILE_PATH")
+
+	if slices.Contains(eventSinks, "file") && eventFilePath == "" {
+		return nil, fmt.Errorf("EVENT_FILE_PATH is required for the file event sink")
+	}
+
 	return &Config{
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
@@ -35,5 +113,82 @@ func Load() (*Config, error) {
 		DBName:     os.Getenv("DB_NAME"),
 		ServerPort: os.Getenv("SERVER_PORT"),
 		LogLevel:   os.Getenv("LOG_LEVEL"),
+
+		AvailabilitySyncInterval: availabilitySyncInterval,
+		PendingRetryInterval:     pendingRetryInterval,
+		SLACheckInterval:         slaCheckInterval,
+		WebhookDeliveryInterval:  webhookDeliveryInterval,
+		OutboxDispatchInterval:   outboxDispatchInterval,
+
+		EventSinks:        eventSinks,
+		EventFilePath:     eventFilePath,
+		OutboxMaxAttempts: outboxMaxAttempts,
+
+		GitHubWebhookSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		GitLabWebhookToken:  os.Getenv("GITLAB_WEBHOOK_TOKEN"),
+		GiteaWebhookSecret:  os.Getenv("GITEA_WEBHOOK_SECRET"),
 	}, nil
 }
+
+// reads a positive duration like "30s" or "5m" from the environment
+func durationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+
+	return duration, nil
+}
+
+// reads a positive integer from the environment
+func positiveIntEnv(key string, defaultValue int) (int, error) {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid %s: %q", key, value)
+	}
+
+	return number, nil
+}
+
+// reads a comma separated list of event sinks, each listed once
+func eventSinksEnv(key string) ([]string, error) {
+
+	value := os.Getenv(key)
+
+	if value == "" {
+		return []string{"log", "webhook"}, nil
+	}
+
+	sinks := []string{}
+
+	for _, sink := range strings.Split(value, ",") {
+
+		sink = strings.TrimSpace(sink)
+
+		if sink != "log" && sink != "webhook" && sink != "file" {
+			return nil, fmt.Errorf("invalid %s: unknown sink %q", key, sink)
+		}
+
+		if !slices.Contains(sinks, sink) {
+			sinks = append(sinks, sink)
+		}
+	}
+
+	return sinks, nil
+}
@@ -19,6 +19,50 @@ var (
 	ErrPRMerged     = errors.New("cannot reassign on merged PR")
 	ErrNotAssigned  = errors.New("reviewer is not assigned to this PR")
 	ErrNoCandidate  = errors.New("no active replacement candidate in team")
+
+	ErrAlreadyAssigned = errors.New("user is already a reviewer of this PR")
+	ErrReviewerLimit   = errors.New("PR already has the reviewer count required by team")
+
+	ErrAbsenceNotFound = errors.New("absence not found")
+	ErrAbsenceClosed   = errors.New("absence already ended or cancelled")
+
+	ErrNotEnoughReviewers = errors.New("not enough active reviewer candidates for team policy")
+	ErrTagNotCovered      = errors.New("no active reviewer candidate has required tag")
+	ErrReviewerCapReached = errors.New("every reviewer candidate is at the open review cap")
+
+	ErrNotApproved = errors.New("PR lacks the approvals required by team")
+	ErrNoApprovers = errors.New("PR has no reviewers to approve it")
+
+	ErrInvalidTransition = errors.New("illegal PR status transition")
+	ErrPRNotOpen         = errors.New("PR is not open for review")
+
+	ErrCodeHostNotFound = errors.New("unknown code host")
+	ErrLoginNotMapped   = errors.New("code host login is not mapped to a user")
+	ErrInvalidSignature = errors.New("webhook signature or token does not match")
+
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrOutboxEventNotFound  = errors.New("dead outbox event not found")
+)
+
+// Validation errors
+var (
+	ErrInvalidStrategy  = errors.New("unknown reviewer strategy")
+	ErrInvalidSettings  = errors.New("invalid team settings")
+	ErrInvalidOwnership = errors.New("invalid ownership rules")
+	ErrInvalidTag       = errors.New("invalid tag")
+	ErrInvalidAbsence   = errors.New("invalid absence period")
+
+	ErrInvalidWorkingHours = errors.New("invalid working hours")
+	ErrInvalidReviewer     = errors.New("user cannot review this PR")
+	ErrInvalidSize         = errors.New("invalid PR size or review weight")
+	ErrInvalidReviewCap    = errors.New("invalid open review cap")
+	ErrInvalidReviewState  = errors.New("invalid review state")
+	ErrInvalidMetadata     = errors.New("invalid PR metadata")
+	ErrInvalidLogin        = errors.New("invalid code host login")
+	ErrInvalidWebhook      = errors.New("invalid webhook payload")
+	ErrInvalidSubscription = errors.New("invalid webhook subscription")
+	ErrInvalidAuditFilter  = errors.New("invalid audit filter")
 )
 
 // Error codes for API responses
@@ -26,17 +70,43 @@ type ErrorCode string
 
 const (
 	// CodeTeamExists indicates that a team already exists
-	CodeTeamExists  ErrorCode = "TEAM_EXISTS"
+	CodeTeamExists ErrorCode = "TEAM_EXISTS"
 	// CodePRExists indicates that a pull request already exists
-	CodePRExists    ErrorCode = "PR_EXISTS"
+	CodePRExists ErrorCode = "PR_EXISTS"
 	// CodePRMerged indicates that a pull request is already merged
-	CodePRMerged    ErrorCode = "PR_MERGED"
+	CodePRMerged ErrorCode = "PR_MERGED"
 	// CodeNotAssigned indicates that a reviewer is not assigned to the PR
 	CodeNotAssigned ErrorCode = "NOT_ASSIGNED"
 	// CodeNoCandidate indicates no active replacement candidate in team
 	CodeNoCandidate ErrorCode = "NO_CANDIDATE"
 	// CodeNotFound indicates the requested resource was not found
-	CodeNotFound    ErrorCode = "NOT_FOUND"
+	CodeNotFound ErrorCode = "NOT_FOUND"
+	// CodeNotEnoughReviewers indicates the team cannot staff a PR with the required reviewer count
+	CodeNotEnoughReviewers ErrorCode = "NOT_ENOUGH_REVIEWERS"
+	// CodeTagNotCovered indicates that nobody can review a required tag of the PR
+	CodeTagNotCovered ErrorCode = "TAG_NOT_COVERED"
+	// CodeAlreadyAssigned indicates that the user already reviews the PR
+	CodeAlreadyAssigned ErrorCode = "ALREADY_ASSIGNED"
+	// CodeReviewerLimit indicates that the PR already has enough reviewers
+	CodeReviewerLimit ErrorCode = "REVIEWER_LIMIT"
+	// CodeReviewerCapReached indicates that every candidate is at its open review cap
+	CodeReviewerCapReached ErrorCode = "REVIEWER_CAP_REACHED"
+	// CodeNotApproved indicates that the PR cannot be merged without more approvals
+	CodeNotApproved ErrorCode = "NOT_APPROVED"
+	// CodeNoApprovers indicates that the PR needs approvals but nobody reviews it
+	CodeNoApprovers ErrorCode = "NO_APPROVERS"
+	// CodeInvalidTransition indicates that the PR cannot move to the requested status
+	CodeInvalidTransition ErrorCode = "INVALID_TRANSITION"
+	// CodePRNotOpen indicates that reviewers of a DRAFT or CLOSED PR cannot change
+	CodePRNotOpen ErrorCode = "PR_NOT_OPEN"
+	// CodeAbsenceClosed indicates that an absence cannot be changed anymore
+	CodeAbsenceClosed ErrorCode = "ABSENCE_CLOSED"
+	// CodeLoginNotMapped indicates that a code host account has no user of the service
+	CodeLoginNotMapped ErrorCode = "LOGIN_NOT_MAPPED"
+	// CodeInvalidSignature indicates that a webhook delivery carries no valid signature or token of the code host
+	CodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+	// CodeInvalidRequest indicates that the request contains invalid values
+	CodeInvalidRequest ErrorCode = "INVALID_REQUEST"
 )
 
 // Mapping errors to codes for HTTP responses
@@ -52,7 +122,40 @@ func GetErrorCode(err error) ErrorCode {
 		return CodeNotAssigned
 	case errors.Is(err, ErrNoCandidate):
 		return CodeNoCandidate
-	case errors.Is(err, ErrTeamNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrPRNotFound):
+	case errors.Is(err, ErrNotEnoughReviewers):
+		return CodeNotEnoughReviewers
+	case errors.Is(err, ErrTagNotCovered):
+		return CodeTagNotCovered
+	case errors.Is(err, ErrAlreadyAssigned):
+		return CodeAlreadyAssigned
+	case errors.Is(err, ErrReviewerLimit):
+		return CodeReviewerLimit
+	case errors.Is(err, ErrReviewerCapReached):
+		return CodeReviewerCapReached
+	case errors.Is(err, ErrNotApproved):
+		return CodeNotApproved
+	case errors.Is(err, ErrNoApprovers):
+		return CodeNoApprovers
+	case errors.Is(err, ErrInvalidTransition):
+		return CodeInvalidTransition
+	case errors.Is(err, ErrPRNotOpen):
+		return CodePRNotOpen
+	case errors.Is(err, ErrAbsenceClosed):
+		return CodeAbsenceClosed
+	case errors.Is(err, ErrLoginNotMapped):
+		return CodeLoginNotMapped
+	case errors.Is(err, ErrInvalidSignature):
+		return CodeInvalidSignature
+	case errors.Is(err, ErrInvalidStrategy), errors.Is(err, ErrInvalidSettings), errors.Is(err, ErrInvalidOwnership),
+		errors.Is(err, ErrInvalidTag), errors.Is(err, ErrInvalidAbsence),
+		errors.Is(err, ErrInvalidWorkingHours), errors.Is(err, ErrInvalidReviewer), errors.Is(err, ErrInvalidSize),
+		errors.Is(err, ErrInvalidReviewCap), errors.Is(err, ErrInvalidReviewState), errors.Is(err, ErrInvalidMetadata),
+		errors.Is(err, ErrInvalidLogin), errors.Is(err, ErrInvalidWebhook), errors.Is(err, ErrInvalidSubscription),
+		errors.Is(err, ErrInvalidAuditFilter):
+		return CodeInvalidRequest
+	case errors.Is(err, ErrTeamNotFound), errors.Is(err, ErrUserNotFound), errors.Is(err, ErrPRNotFound),
+		errors.Is(err, ErrAbsenceNotFound), errors.Is(err, ErrCodeHostNotFound),
+		errors.Is(err, ErrSubscriptionNotFound), errors.Is(err, ErrDeliveryNotFound), errors.Is(err, ErrOutboxEventNotFound):
 		return CodeNotFound
 	default:
 		return CodeNotFound
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+)
+
+/*
+
+Audit handler for the audit log of changes, read only.
+Pages go from the latest entry back, the next page is asked with cursor=next_cursor.
+
+*/
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListEntries lists the audit log, actor, action, entity_type, entity_id, from and to (RFC 3339) narrow it
+func (h *AuditHandler) ListEntries(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	filter := models.AuditFilter{
+		Actor:      query.Get("actor"),
+		Action:     models.AuditAction(query.Get("action")),
+		EntityType: models.AuditEntity(query.Get("entity_type")),
+		EntityID:   query.Get("entity_id"),
+	}
+
+	var err error
+
+	if filter.From, err = timeParam(query.Get("from")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be an RFC 3339 time")
+		return
+	}
+
+	if filter.To, err = timeParam(query.Get("to")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "to must be an RFC 3339 time")
+		return
+	}
+
+	if value := query.Get("cursor"); value != "" {
+
+		cursor, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || cursor < 1 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "cursor must be a next_cursor of the audit log")
+			return
+		}
+
+		filter.Cursor = cursor
+	}
+
+	if value := query.Get("limit"); value != "" {
+
+		limit, err := strconv.Atoi(value)
+
+		if err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a positive integer")
+			return
+		}
+
+		filter.Limit = limit
+	}
+
+	page, err := h.auditService.List(r.Context(), filter)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+// parses an optional RFC 3339 time, the audit log keeps UTC times
+func timeParam(value string) (*time.Time, error) {
+
+	if value == "" {
+		return nil, nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	at = at.UTC()
+
+	return &at, nil
+}
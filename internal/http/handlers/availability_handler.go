@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+)
+
+/*
+
+Availability handler for out-of-office schedules.
+Handles listing, creating and cancelling user absences.
+
+*/
+
+type AvailabilityHandler struct {
+	availabilityService *service.AvailabilityService
+}
+
+func NewAvailabilityHandler(availabilityService *service.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{
+		availabilityService: availabilityService,
+	}
+}
+
+func (h *AvailabilityHandler) ListAbsences(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.URL.Query().Get("user_id")
+
+	if userID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required")
+		return
+	}
+
+	includePast := false
+
+	if value := r.URL.Query().Get("include_past"); value != "" {
+
+		parsed, err := strconv.ParseBool(value)
+
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "include_past must be a boolean")
+			return
+		}
+
+		includePast = parsed
+	}
+
+	absences, err := h.availabilityService.ListAbsences(r.Context(), userID, includePast)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user_id":  userID,
+		"absences": absences,
+	})
+}
+
+func (h *AvailabilityHandler) CreateAbsence(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		UserID   string    `json:"user_id"`
+		StartsAt time.Time `json:"starts_at"`
+		EndsAt   time.Time `json:"ends_at"`
+		Reason   string    `json:"reason"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	absence, err := h.availabilityService.CreateAbsence(r.Context(), req.UserID, req.StartsAt, req.EndsAt, req.Reason)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]any{"absence": absence})
+}
+
+func (h *AvailabilityHandler) CancelAbsence(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		AbsenceID int64 `json:"absence_id"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil || req.AbsenceID == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	absence, err := h.availabilityService.CancelAbsence(r.Context(), req.AbsenceID)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"absence": absence})
+}
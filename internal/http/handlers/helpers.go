@@ -21,8 +21,8 @@ func respondJSON(w http.ResponseWriter, status int, data any) {
 	err := json.NewEncoder(w).Encode(data)
 
 	if err != nil {
-        log.Printf("Error encoding response: %v", err)
-    }
+		log.Printf("Error encoding response: %v", err)
+	}
 }
 
 func respondError(w http.ResponseWriter, status int, code, message string) {
@@ -41,14 +41,21 @@ func handleServiceError(w http.ResponseWriter, err error) {
 	var status int
 
 	switch code {
-	case apperrors.CodeTeamExists:
+	case apperrors.CodeTeamExists, apperrors.CodeInvalidRequest:
 		status = http.StatusBadRequest
 	case apperrors.CodePRExists:
 		status = http.StatusConflict
-	case apperrors.CodePRMerged, apperrors.CodeNotAssigned, apperrors.CodeNoCandidate:
+	case apperrors.CodePRMerged, apperrors.CodeNotAssigned, apperrors.CodeNoCandidate,
+		apperrors.CodeNotEnoughReviewers, apperrors.CodeTagNotCovered, apperrors.CodeAbsenceClosed,
+		apperrors.CodeAlreadyAssigned, apperrors.CodeReviewerLimit, apperrors.CodeReviewerCapReached,
+		apperrors.CodeNotApproved, apperrors.CodeNoApprovers, apperrors.CodeInvalidTransition, apperrors.CodePRNotOpen:
 		status = http.StatusConflict
 	case apperrors.CodeNotFound:
 		status = http.StatusNotFound
+	case apperrors.CodeLoginNotMapped:
+		status = http.StatusUnprocessableEntity
+	case apperrors.CodeInvalidSignature:
+		status = http.StatusUnauthorized
 	default:
 		status = http.StatusInternalServerError
 		code = "INTERNAL_ERROR"
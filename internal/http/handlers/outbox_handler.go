@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+)
+
+/*
+
+Outbox handler for the dead letters: events no sink accepted in OUTBOX_MAX_ATTEMPTS
+attempts and requeuing them once the sink is fixed.
+
+*/
+
+type OutboxHandler struct {
+	outboxService *service.OutboxService
+}
+
+func NewOutboxHandler(outboxService *service.OutboxService) *OutboxHandler {
+	return &OutboxHandler{outboxService: outboxService}
+}
+
+func (h *OutboxHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+
+	events, err := h.outboxService.ListDeadLetters(r.Context())
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"events": events})
+}
+
+// Requeue dispatches a dead event again and responds with the remaining dead letters
+func (h *OutboxHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		EventID int64 `json:"event_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.EventID == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if err := h.outboxService.Requeue(r.Context(), req.EventID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	h.ListDeadLetters(w, r)
+}
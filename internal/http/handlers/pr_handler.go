@@ -1,16 +1,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
 )
 
 /*
 
 PR handler for managing pull requests.
-Handles PR creation, merging and reviewer reassignment with proper error handling.
+Handles PR creation and preview, metadata updates and listing, merging, reviewer reassignment,
+manual reviewer changes and assignment explanations with proper error handling.
 
 */
 
@@ -24,11 +28,7 @@ func NewPRHandler(prService *service.PRService) *PRHandler {
 
 func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 
-	var req struct {
-		PullRequestID   string `json:"pull_request_id"`
-		PullRequestName string `json:"pull_request_name"`
-		AuthorID        string `json:"author_id"`
-	}
+	var req service.CreatePRRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 
@@ -37,7 +37,7 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.prService.CreatePR(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID)
+	pr, err := h.prService.CreatePR(r.Context(), req)
 
 	if err != nil {
 		handleServiceError(w, err)
@@ -47,7 +47,47 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusCreated, map[string]any{"pr": pr})
 }
 
+// PreviewPR shows the reviewers /pullRequest/create would assign, without registering the PR
+func (h *PRHandler) PreviewPR(w http.ResponseWriter, r *http.Request) {
+
+	var req service.CreatePRRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, trace, err := h.prService.PreviewPR(r.Context(), req)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"pr":    pr,
+		"trace": trace,
+	})
+}
+
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.prService.MergePR)
+}
+
+func (h *PRHandler) MarkReady(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.prService.MarkReady)
+}
+
+func (h *PRHandler) ClosePR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.prService.ClosePR)
+}
+
+func (h *PRHandler) ReopenPR(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.prService.ReopenPR)
+}
+
+// decodes {pull_request_id}, applies the status change and responds with the PR
+func (h *PRHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(context.Context, string) (*models.PullRequest, error)) {
 
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
@@ -58,7 +98,7 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pr, err := h.prService.MergePR(r.Context(), req.PullRequestID)
+	pr, err := change(r.Context(), req.PullRequestID)
 
 	if err != nil {
 		handleServiceError(w, err)
@@ -73,6 +113,7 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
 		OldUserID     string `json:"old_user_id"`
+		NewUserID     string `json:"new_user_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -80,6 +121,23 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// An explicitly chosen replacement skips the strategy
+	if req.NewUserID != "" {
+
+		pr, err := h.prService.ReassignReviewerTo(r.Context(), req.PullRequestID, req.OldUserID, req.NewUserID)
+
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, map[string]any{
+			"pr":          pr,
+			"replaced_by": req.NewUserID,
+		})
+		return
+	}
+
 	pr, replacedBy, err := h.prService.ReassignReviewer(r.Context(), req.PullRequestID, req.OldUserID)
 
 	if err != nil {
@@ -92,3 +150,158 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		"replaced_by": replacedBy,
 	})
 }
+
+// ExplainAssignment returns the decision traces of the PR's reviewer changes
+func (h *PRHandler) ExplainAssignment(w http.ResponseWriter, r *http.Request) {
+
+	prID := r.URL.Query().Get("pull_request_id")
+
+	if prID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	traces, err := h.prService.ExplainAssignment(r.Context(), prID)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"pull_request_id": prID,
+		"traces":          traces,
+	})
+}
+
+// UpdateMetadata changes the name, repository, url, base branch, labels or description of a PR
+func (h *PRHandler) UpdateMetadata(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		service.PRMetadataUpdate
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PullRequestID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := h.prService.UpdateMetadata(r.Context(), req.PullRequestID, req.PRMetadataUpdate)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
+
+// ListPRs lists PRs newest first, repository, label, status and author_id narrow them
+func (h *PRHandler) ListPRs(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	filter := models.PRFilter{
+		Repository: query.Get("repository"),
+		Label:      query.Get("label"),
+		Status:     models.PRStatus(query.Get("status")),
+		AuthorID:   query.Get("author_id"),
+	}
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "status must be DRAFT, OPEN, MERGED or CLOSED")
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+
+		limit, err := strconv.Atoi(value)
+
+		if err != nil || limit < 1 || limit > models.MaxPRListLimit {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be between 1 and "+strconv.Itoa(models.MaxPRListLimit))
+			return
+		}
+
+		filter.Limit = limit
+	}
+
+	prs, err := h.prService.ListPRs(r.Context(), filter)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"prs": prs})
+}
+
+// SubmitReview records APPROVED, CHANGES_REQUESTED or COMMENTED of an assigned reviewer
+func (h *PRHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		PullRequestID string             `json:"pull_request_id"`
+		UserID        string             `json:"user_id"`
+		State         models.ReviewState `json:"state"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := h.prService.SubmitReview(r.Context(), req.PullRequestID, req.UserID, req.State)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
+
+// ListPending lists OPEN PRs waiting for reviewers, team_name narrows them to the authors' team
+func (h *PRHandler) ListPending(w http.ResponseWriter, r *http.Request) {
+
+	pending, err := h.prService.ListPending(r.Context(), r.URL.Query().Get("team_name"))
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"pending": pending,
+	})
+}
+
+func (h *PRHandler) AddReviewer(w http.ResponseWriter, r *http.Request) {
+	h.changeReviewer(w, r, h.prService.AddReviewer)
+}
+
+func (h *PRHandler) RemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	h.changeReviewer(w, r, h.prService.RemoveReviewer)
+}
+
+// decodes {pull_request_id, user_id}, applies the change and responds with the PR
+func (h *PRHandler) changeReviewer(w http.ResponseWriter, r *http.Request, change func(context.Context, string, string) (*models.PullRequest, error)) {
+
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		UserID        string `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	pr, err := change(r.Context(), req.PullRequestID, req.UserID)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
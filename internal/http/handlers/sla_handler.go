@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+)
+
+/*
+
+SLA handler for reviews that missed the review SLA of their team.
+Breaches are found by the SLA worker, the handler only lists them.
+
+*/
+
+type SLAHandler struct {
+	slaService *service.SLAService
+}
+
+func NewSLAHandler(slaService *service.SLAService) *SLAHandler {
+	return &SLAHandler{slaService: slaService}
+}
+
+// ListBreaches lists breaches latest first, team_name, user_id, pull_request_id and since (RFC 3339) narrow them
+func (h *SLAHandler) ListBreaches(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	filter := models.SLABreachFilter{
+		TeamName:      query.Get("team_name"),
+		UserID:        query.Get("user_id"),
+		PullRequestID: query.Get("pull_request_id"),
+	}
+
+	if value := query.Get("since"); value != "" {
+
+		since, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "since must be an RFC 3339 time")
+			return
+		}
+
+		filter.Since = since
+	}
+
+	breaches, err := h.slaService.ListBreaches(r.Context(), filter)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"breaches": breaches})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+)
+
+/*
+
+Subscription handler for outbound webhooks: subscriptions, their delivery log and replays.
+Deliveries themselves are sent by the delivery worker.
+
+*/
+
+type SubscriptionHandler struct {
+	outboundService *service.OutboundWebhookService
+}
+
+func NewSubscriptionHandler(outboundService *service.OutboundWebhookService) *SubscriptionHandler {
+	return &SubscriptionHandler{outboundService: outboundService}
+}
+
+// Subscribe registers a URL for event types, the secret is only shown in this response
+func (h *SubscriptionHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		URL    string             `json:"url"`
+		Events []models.EventType `json:"events"`
+		Secret string             `json:"secret"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	subscription, err := h.outboundService.Subscribe(r.Context(), req.URL, req.Events, req.Secret)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]any{"subscription": subscription, "secret": subscription.Secret})
+}
+
+func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+
+	subscriptions, err := h.outboundService.ListSubscriptions(r.Context())
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"subscriptions": subscriptions})
+}
+
+// Unsubscribe drops a subscription and responds with the remaining ones
+func (h *SubscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		SubscriptionID int64 `json:"subscription_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SubscriptionID == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if err := h.outboundService.Unsubscribe(r.Context(), req.SubscriptionID); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	h.ListSubscriptions(w, r)
+}
+
+// ListDeliveries lists the delivery log latest first, subscription_id, event_type, status and limit narrow it
+func (h *SubscriptionHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+
+	filter := models.WebhookDeliveryFilter{
+		EventType: models.EventType(query.Get("event_type")),
+		Status:    models.DeliveryStatus(query.Get("status")),
+	}
+
+	if value := query.Get("subscription_id"); value != "" {
+
+		subscriptionID, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || subscriptionID < 1 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "subscription_id must be a positive integer")
+			return
+		}
+
+		filter.SubscriptionID = subscriptionID
+	}
+
+	if value := query.Get("limit"); value != "" {
+
+		limit, err := strconv.Atoi(value)
+
+		if err != nil || limit < 1 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a positive integer")
+			return
+		}
+
+		filter.Limit = limit
+	}
+
+	deliveries, err := h.outboundService.ListDeliveries(r.Context(), filter)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"deliveries": deliveries})
+}
+
+// Replay queues a delivery again, the response is the new delivery
+func (h *SubscriptionHandler) Replay(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		DeliveryID int64 `json:"delivery_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeliveryID == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	delivery, err := h.outboundService.Replay(r.Context(), req.DeliveryID)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]any{"delivery": delivery})
+}
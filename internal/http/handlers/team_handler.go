@@ -11,7 +11,9 @@ import (
 /*
 
 Team handler for team management operations.
-Handles team creation and retrieval with member management.
+Handles team creation and retrieval with member management,
+switching the team's reviewer selection strategy, team settings
+and CODEOWNERS-style ownership rules.
 
 */
 
@@ -26,8 +28,9 @@ func NewTeamHandler(teamService *service.TeamService) *TeamHandler {
 func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
-		TeamName string              `json:"team_name"`
-		Members  []models.TeamMember `json:"members"`
+		TeamName         string                  `json:"team_name"`
+		Members          []models.TeamMember     `json:"members"`
+		ReviewerStrategy models.ReviewerStrategy `json:"reviewer_strategy"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -35,7 +38,7 @@ func (h *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := h.teamService.CreateTeam(r.Context(), req.TeamName, req.Members)
+	team, err := h.teamService.CreateTeam(r.Context(), req.TeamName, req.Members, req.ReviewerStrategy)
 
 	if err != nil {
 		handleServiceError(w, err)
@@ -60,3 +63,113 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, team)
 }
+
+func (h *TeamHandler) SetStrategy(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		TeamName         string                  `json:"team_name"`
+		ReviewerStrategy models.ReviewerStrategy `json:"reviewer_strategy"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	team, err := h.teamService.SetStrategy(r.Context(), req.TeamName, req.ReviewerStrategy)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"team": team})
+}
+
+func (h *TeamHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+
+	teamName := r.URL.Query().Get("team_name")
+
+	if teamName == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	settings, err := h.teamService.GetSettings(r.Context(), teamName)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"settings": settings})
+}
+
+func (h *TeamHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		TeamName string `json:"team_name"`
+		service.TeamSettingsUpdate
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	settings, err := h.teamService.UpdateSettings(r.Context(), req.TeamName, req.TeamSettingsUpdate)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"settings": settings})
+}
+
+func (h *TeamHandler) GetOwnership(w http.ResponseWriter, r *http.Request) {
+
+	teamName := r.URL.Query().Get("team_name")
+
+	if teamName == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "team_name is required")
+		return
+	}
+
+	rules, err := h.teamService.GetOwnershipRules(r.Context(), teamName)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"team_name": teamName,
+		"rules":     rules,
+	})
+}
+
+func (h *TeamHandler) SetOwnership(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		TeamName   string `json:"team_name"`
+		Codeowners string `json:"codeowners"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	rules, err := h.teamService.SetOwnershipRules(r.Context(), req.TeamName, req.Codeowners)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"team_name": req.TeamName,
+		"rules":     rules,
+	})
+}
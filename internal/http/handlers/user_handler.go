@@ -1,16 +1,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
 )
 
 /*
 
 User handler for user management and review tracking.
-Handles user activation status and review history retrieval.
+Handles user activation status, review history retrieval, expertise tags
+and working hours.
 
 */
 
@@ -27,8 +31,9 @@ func NewUserHandler(userService *service.UserService) *UserHandler {
 func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
-		UserID   string `json:"user_id"`
-		IsActive bool   `json:"is_active"`
+		UserID              string `json:"user_id"`
+		IsActive            bool   `json:"is_active"`
+		ReassignOpenReviews bool   `json:"reassign_open_reviews"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -38,6 +43,32 @@ func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !req.IsActive && req.ReassignOpenReviews {
+
+		user, report, err := h.userService.Deactivate(r.Context(), req.UserID)
+
+		// The user is off already, the PRs handed over so far are reported with the error
+		if err != nil && report != nil {
+			respondJSON(w, http.StatusMultiStatus, map[string]any{
+				"user":         user,
+				"reassignment": report,
+				"error":        map[string]string{"code": "INTERNAL_ERROR", "message": err.Error()},
+			})
+			return
+		}
+
+		if err != nil {
+			handleServiceError(w, err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, map[string]any{
+			"user":         user,
+			"reassignment": report,
+		})
+		return
+	}
+
 	user, err := h.userService.SetIsActive(r.Context(), req.UserID, req.IsActive)
 
 	if err != nil {
@@ -57,7 +88,9 @@ func (h *UserHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := h.userService.GetUserReviews(r.Context(), userID)
+	state := models.ReviewState(r.URL.Query().Get("review_state"))
+
+	prs, err := h.userService.GetUserReviews(r.Context(), userID, state)
 
 	if err != nil {
 		handleServiceError(w, err)
@@ -66,20 +99,26 @@ func (h *UserHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
 
 	// PullRequest -> PRShort
 	type PRShort struct {
-		PullRequestID   string `json:"pull_request_id"`
-		PullRequestName string `json:"pull_request_name"`
-		AuthorID        string `json:"author_id"`
-		Status          string `json:"status"`
+		PullRequestID   string             `json:"pull_request_id"`
+		PullRequestName string             `json:"pull_request_name"`
+		AuthorID        string             `json:"author_id"`
+		Status          string             `json:"status"`
+		ReviewState     models.ReviewState `json:"review_state"`
+		ReviewedAt      *time.Time         `json:"reviewed_at,omitempty"`
 	}
 
 	shortPRs := make([]PRShort, len(prs))
 
 	for i, pr := range prs {
+		review := pr.ReviewOf(userID)
+
 		shortPRs[i] = PRShort{
 			PullRequestID:   pr.PullRequestID,
 			PullRequestName: pr.PullRequestName,
 			AuthorID:        pr.AuthorID,
 			Status:          string(pr.Status),
+			ReviewState:     review.State,
+			ReviewedAt:      review.SubmittedAt,
 		}
 	}
 
@@ -88,3 +127,114 @@ func (h *UserHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
 		"pull_requests": shortPRs,
 	})
 }
+
+func (h *UserHandler) SetWorkingHours(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		UserID       string              `json:"user_id"`
+		Timezone     string              `json:"timezone"`
+		WorkingHours models.WorkingHours `json:"working_hours"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	user, err := h.userService.SetWorkingHours(r.Context(), req.UserID, req.Timezone, req.WorkingHours)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+func (h *UserHandler) SetReviewCap(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		UserID         string `json:"user_id"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	user, err := h.userService.SetReviewCap(r.Context(), req.UserID, req.MaxOpenReviews)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+func (h *UserHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.URL.Query().Get("user_id")
+
+	if userID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id is required")
+		return
+	}
+
+	tags, err := h.userService.GetTags(r.Context(), userID)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user_id": userID,
+		"tags":    tags,
+	})
+}
+
+func (h *UserHandler) AddTags(w http.ResponseWriter, r *http.Request) {
+	h.changeTags(w, r, h.userService.AddTags)
+}
+
+func (h *UserHandler) RemoveTags(w http.ResponseWriter, r *http.Request) {
+	h.changeTags(w, r, h.userService.RemoveTags)
+}
+
+func (h *UserHandler) SetTags(w http.ResponseWriter, r *http.Request) {
+	h.changeTags(w, r, h.userService.SetTags)
+}
+
+// decodes {user_id, tags}, applies the change and responds with the resulting tags
+func (h *UserHandler) changeTags(w http.ResponseWriter, r *http.Request, change func(context.Context, string, []string) ([]string, error)) {
+
+	var req struct {
+		UserID string   `json:"user_id"`
+		Tags   []string `json:"tags"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+
+	if err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	tags, err := change(r.Context(), req.UserID, req.Tags)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{
+		"user_id": req.UserID,
+		"tags":    tags,
+	})
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+/*
+
+Webhook handler for deliveries of code hosts and the login mapping they rely on.
+The code host comes from the path (/webhooks/github, /webhooks/gitlab, /webhooks/gitea),
+the raw body and headers are handed to the service as is, signatures are computed over them.
+
+*/
+
+// No code host sends payloads larger than 25 MB
+const maxWebhookBodySize = 25 << 20
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// Deliver applies a delivery of the code host named in the path
+func (h *WebhookHandler) Deliver(w http.ResponseWriter, r *http.Request) {
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+
+	if err != nil {
+
+		var tooLarge *http.MaxBytesError
+
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "INVALID_REQUEST", "payload is too large")
+			return
+		}
+
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "cannot read payload")
+		return
+	}
+
+	// Changes of a delivery are made by the code host
+	ctx := service.WithActor(r.Context(), "webhook:"+strings.ToLower(chi.URLParam(r, "code_host")))
+
+	result, err := h.webhookService.HandleDelivery(ctx, codeHostParam(r), r.Header, body)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (h *WebhookHandler) ListLogins(w http.ResponseWriter, r *http.Request) {
+
+	logins, err := h.webhookService.ListLogins(r.Context(), codeHostParam(r))
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"logins": logins})
+}
+
+// SetLogin maps a login of the code host to a user, replacing the user it was mapped to
+func (h *WebhookHandler) SetLogin(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		Login  string `json:"login"`
+		UserID string `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	login, err := h.webhookService.SaveLogin(r.Context(), codeHostParam(r), req.Login, req.UserID)
+
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]any{"login": login})
+}
+
+// RemoveLogin drops the mapping of a login and responds with the remaining ones
+func (h *WebhookHandler) RemoveLogin(w http.ResponseWriter, r *http.Request) {
+
+	var req struct {
+		Login string `json:"login"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Login == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if err := h.webhookService.RemoveLogin(r.Context(), codeHostParam(r), req.Login); err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	h.ListLogins(w, r)
+}
+
+// code host named in the path, "github" stands for GITHUB
+func codeHostParam(r *http.Request) models.CodeHost {
+	return models.CodeHost(strings.ToUpper(chi.URLParam(r, "code_host")))
+}
@@ -0,0 +1,17 @@
+package custom_middleware
+
+import (
+	"net/http"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+)
+
+// Actor puts the X-Actor header of the request into its context, the audit log
+// records it as the one who made the changes. Callers are not authenticated,
+// so the header is recorded as a claim
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := service.WithClaimedActor(r.Context(), r.Header.Get("X-Actor"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
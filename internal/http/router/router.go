@@ -12,43 +12,100 @@ import (
 /*
 
 HTTP router setup with middleware and route definitions.
-Configures all API endpoints with logging and recovery middleware.
+Configures all API endpoints with logging, recovery and actor (X-Actor for the audit log) middleware.
 
 */
 
 func New(teamService *service.TeamService, userService *service.UserService,
-	prService *service.PRService, statsService *service.StatsService) http.Handler {
+	prService *service.PRService, statsService *service.StatsService,
+	availabilityService *service.AvailabilityService, slaService *service.SLAService,
+	webhookService *service.WebhookService, outboundService *service.OutboundWebhookService,
+	outboxService *service.OutboxService, auditService *service.AuditService) http.Handler {
 
 	r := chi.NewRouter()
 
 	r.Use(custom_middleware.Recovery)
 	r.Use(custom_middleware.Logger)
+	r.Use(custom_middleware.Actor)
 
 	// handlers
 	teamHandler := handler.NewTeamHandler(teamService)
 	userHandler := handler.NewUserHandler(userService)
 	prHandler := handler.NewPRHandler(prService)
 	statsHandler := handler.NewStatsHandler(statsService)
+	availabilityHandler := handler.NewAvailabilityHandler(availabilityService)
+	slaHandler := handler.NewSLAHandler(slaService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	subscriptionHandler := handler.NewSubscriptionHandler(outboundService)
+	outboxHandler := handler.NewOutboxHandler(outboxService)
+	auditHandler := handler.NewAuditHandler(auditService)
 	healthHandler := handler.NewHealthHandler()
 
 	// routes
 	r.Route("/team", func(r chi.Router) {
 		r.Post("/add", teamHandler.CreateTeam)
 		r.Get("/get", teamHandler.GetTeam)
+		r.Post("/setStrategy", teamHandler.SetStrategy)
+		r.Get("/settings", teamHandler.GetSettings)
+		r.Post("/settings", teamHandler.UpdateSettings)
+		r.Get("/ownership", teamHandler.GetOwnership)
+		r.Post("/ownership", teamHandler.SetOwnership)
 	})
 
 	r.Route("/users", func(r chi.Router) {
 		r.Post("/setIsActive", userHandler.SetIsActive)
 		r.Get("/getReview", userHandler.GetReviews)
+		r.Post("/setWorkingHours", userHandler.SetWorkingHours)
+		r.Post("/setReviewCap", userHandler.SetReviewCap)
+		r.Get("/getTags", userHandler.GetTags)
+		r.Post("/addTags", userHandler.AddTags)
+		r.Post("/removeTags", userHandler.RemoveTags)
+		r.Post("/setTags", userHandler.SetTags)
+		r.Get("/getAbsences", availabilityHandler.ListAbsences)
+		r.Post("/addAbsence", availabilityHandler.CreateAbsence)
+		r.Post("/cancelAbsence", availabilityHandler.CancelAbsence)
 	})
 
 	r.Route("/pullRequest", func(r chi.Router) {
 		r.Post("/create", prHandler.CreatePR)
+		r.Post("/preview", prHandler.PreviewPR)
+		r.Post("/update", prHandler.UpdateMetadata)
+		r.Get("/list", prHandler.ListPRs)
 		r.Post("/merge", prHandler.MergePR)
+		r.Post("/ready", prHandler.MarkReady)
+		r.Post("/close", prHandler.ClosePR)
+		r.Post("/reopen", prHandler.ReopenPR)
+		r.Post("/review", prHandler.SubmitReview)
 		r.Post("/reassign", prHandler.ReassignReviewer)
+		r.Post("/addReviewer", prHandler.AddReviewer)
+		r.Post("/removeReviewer", prHandler.RemoveReviewer)
+		r.Get("/explain", prHandler.ExplainAssignment)
+		r.Get("/pending", prHandler.ListPending)
+	})
+
+	r.Route("/webhooks/{code_host}", func(r chi.Router) {
+		r.Post("/", webhookHandler.Deliver)
+		r.Get("/logins", webhookHandler.ListLogins)
+		r.Post("/setLogin", webhookHandler.SetLogin)
+		r.Post("/removeLogin", webhookHandler.RemoveLogin)
+	})
+
+	r.Route("/subscriptions", func(r chi.Router) {
+		r.Post("/add", subscriptionHandler.Subscribe)
+		r.Get("/list", subscriptionHandler.ListSubscriptions)
+		r.Post("/remove", subscriptionHandler.Unsubscribe)
+		r.Get("/deliveries", subscriptionHandler.ListDeliveries)
+		r.Post("/replay", subscriptionHandler.Replay)
+	})
+
+	r.Route("/outbox", func(r chi.Router) {
+		r.Get("/deadLetters", outboxHandler.ListDeadLetters)
+		r.Post("/requeue", outboxHandler.Requeue)
 	})
 
 	r.Get("/stats/assignments", statsHandler.GetAssignmentStats)
+	r.Get("/sla/breaches", slaHandler.ListBreaches)
+	r.Get("/audit", auditHandler.ListEntries)
 	r.Get("/health", healthHandler.Check)
 
 	return r
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// AbsenceStatus is derived from the absence dates and cancellation at a given moment
+type AbsenceStatus string
+
+const (
+	AbsenceScheduled AbsenceStatus = "SCHEDULED"
+	AbsenceOngoing   AbsenceStatus = "ONGOING"
+	AbsenceEnded     AbsenceStatus = "ENDED"
+	AbsenceCancelled AbsenceStatus = "CANCELLED"
+)
+
+// Absence is a date range [StartsAt, EndsAt) during which the user cannot review
+type Absence struct {
+	AbsenceID   int64         `json:"absence_id"`
+	UserID      string        `json:"user_id"`
+	StartsAt    time.Time     `json:"starts_at"`
+	EndsAt      time.Time     `json:"ends_at"`
+	Reason      string        `json:"reason"`
+	Status      AbsenceStatus `json:"status"`
+	CancelledAt *time.Time    `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func NewAbsence(userID string, startsAt, endsAt time.Time, reason string) *Absence {
+	return &Absence{
+		UserID:    userID,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		Reason:    reason,
+		Status:    AbsenceScheduled,
+		CreatedAt: time.Now(),
+	}
+}
+
+// ActiveFlagChange is a user whose is_active flag an absence switched, before and after the switch
+type ActiveFlagChange struct {
+	Before *User
+	After  *User
+}
+
+// StatusAt computes the status of the absence at the given moment
+func (a *Absence) StatusAt(at time.Time) AbsenceStatus {
+	switch {
+	case a.CancelledAt != nil:
+		return AbsenceCancelled
+	case !at.Before(a.EndsAt):
+		return AbsenceEnded
+	case !at.Before(a.StartsAt):
+		return AbsenceOngoing
+	default:
+		return AbsenceScheduled
+	}
+}
+
+// IsClosed reports whether the absence can no longer affect availability
+func (a *Absence) IsClosed(at time.Time) bool {
+	status := a.StatusAt(at)
+	return status == AbsenceEnded || status == AbsenceCancelled
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+)
+
+// TraceAction is the change of reviewers a trace explains
+type TraceAction string
+
+const (
+	TraceActionCreate         TraceAction = "CREATE"
+	TraceActionReassign       TraceAction = "REASSIGN"
+	TraceActionManualReassign TraceAction = "MANUAL_REASSIGN"
+	TraceActionManualAdd      TraceAction = "MANUAL_ADD"
+	TraceActionRetry          TraceAction = "RETRY"
+	TraceActionReady          TraceAction = "READY"
+	TraceActionReopen         TraceAction = "REOPEN"
+	TraceActionEscalate       TraceAction = "ESCALATE"
+	TraceActionLabel          TraceAction = "LABEL"
+)
+
+// AssignmentStep is the part of the assignment pipeline a decision was made in
+type AssignmentStep string
+
+const (
+	StepOwners   AssignmentStep = "OWNERS"
+	StepTags     AssignmentStep = "TAGS"
+	StepTeam     AssignmentStep = "TEAM"
+	StepFallback AssignmentStep = "FALLBACK"
+	StepLabels   AssignmentStep = "LABELS"
+)
+
+// ExclusionReason tells why a team member was not considered
+type ExclusionReason string
+
+const (
+	ExcludedAuthor          ExclusionReason = "AUTHOR"
+	ExcludedInactive        ExclusionReason = "INACTIVE"
+	ExcludedAbsent          ExclusionReason = "ABSENT"
+	ExcludedAlreadyAssigned ExclusionReason = "ALREADY_ASSIGNED"
+	ExcludedWorkingHours    ExclusionReason = "OUTSIDE_WORKING_HOURS"
+	ExcludedMissingTag      ExclusionReason = "MISSING_TAG"
+	ExcludedNeverPair       ExclusionReason = "NEVER_PAIR"
+	ExcludedAtCap           ExclusionReason = "AT_CAP"
+)
+
+// TieBreakOutcome marks candidates whose load equalled the one of a candidate
+// on the other side of the cut, so the strategy rather than load decided
+type TieBreakOutcome string
+
+const (
+	TieBreakWon  TieBreakOutcome = "WON"
+	TieBreakLost TieBreakOutcome = "LOST"
+)
+
+// AssignmentTrace records how reviewers were chosen for one change of a PR
+type AssignmentTrace struct {
+	TraceID        int64            `json:"trace_id"`
+	PullRequestID  string           `json:"pull_request_id"`
+	Action         TraceAction      `json:"action"`
+	ReplacedUserID string           `json:"replaced_user_id,omitempty"`
+	Strategy       ReviewerStrategy `json:"strategy,omitempty"`
+	Selected       []string         `json:"selected"`
+	Excluded       []TraceExclusion `json:"excluded"`
+	Rounds         []SelectionRound `json:"rounds"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+type TraceExclusion struct {
+	UserID string          `json:"user_id"`
+	Step   AssignmentStep  `json:"step"`
+	Reason ExclusionReason `json:"reason"`
+}
+
+// SelectionRound is a single call of the team's strategy
+type SelectionRound struct {
+	Step       AssignmentStep    `json:"step"`
+	Wanted     int               `json:"wanted"`
+	Candidates []TracedCandidate `json:"candidates"`
+}
+
+// TracedCandidate is a candidate of a round with the load the strategy saw at decision
+// time: open reviews plus the team's fairness window and author spread penalties
+type TracedCandidate struct {
+	UserID   string          `json:"user_id"`
+	Load     int             `json:"load"`
+	Selected bool            `json:"selected"`
+	TieBreak TieBreakOutcome `json:"tie_break,omitempty"`
+}
+
+func NewAssignmentTrace(prID string, action TraceAction) *AssignmentTrace {
+	return &AssignmentTrace{
+		PullRequestID: prID,
+		Action:        action,
+		Selected:      []string{},
+		Excluded:      []TraceExclusion{},
+		Rounds:        []SelectionRound{},
+		CreatedAt:     time.Now(),
+	}
+}
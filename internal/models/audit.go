@@ -0,0 +1,87 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// AuditAction names a change recorded in the audit log
+type AuditAction string
+
+const (
+	// AuditTeamCreated is recorded when a team is created with its members
+	AuditTeamCreated AuditAction = "team.created"
+	// AuditUserActivated is recorded when an inactive user is switched on
+	AuditUserActivated AuditAction = "user.activated"
+	// AuditUserDeactivated is recorded when an active user is switched off
+	AuditUserDeactivated AuditAction = "user.deactivated"
+	// AuditPRCreated is recorded for every PR registered in the service
+	AuditPRCreated AuditAction = "pr.created"
+	// AuditPRMerged is recorded when a PR gets merged
+	AuditPRMerged AuditAction = "pr.merged"
+	// AuditPRClosed is recorded when a PR is abandoned without merging
+	AuditPRClosed AuditAction = "pr.closed"
+	// AuditReviewerReassigned is recorded when a reviewer of a PR is replaced by another one
+	AuditReviewerReassigned AuditAction = "reviewer.reassigned"
+	// AuditReviewerAdded is recorded when reviewers join an existing PR: added by hand,
+	// for an overdue review, for a label, on a retry of the queue or when the PR opens for review
+	AuditReviewerAdded AuditAction = "reviewer.added"
+	// AuditReviewerRemoved is recorded when a reviewer is taken off a PR without a replacement
+	AuditReviewerRemoved AuditAction = "reviewer.removed"
+)
+
+var auditActions = []AuditAction{
+	AuditTeamCreated, AuditUserActivated, AuditUserDeactivated,
+	AuditPRCreated, AuditPRMerged, AuditPRClosed, AuditReviewerReassigned,
+	AuditReviewerAdded, AuditReviewerRemoved,
+}
+
+func (a AuditAction) IsValid() bool {
+	return slices.Contains(auditActions, a)
+}
+
+// AuditEntity is the kind of entity an audit entry is about
+type AuditEntity string
+
+const (
+	AuditEntityTeam        AuditEntity = "team"
+	AuditEntityUser        AuditEntity = "user"
+	AuditEntityPullRequest AuditEntity = "pull_request"
+)
+
+func (e AuditEntity) IsValid() bool {
+	return e == AuditEntityTeam || e == AuditEntityUser || e == AuditEntityPullRequest
+}
+
+// AuditEntry is an append-only record of a change: who did what to which entity
+// and the entity before and after it. Before is null for created entities,
+// entries read from the log carry Before and After as the saved JSON
+type AuditEntry struct {
+	AuditID    int64       `json:"audit_id"`
+	Actor      string      `json:"actor"`
+	Action     AuditAction `json:"action"`
+	EntityType AuditEntity `json:"entity_type"`
+	EntityID   string      `json:"entity_id"`
+	Before     any         `json:"before"`
+	After      any         `json:"after"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// AuditFilter narrows the audit log, zero fields do not filter. Entries are listed
+// latest first, Cursor is the NextCursor of the previous page
+type AuditFilter struct {
+	Actor      string
+	Action     AuditAction
+	EntityType AuditEntity
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Cursor     int64
+	Limit      int
+}
+
+// AuditPage is a page of the audit log, NextCursor is set while older entries are left
+type AuditPage struct {
+	Entries    []*AuditEntry `json:"entries"`
+	NextCursor *int64        `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// CodeHost is a code hosting platform that reports PR changes through webhooks
+type CodeHost string
+
+const (
+	CodeHostGitHub CodeHost = "GITHUB"
+	CodeHostGitLab CodeHost = "GITLAB"
+	CodeHostGitea  CodeHost = "GITEA"
+)
+
+func (h CodeHost) IsValid() bool {
+	switch h {
+	case CodeHostGitHub, CodeHostGitLab, CodeHostGitea:
+		return true
+	}
+	return false
+}
+
+const MaxLoginLength = 255
+
+// CodeHostLogin maps an account of a code host to the user reviewing in this service
+type CodeHostLogin struct {
+	CodeHost  CodeHost  `json:"code_host"`
+	Login     string    `json:"login"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeLogin trims a login and lowercases it, code hosts treat logins case-insensitively
+func NormalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+// WebhookOutcome tells what a webhook delivery did to the PR
+type WebhookOutcome string
+
+const (
+	WebhookCreated  WebhookOutcome = "CREATED"
+	WebhookUpdated  WebhookOutcome = "UPDATED"
+	WebhookReady    WebhookOutcome = "READY"
+	WebhookMerged   WebhookOutcome = "MERGED"
+	WebhookClosed   WebhookOutcome = "CLOSED"
+	WebhookReopened WebhookOutcome = "REOPENED"
+	WebhookIgnored  WebhookOutcome = "IGNORED"
+)
+
+// WebhookResult is the response to a webhook delivery, Reason explains an ignored one
+type WebhookResult struct {
+	Event         string         `json:"event"`
+	Action        string         `json:"action,omitempty"`
+	PullRequestID string         `json:"pull_request_id,omitempty"`
+	Outcome       WebhookOutcome `json:"outcome"`
+	Reason        string         `json:"reason,omitempty"`
+}
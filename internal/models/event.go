@@ -0,0 +1,85 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// EventType names a domain event published to the outside world
+type EventType string
+
+const (
+	// EventPRCreated is published for every PR registered in the service
+	EventPRCreated EventType = "pr.created"
+	// EventPRMerged is published when a PR gets merged
+	EventPRMerged EventType = "pr.merged"
+	// EventPRClosed is published when a PR is abandoned without merging
+	EventPRClosed EventType = "pr.closed"
+	// EventReviewerAssigned is published for every reviewer added to a PR
+	EventReviewerAssigned EventType = "reviewer.assigned"
+	// EventReviewerReassigned is published when a reviewer of a PR is replaced by another one
+	EventReviewerReassigned EventType = "reviewer.reassigned"
+	// EventUserDeactivated is published when an active user is switched off
+	EventUserDeactivated EventType = "user.deactivated"
+	// EventReviewSLABreached is published for every reviewer who missed the team's review SLA
+	EventReviewSLABreached EventType = "review.sla_breached"
+)
+
+var eventTypes = []EventType{
+	EventPRCreated, EventPRMerged, EventPRClosed, EventReviewerAssigned, EventReviewerReassigned,
+	EventUserDeactivated, EventReviewSLABreached,
+}
+
+func (t EventType) IsValid() bool {
+	return slices.Contains(eventTypes, t)
+}
+
+// Event is a domain event, Data is the JSON payload specific to its type.
+// ID is the id of the event in the outbox, a sink may receive an event more than once
+type Event struct {
+	ID         int64     `json:"event_id,omitempty"`
+	Type       EventType `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Aggregates events are about, events of one aggregate are dispatched in the order they happened
+const (
+	AggregatePullRequest = "pull_request"
+	AggregateUser        = "user"
+)
+
+// Aggregate tells the kind and id of the entity the event is about
+func (e Event) Aggregate() (string, string) {
+	switch data := e.Data.(type) {
+	case PREventData:
+		return AggregatePullRequest, data.PullRequest.PullRequestID
+	case ReviewerEventData:
+		return AggregatePullRequest, data.PullRequestID
+	case *SLABreach:
+		return AggregatePullRequest, data.PullRequestID
+	case UserEventData:
+		return AggregateUser, data.User.UserID
+	}
+	return "", ""
+}
+
+// PREventData is the payload of pr.created, pr.merged and pr.closed
+type PREventData struct {
+	PullRequest *PullRequest `json:"pull_request"`
+}
+
+// ReviewerEventData is the payload of reviewer.assigned and reviewer.reassigned,
+// Action tells which change of reviewers it was (the action of its decision trace)
+type ReviewerEventData struct {
+	PullRequestID      string      `json:"pull_request_id"`
+	AuthorID           string      `json:"author_id"`
+	ReviewerID         string      `json:"reviewer_id"`
+	ReplacedReviewerID string      `json:"replaced_reviewer_id,omitempty"`
+	Action             TraceAction `json:"action"`
+}
+
+// UserEventData is the payload of user.deactivated
+type UserEventData struct {
+	User *User `json:"user"`
+}
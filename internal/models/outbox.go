@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxStatus is the state of an event in the outbox
+type OutboxStatus string
+
+const (
+	// OutboxPending waits to be dispatched to the sinks
+	OutboxPending OutboxStatus = "PENDING"
+	// OutboxDispatched was accepted by every sink
+	OutboxDispatched OutboxStatus = "DISPATCHED"
+	// OutboxDead ran out of attempts and stays in the outbox until it is requeued
+	OutboxDead OutboxStatus = "DEAD"
+)
+
+// OutboxEvent is a domain event saved for dispatch, Payload is the JSON of its data
+type OutboxEvent struct {
+	EventID       int64           `json:"event_id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     EventType       `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Status        OutboxStatus    `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	DispatchedAt  *time.Time      `json:"dispatched_at,omitempty"`
+}
+
+// NewOutboxEvent serializes the event for the outbox, it is due right away
+func NewOutboxEvent(event Event) (*OutboxEvent, error) {
+
+	payload, err := json.Marshal(event.Data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aggregateType, aggregateID := event.Aggregate()
+	occurredAt := event.OccurredAt
+
+	return &OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     event.Type,
+		Payload:       payload,
+		OccurredAt:    occurredAt,
+		Status:        OutboxPending,
+		NextAttemptAt: &occurredAt,
+	}, nil
+}
+
+// Event is the event as sinks receive it, its data stays the saved JSON
+func (e *OutboxEvent) Event() Event {
+	return Event{
+		ID:         e.EventID,
+		Type:       e.EventType,
+		OccurredAt: e.OccurredAt,
+		Data:       e.Payload,
+	}
+}
@@ -0,0 +1,12 @@
+package models
+
+// OwnershipRule maps a CODEOWNERS-style path pattern to its owners
+type OwnershipRule struct {
+	Pattern    string   `json:"pattern"`
+	OwnerUsers []string `json:"owner_users"`
+	OwnerTeams []string `json:"owner_teams"`
+}
+
+func (r *OwnershipRule) HasOwners() bool {
+	return len(r.OwnerUsers) > 0 || len(r.OwnerTeams) > 0
+}
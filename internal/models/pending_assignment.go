@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+// PendingReason tells why a PR waits for reviewers
+type PendingReason string
+
+const (
+	// PendingUnderstaffed is a PR with fewer reviewers than its team requires
+	PendingUnderstaffed PendingReason = "UNDERSTAFFED"
+	// PendingAwaitingReviewer is a PR whose candidates were all at their open review cap
+	PendingAwaitingReviewer PendingReason = "AWAITING_REVIEWER"
+)
+
+const (
+	// PendingRetryBackoff is the pause after the first failed attempt of a queued PR,
+	// it doubles with every next attempt up to PendingRetryMaxBackoff
+	PendingRetryBackoff    = time.Minute
+	PendingRetryMaxBackoff = time.Hour
+)
+
+// PendingAssignment is an OPEN PR in the queue of PRs waiting for reviewers
+type PendingAssignment struct {
+	PullRequestID     string        `json:"pull_request_id"`
+	PullRequestName   string        `json:"pull_request_name"`
+	AuthorID          string        `json:"author_id"`
+	TeamName          string        `json:"team_name"`
+	Reason            PendingReason `json:"reason"`
+	AssignedReviewers []string      `json:"assigned_reviewers"`
+	EnqueuedAt        time.Time     `json:"enqueued_at"`
+	Attempts          int           `json:"attempts"`
+	LastAttemptAt     *time.Time    `json:"last_attempt_at,omitempty"`
+}
+
+// RetryDue reports whether the pause after the last failed attempt is over
+func (p *PendingAssignment) RetryDue(now time.Time) bool {
+
+	if p.Attempts == 0 || p.LastAttemptAt == nil {
+		return true
+	}
+
+	backoff := PendingRetryMaxBackoff
+
+	// Shifting further would only overflow, the cap is reached long before
+	if p.Attempts <= 16 {
+		backoff = min(PendingRetryBackoff<<(p.Attempts-1), PendingRetryMaxBackoff)
+	}
+
+	return !now.Before(p.LastAttemptAt.Add(backoff))
+}
+
+// PendingReason reports whether the PR belongs to the pending assignment queue and why
+func (pr *PullRequest) PendingReason() (PendingReason, bool) {
+
+	switch {
+	case !pr.IsOpen():
+		return "", false
+	case pr.AwaitingReviewer:
+		return PendingAwaitingReviewer, true
+	case pr.Understaffed:
+		return PendingUnderstaffed, true
+	}
+
+	return "", false
+}
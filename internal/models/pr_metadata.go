@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bounds of PR metadata
+const (
+	MaxRepositoryLength  = 255
+	MaxBranchLength      = 255
+	MaxURLLength         = 2048
+	MaxLabelLength       = 100
+	MaxLabels            = 50
+	MaxDescriptionLength = 65536
+)
+
+// PRMetadata links a PR to its code host, every field is optional.
+// PR IDs stay unique across repositories, Repository is informational ("owner/name")
+type PRMetadata struct {
+	Repository  string   `json:"repository,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	BaseBranch  string   `json:"base_branch,omitempty"`
+	Labels      []string `json:"labels"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Normalize trims the fields, lowercases labels, drops their duplicates and sorts them,
+// then checks the bounds. URL must be an absolute http(s) one
+func (m *PRMetadata) Normalize() error {
+
+	m.Repository = strings.TrimSpace(m.Repository)
+	m.URL = strings.TrimSpace(m.URL)
+	m.BaseBranch = strings.TrimSpace(m.BaseBranch)
+
+	labels := []string{}
+
+	for _, label := range m.Labels {
+
+		label = NormalizeLabel(label)
+
+		if label == "" || utf8.RuneCountInString(label) > MaxLabelLength {
+			return fmt.Errorf("label must have 1 to %d characters", MaxLabelLength)
+		}
+
+		labels = append(labels, label)
+	}
+
+	slices.Sort(labels)
+	m.Labels = slices.Compact(labels)
+
+	switch {
+	case len(m.Labels) > MaxLabels:
+		return fmt.Errorf("a PR may have up to %d labels", MaxLabels)
+	case len(m.Repository) > MaxRepositoryLength:
+		return fmt.Errorf("repository must be up to %d characters", MaxRepositoryLength)
+	case len(m.BaseBranch) > MaxBranchLength:
+		return fmt.Errorf("base_branch must be up to %d characters", MaxBranchLength)
+	case len(m.Description) > MaxDescriptionLength:
+		return fmt.Errorf("description must be up to %d bytes", MaxDescriptionLength)
+	}
+
+	if m.URL == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(m.URL)
+
+	if err != nil || len(m.URL) > MaxURLLength || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+
+	return nil
+}
+
+// NormalizeLabel makes labels of code hosts compare equal regardless of case and padding
+func NormalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// HasLabel reports whether the PR has the normalized label
+func (pr *PullRequest) HasLabel(label string) bool {
+	return slices.Contains(pr.Labels, label)
+}
+
+// LabelRule asks for a reviewer of Team on PRs having Label, on top of the reviewer count
+type LabelRule struct {
+	Label string `json:"label"`
+	Team  string `json:"team"`
+}
+
+// PRFilter narrows listed PRs, zero fields do not filter
+type PRFilter struct {
+	Repository string
+	Label      string
+	Status     PRStatus
+	AuthorID   string
+	Limit      int
+}
+
+// Bounds of listed PRs
+const (
+	DefaultPRListLimit = 100
+	MaxPRListLimit     = 1000
+)
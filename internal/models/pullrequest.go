@@ -1,25 +1,67 @@
 package models
 
 import (
+	"fmt"
 	"slices"
 	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 )
 
+// PRStatus is the lifecycle state of a PR:
+//
+//	DRAFT  -> OPEN (ready for review), CLOSED
+//	OPEN   -> MERGED, CLOSED
+//	CLOSED -> OPEN (reopen)
+//
+// Only OPEN PRs have reviewers picked and count towards reviewer load, MERGED is final
 type PRStatus string
 
 const (
+	PRStatusDraft  PRStatus = "DRAFT"
 	PRStatusOpen   PRStatus = "OPEN"
 	PRStatusMerged PRStatus = "MERGED"
+	PRStatusClosed PRStatus = "CLOSED"
+)
+
+// Review weight bounds, a PR weighs DefaultReviewWeight in its reviewers' load
+// unless its team weighs PRs by size
+const (
+	DefaultReviewWeight = 1
+	MaxReviewWeight     = 10
 )
 
+// PRSize is the optional size metadata of a PR
+type PRSize struct {
+	LinesAdded   int `json:"lines_added"`
+	LinesRemoved int `json:"lines_removed"`
+	FilesChanged int `json:"files_changed"`
+}
+
 type PullRequest struct {
 	PullRequestID     string     `json:"pull_request_id"`
 	PullRequestName   string     `json:"pull_request_name"`
 	AuthorID          string     `json:"author_id"`
 	Status            PRStatus   `json:"status"`
 	AssignedReviewers []string   `json:"assigned_reviewers"`
+	FallbackReviewers []string   `json:"fallback_reviewers"`
+	Reviews           []Review   `json:"reviews"`
+	Understaffed      bool       `json:"understaffed"`
+	AwaitingReviewer  bool       `json:"awaiting_reviewer"`
+	ChangedFiles      []string   `json:"changed_files"`
+	RequiredTags      []string   `json:"required_tags"`
+	Size              *PRSize    `json:"size,omitempty"`
+	ReviewWeight      int        `json:"review_weight"`
 	CreatedAt         time.Time  `json:"created_at"`
 	MergedAt          *time.Time `json:"merged_at,omitempty"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty"`
+
+	// Optional code host metadata, flattened into the PR
+	PRMetadata
+
+	// Domain events and audit entries of changes not saved yet
+	events []Event
+	audit  []AuditEntry
 }
 
 func NewPullRequest(prID, prName, authorID string) *PullRequest {
@@ -29,25 +71,133 @@ func NewPullRequest(prID, prName, authorID string) *PullRequest {
 		AuthorID:          authorID,
 		Status:            PRStatusOpen,
 		AssignedReviewers: []string{},
+		FallbackReviewers: []string{},
+		Reviews:           []Review{},
+		ChangedFiles:      []string{},
+		RequiredTags:      []string{},
+		ReviewWeight:      DefaultReviewWeight,
+		PRMetadata:        PRMetadata{Labels: []string{}},
 		CreatedAt:         time.Now(),
 	}
 }
 
-func (pr *PullRequest) Merge() {
-	pr.Status = PRStatusMerged
+func (pr *PullRequest) Merge() error {
+	if err := pr.transition("merge", PRStatusMerged, PRStatusOpen); err != nil {
+		return err
+	}
 	now := time.Now()
 	pr.MergedAt = &now
+	return nil
+}
+
+// Close abandons a DRAFT or OPEN PR without merging, its reviews stop counting as load
+func (pr *PullRequest) Close() error {
+	if err := pr.transition("close", PRStatusClosed, PRStatusDraft, PRStatusOpen); err != nil {
+		return err
+	}
+	now := time.Now()
+	pr.ClosedAt = &now
+	return nil
+}
+
+// Reopen brings a CLOSED PR back to review
+func (pr *PullRequest) Reopen() error {
+	if err := pr.transition("reopen", PRStatusOpen, PRStatusClosed); err != nil {
+		return err
+	}
+	pr.ClosedAt = nil
+	return nil
+}
+
+// MarkReady opens a DRAFT PR for review
+func (pr *PullRequest) MarkReady() error {
+	return pr.transition("mark ready", PRStatusOpen, PRStatusDraft)
+}
+
+// RecordEvent keeps a domain event of a change of the PR, the PR repository
+// writes recorded events to the outbox in the transaction saving the change
+func (pr *PullRequest) RecordEvent(event Event) {
+	pr.events = append(pr.events, event)
+}
+
+// Events returns the events recorded since the PR was last saved
+func (pr *PullRequest) Events() []Event {
+	return pr.events
+}
+
+// RecordAudit keeps an audit entry of a change of the PR, the PR repository
+// writes it to the audit log in the transaction saving the change
+func (pr *PullRequest) RecordAudit(entry AuditEntry) {
+	pr.audit = append(pr.audit, entry)
+}
+
+// AuditEntries returns the audit entries recorded since the PR was last saved
+func (pr *PullRequest) AuditEntries() []AuditEntry {
+	return pr.audit
+}
+
+// ClearRecorded forgets the recorded events and audit entries once they are saved
+func (pr *PullRequest) ClearRecorded() {
+	pr.events = nil
+	pr.audit = nil
+}
+
+// Snapshot copies the PR as it is now, changes of the PR made later do not reach the copy
+func (pr *PullRequest) Snapshot() *PullRequest {
+	snapshot := *pr
+	snapshot.AssignedReviewers = slices.Clone(pr.AssignedReviewers)
+	snapshot.FallbackReviewers = slices.Clone(pr.FallbackReviewers)
+	snapshot.Reviews = slices.Clone(pr.Reviews)
+	snapshot.ChangedFiles = slices.Clone(pr.ChangedFiles)
+	snapshot.RequiredTags = slices.Clone(pr.RequiredTags)
+	snapshot.Labels = slices.Clone(pr.Labels)
+	snapshot.events = nil
+	snapshot.audit = nil
+	return &snapshot
+}
+
+// moves the PR to the status when it is in one of the statuses the action starts from
+func (pr *PullRequest) transition(action string, to PRStatus, from ...PRStatus) error {
+	if !slices.Contains(from, pr.Status) {
+		return fmt.Errorf("%w: cannot %s a %s PR", apperrors.ErrInvalidTransition, action, pr.Status)
+	}
+	pr.Status = to
+	return nil
+}
+
+func (s PRStatus) IsValid() bool {
+	switch s {
+	case PRStatusDraft, PRStatusOpen, PRStatusMerged, PRStatusClosed:
+		return true
+	}
+	return false
 }
 
 func (pr *PullRequest) IsMerged() bool {
 	return pr.Status == PRStatusMerged
 }
 
+// IsOpen reports whether the PR is under review, reviewers of other PRs cannot change
+func (pr *PullRequest) IsOpen() bool {
+	return pr.Status == PRStatusOpen
+}
+
 func (pr *PullRequest) AddReviewer(userID string) {
 	pr.AssignedReviewers = append(pr.AssignedReviewers, userID)
+	pr.Reviews = append(pr.Reviews, Review{UserID: userID, State: ReviewPending})
+}
+
+// AddFallbackReviewer assigns a reviewer taken from a fallback team
+func (pr *PullRequest) AddFallbackReviewer(userID string) {
+	pr.AddReviewer(userID)
+	pr.FallbackReviewers = append(pr.FallbackReviewers, userID)
 }
 
 func (pr *PullRequest) RemoveReviewer(userID string) bool {
+	if i := slices.Index(pr.FallbackReviewers, userID); i != -1 {
+		pr.FallbackReviewers = slices.Delete(pr.FallbackReviewers, i, i+1)
+	}
+	pr.Reviews = slices.DeleteFunc(pr.Reviews, func(r Review) bool { return r.UserID == userID })
 	if i := slices.Index(pr.AssignedReviewers, userID); i != -1 {
 		pr.AssignedReviewers = slices.Delete(pr.AssignedReviewers, i, i+1)
 		return true
@@ -55,6 +205,43 @@ func (pr *PullRequest) RemoveReviewer(userID string) bool {
 	return false
 }
 
+// ReviewOf is the review of an assigned reviewer, PENDING until a review is submitted
+func (pr *PullRequest) ReviewOf(userID string) Review {
+	if i := slices.IndexFunc(pr.Reviews, func(r Review) bool { return r.UserID == userID }); i != -1 {
+		return pr.Reviews[i]
+	}
+	return Review{UserID: userID, State: ReviewPending}
+}
+
+func (pr *PullRequest) ReviewState(userID string) ReviewState {
+	return pr.ReviewOf(userID).State
+}
+
+// SetReview records the review state of an assigned reviewer
+func (pr *PullRequest) SetReview(userID string, state ReviewState, submittedAt *time.Time) {
+	review := Review{UserID: userID, State: state, SubmittedAt: submittedAt}
+	if i := slices.IndexFunc(pr.Reviews, func(r Review) bool { return r.UserID == userID }); i != -1 {
+		pr.Reviews[i] = review
+		return
+	}
+	pr.Reviews = append(pr.Reviews, review)
+}
+
+// Approvals counts assigned reviewers who approved the PR
+func (pr *PullRequest) Approvals() int {
+	approvals := 0
+	for _, reviewerID := range pr.AssignedReviewers {
+		if pr.ReviewState(reviewerID) == ReviewApproved {
+			approvals++
+		}
+	}
+	return approvals
+}
+
+func (pr *PullRequest) IsFallbackReviewer(userID string) bool {
+	return slices.Contains(pr.FallbackReviewers, userID)
+}
+
 func (pr *PullRequest) HasReviewer(userID string) bool {
 	return slices.Contains(pr.AssignedReviewers, userID)
 }
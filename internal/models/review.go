@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// ReviewState is where an assigned reviewer stands with a PR
+type ReviewState string
+
+const (
+	// ReviewPending is the state of a reviewer who has not submitted a review yet
+	ReviewPending ReviewState = "PENDING"
+	// ReviewApproved counts towards the approvals required for merging
+	ReviewApproved ReviewState = "APPROVED"
+	// ReviewChangesRequested asks the author for changes
+	ReviewChangesRequested ReviewState = "CHANGES_REQUESTED"
+	// ReviewCommented leaves comments without a decision
+	ReviewCommented ReviewState = "COMMENTED"
+)
+
+func (s ReviewState) IsValid() bool {
+	switch s {
+	case ReviewPending, ReviewApproved, ReviewChangesRequested, ReviewCommented:
+		return true
+	}
+	return false
+}
+
+// IsSubmitted reports whether the state is the outcome of a submitted review
+func (s ReviewState) IsSubmitted() bool {
+	return s.IsValid() && s != ReviewPending
+}
+
+// Review is the latest review state of an assigned reviewer, SubmittedAt is empty while PENDING
+type Review struct {
+	UserID      string      `json:"user_id"`
+	State       ReviewState `json:"state"`
+	SubmittedAt *time.Time  `json:"submitted_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// AwaitedReview is a PENDING review of an OPEN PR, the unit the review SLA is checked on
+type AwaitedReview struct {
+	PullRequestID string    `json:"pull_request_id"`
+	AuthorID      string    `json:"author_id"`
+	TeamName      string    `json:"team_name"`
+	UserID        string    `json:"user_id"`
+	AssignedAt    time.Time `json:"assigned_at"`
+}
+
+// SLABreach records a reviewer who did not review within the team's SLA and how it was escalated.
+// EscalatedTo is the reviewer added or put in place of the late one, EscalationError
+// explains an escalation that failed, the breach is recorded either way
+type SLABreach struct {
+	BreachID        int64         `json:"breach_id"`
+	PullRequestID   string        `json:"pull_request_id"`
+	UserID          string        `json:"user_id"`
+	TeamName        string        `json:"team_name"`
+	AssignedAt      time.Time     `json:"assigned_at"`
+	Deadline        time.Time     `json:"deadline"`
+	DetectedAt      time.Time     `json:"detected_at"`
+	Escalation      SLAEscalation `json:"escalation"`
+	EscalatedTo     string        `json:"escalated_to,omitempty"`
+	EscalationError string        `json:"escalation_error,omitempty"`
+}
+
+// SLABreachFilter narrows the list of breaches, zero fields do not filter
+type SLABreachFilter struct {
+	TeamName      string
+	UserID        string
+	PullRequestID string
+	Since         time.Time
+}
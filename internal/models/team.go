@@ -4,10 +4,41 @@ import (
 	"time"
 )
 
+// ReviewerStrategy defines how reviewers are picked among team candidates
+type ReviewerStrategy string
+
+const (
+	StrategyLeastLoaded        ReviewerStrategy = "LEAST_LOADED"
+	StrategyRoundRobin         ReviewerStrategy = "ROUND_ROBIN"
+	StrategyWeightedRandom     ReviewerStrategy = "WEIGHTED_RANDOM"
+	StrategyHistoricalFairness ReviewerStrategy = "HISTORICAL_FAIRNESS"
+)
+
+// DefaultReviewerStrategy is used for teams that did not choose a strategy
+const DefaultReviewerStrategy = StrategyLeastLoaded
+
+func (s ReviewerStrategy) IsValid() bool {
+	switch s {
+	case StrategyLeastLoaded, StrategyRoundRobin, StrategyWeightedRandom, StrategyHistoricalFairness:
+		return true
+	}
+	return false
+}
+
+// UsesLoad reports whether the strategy ranks candidates by their load,
+// ROUND_ROBIN is a strict rotation and does not
+func (s ReviewerStrategy) UsesLoad() bool {
+	return s != StrategyRoundRobin
+}
+
 type Team struct {
-	TeamName  string       `json:"team_name"`
-	Members   []TeamMember `json:"members"`
-	CreatedAt time.Time    `json:"created_at"`
+	TeamName         string           `json:"team_name"`
+	ReviewerStrategy ReviewerStrategy `json:"reviewer_strategy"`
+	Members          []TeamMember     `json:"members"`
+	CreatedAt        time.Time        `json:"created_at"`
+
+	// Audit entries of changes not saved yet
+	audit []AuditEntry
 }
 
 type TeamMember struct {
@@ -18,8 +49,25 @@ type TeamMember struct {
 
 func NewTeam(teamName string, members []TeamMember) *Team {
 	return &Team{
-		TeamName:  teamName,
-		Members:   members,
-		CreatedAt: time.Now(),
+		TeamName:         teamName,
+		ReviewerStrategy: DefaultReviewerStrategy,
+		Members:          members,
+		CreatedAt:        time.Now(),
 	}
 }
+
+// RecordAudit keeps an audit entry of a change of the team, the team repository
+// writes it to the audit log in the transaction saving the change
+func (t *Team) RecordAudit(entry AuditEntry) {
+	t.audit = append(t.audit, entry)
+}
+
+// AuditEntries returns the audit entries recorded since the team was last saved
+func (t *Team) AuditEntries() []AuditEntry {
+	return t.audit
+}
+
+// ClearAudit forgets the recorded audit entries once they are saved
+func (t *Team) ClearAudit() {
+	t.audit = nil
+}
@@ -0,0 +1,342 @@
+package models
+
+import (
+	"time"
+)
+
+// UnderstaffedPolicy defines what happens when a team has fewer
+// reviewer candidates than its settings require
+type UnderstaffedPolicy string
+
+const (
+	// UnderstaffedFail rejects the PR with NOT_ENOUGH_REVIEWERS
+	UnderstaffedFail UnderstaffedPolicy = "FAIL"
+	// UnderstaffedFallback takes missing reviewers from the fallback teams in order
+	UnderstaffedFallback UnderstaffedPolicy = "FALLBACK"
+	// UnderstaffedAllow creates the PR marked as understaffed
+	UnderstaffedAllow UnderstaffedPolicy = "ALLOW"
+)
+
+// Reviewer count bounds accepted in team settings
+const (
+	DefaultReviewerCount = 2
+	MinReviewerCount     = 1
+	MaxReviewerCount     = 10
+)
+
+func (p UnderstaffedPolicy) IsValid() bool {
+	switch p {
+	case UnderstaffedFail, UnderstaffedFallback, UnderstaffedAllow:
+		return true
+	}
+	return false
+}
+
+// CapPolicy defines what happens when every candidate left is at its open review cap
+type CapPolicy string
+
+const (
+	// CapQueue leaves the slots empty and marks the PR as awaiting a reviewer
+	CapQueue CapPolicy = "QUEUE"
+	// CapExceed assigns capped candidates anyway and logs a warning
+	CapExceed CapPolicy = "EXCEED"
+	// CapFail rejects the assignment with REVIEWER_CAP_REACHED
+	CapFail CapPolicy = "FAIL"
+)
+
+func (p CapPolicy) IsValid() bool {
+	switch p {
+	case CapQueue, CapExceed, CapFail:
+		return true
+	}
+	return false
+}
+
+// MergeApprovals defines which approvals a PR needs before it can be merged
+type MergeApprovals string
+
+const (
+	// MergeApprovalsNone merges PRs regardless of reviews
+	MergeApprovalsNone MergeApprovals = "NONE"
+	// MergeApprovalsAll requires every assigned reviewer to approve
+	MergeApprovalsAll MergeApprovals = "ALL"
+	// MergeApprovalsQuorum requires approval_quorum approvals of assigned reviewers
+	MergeApprovalsQuorum MergeApprovals = "QUORUM"
+)
+
+func (m MergeApprovals) IsValid() bool {
+	switch m {
+	case MergeApprovalsNone, MergeApprovalsAll, MergeApprovalsQuorum:
+		return true
+	}
+	return false
+}
+
+// DefaultApprovalQuorum is the quorum of teams that never set one
+const DefaultApprovalQuorum = 1
+
+// SLAEscalation defines what happens when a reviewer misses the team's review SLA.
+// A review.sla_breached event is published for every breach whatever the escalation is
+type SLAEscalation string
+
+const (
+	// SLAEscalationNotify only publishes the event
+	SLAEscalationNotify SLAEscalation = "NOTIFY"
+	// SLAEscalationAddReviewer assigns one more reviewer next to the late one
+	SLAEscalationAddReviewer SLAEscalation = "ADD_REVIEWER"
+	// SLAEscalationReassign replaces the late reviewer the way a manual reassign does
+	SLAEscalationReassign SLAEscalation = "REASSIGN"
+)
+
+func (e SLAEscalation) IsValid() bool {
+	switch e {
+	case SLAEscalationNotify, SLAEscalationAddReviewer, SLAEscalationReassign:
+		return true
+	}
+	return false
+}
+
+// MaxReviewSLAHours bounds the review SLA accepted in team settings
+const MaxReviewSLAHours = 24 * 30
+
+// LoadWeighting defines how much an open PR adds to its reviewers' load
+type LoadWeighting string
+
+const (
+	// LoadWeightingCount counts every open PR as 1
+	LoadWeightingCount LoadWeighting = "COUNT"
+	// LoadWeightingSize weighs a PR by its explicit review weight or by its size
+	LoadWeightingSize LoadWeighting = "SIZE"
+)
+
+func (w LoadWeighting) IsValid() bool {
+	switch w {
+	case LoadWeightingCount, LoadWeightingSize:
+		return true
+	}
+	return false
+}
+
+// Size weighting steps: a PR weighs one more point for every step of changed lines or files
+const (
+	DefaultWeightLinesStep = 500
+	DefaultWeightFilesStep = 20
+	MaxWeightStep          = 100000
+)
+
+// Author spread bounds accepted in team settings
+const (
+	MaxAuthorSpreadDays    = 365
+	MaxAuthorSpreadPenalty = 10
+)
+
+// Fairness window bounds accepted in team settings
+const (
+	MaxFairnessWindowDays = 365
+	MaxFairnessWeight     = 10
+)
+
+// TeamSettings tune reviewer assignment of a team. WorkingHoursWindow is the number
+// of hours ahead in which a reviewer starting work still counts as available.
+// AuthorSpreadPenalty is added to a candidate's load for every PR of the same author
+// the candidate was assigned in the last AuthorSpreadDays days, zero in either disables it.
+// FairnessWeight is added likewise for every assignment of the candidate in the last
+// FairnessWindowDays days, whoever the author was. LoadWeighting with the two steps
+// sets the review weight of the team's new PRs. MaxOpenReviews caps open reviews of
+// members without a cap of their own, zero means no cap. MergeApprovals with
+// ApprovalQuorum decide which approvals the team's PRs need to be merged.
+// ReviewSLAHours is the time a reviewer of the team's PRs has to submit a review,
+// counted in the reviewer's working hours with SLABusinessHours, zero disables the SLA.
+// LabelRules ask for a reviewer of another team on the team's PRs having a label
+type TeamSettings struct {
+	TeamName            string             `json:"team_name"`
+	ReviewerStrategy    ReviewerStrategy   `json:"reviewer_strategy"`
+	ReviewerCount       int                `json:"reviewer_count"`
+	UnderstaffedPolicy  UnderstaffedPolicy `json:"understaffed_policy"`
+	FallbackTeams       []string           `json:"fallback_teams"`
+	WorkingHoursPolicy  WorkingHoursPolicy `json:"working_hours_policy"`
+	WorkingHoursWindow  int                `json:"working_hours_window"`
+	NeverPair           []ReviewerPair     `json:"never_pair"`
+	AuthorSpreadDays    int                `json:"author_spread_days"`
+	AuthorSpreadPenalty int                `json:"author_spread_penalty"`
+	FairnessWindowDays  int                `json:"fairness_window_days"`
+	FairnessWeight      int                `json:"fairness_weight"`
+	LoadWeighting       LoadWeighting      `json:"load_weighting"`
+	WeightLinesStep     int                `json:"weight_lines_step"`
+	WeightFilesStep     int                `json:"weight_files_step"`
+	MaxOpenReviews      int                `json:"max_open_reviews"`
+	CapPolicy           CapPolicy          `json:"cap_policy"`
+	MergeApprovals      MergeApprovals     `json:"merge_approvals"`
+	ApprovalQuorum      int                `json:"approval_quorum"`
+	ReviewSLAHours      int                `json:"review_sla_hours"`
+	SLABusinessHours    bool               `json:"sla_business_hours"`
+	SLAEscalation       SLAEscalation      `json:"sla_escalation"`
+	LabelRules          []LabelRule        `json:"label_rules"`
+	UpdatedAt           time.Time          `json:"updated_at"`
+}
+
+// ReviewerPair are two users who must never review each other's PRs
+type ReviewerPair struct {
+	UserA string `json:"user_a"`
+	UserB string `json:"user_b"`
+}
+
+// Normalized orders the pair so that equal pairs compare equal
+func (p ReviewerPair) Normalized() ReviewerPair {
+	if p.UserB < p.UserA {
+		return ReviewerPair{UserA: p.UserB, UserB: p.UserA}
+	}
+	return p
+}
+
+// NeverPaired reports whether a never pair rule forbids the reviewer for the author's PRs
+func (s *TeamSettings) NeverPaired(authorID, reviewerID string) bool {
+
+	pair := ReviewerPair{UserA: authorID, UserB: reviewerID}.Normalized()
+
+	for _, rule := range s.NeverPair {
+		if rule.Normalized() == pair {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LabelRulesFor returns the label rules matched by the labels of the PR, in their order
+func (s *TeamSettings) LabelRulesFor(pr *PullRequest) []LabelRule {
+
+	matched := []LabelRule{}
+
+	for _, rule := range s.LabelRules {
+		if pr.HasLabel(rule.Label) {
+			matched = append(matched, rule)
+		}
+	}
+
+	return matched
+}
+
+// AuthorSpreadEnabled reports whether recent reviews of the same author are penalized
+func (s *TeamSettings) AuthorSpreadEnabled() bool {
+	return s.AuthorSpreadDays > 0 && s.AuthorSpreadPenalty > 0
+}
+
+// FairnessWindowEnabled reports whether recent assignments count towards the load
+func (s *TeamSettings) FairnessWindowEnabled() bool {
+	return s.FairnessWindowDays > 0 && s.FairnessWeight > 0
+}
+
+// FairnessScore is the load a candidate has for the team's strategy
+// given its open load and its assignments within the fairness window
+func (s *TeamSettings) FairnessScore(openLoad, windowAssigned int) int {
+
+	if !s.FairnessWindowEnabled() {
+		return openLoad
+	}
+
+	return openLoad + windowAssigned*s.FairnessWeight
+}
+
+// ReviewWeight is the weight of a new PR in its reviewers' load. With SIZE weighting an explicit
+// weight wins, otherwise the PR weighs 1 plus a point for every WeightLinesStep changed lines
+// and every WeightFilesStep changed files (a zero step is ignored), up to MaxReviewWeight
+func (s *TeamSettings) ReviewWeight(size *PRSize, explicit *int) int {
+
+	if s.LoadWeighting != LoadWeightingSize {
+		return DefaultReviewWeight
+	}
+
+	if explicit != nil {
+		return *explicit
+	}
+
+	if size == nil {
+		return DefaultReviewWeight
+	}
+
+	weight := DefaultReviewWeight
+
+	if s.WeightLinesStep > 0 {
+		weight += (size.LinesAdded + size.LinesRemoved) / s.WeightLinesStep
+	}
+
+	if s.WeightFilesStep > 0 {
+		weight += size.FilesChanged / s.WeightFilesStep
+	}
+
+	return min(weight, MaxReviewWeight)
+}
+
+// ReviewCap is the cap on open reviews of a candidate picked with these settings,
+// the user's own cap wins over the team's one. Zero means no cap
+func (s *TeamSettings) ReviewCap(user *User) int {
+
+	if user.MaxOpenReviews != nil {
+		return *user.MaxOpenReviews
+	}
+
+	return s.MaxOpenReviews
+}
+
+// NeedsApprovals reports whether the team's PRs need approvals to be merged
+func (s *TeamSettings) NeedsApprovals() bool {
+	return s.MergeApprovals == MergeApprovalsAll || s.MergeApprovals == MergeApprovalsQuorum
+}
+
+// MissingApprovals is the number of approvals the PR still needs to be merged.
+// ALL needs an approval of every assigned reviewer. QUORUM needs approval_quorum
+// approvals, but never more than the PR has reviewers: an understaffed PR is merged
+// once all of its reviewers approve. A PR without reviewers cannot be approved,
+// see NeedsApprovals
+func (s *TeamSettings) MissingApprovals(pr *PullRequest) int {
+
+	required := 0
+
+	switch s.MergeApprovals {
+	case MergeApprovalsAll:
+		required = len(pr.AssignedReviewers)
+	case MergeApprovalsQuorum:
+		required = min(s.ApprovalQuorum, len(pr.AssignedReviewers))
+	}
+
+	return max(required-pr.Approvals(), 0)
+}
+
+// ReviewDeadline is the moment a review assigned at assignedAt breaches the SLA,
+// ok is false when the team has no SLA
+func (s *TeamSettings) ReviewDeadline(reviewer *User, assignedAt time.Time) (deadline time.Time, ok bool) {
+
+	if s.ReviewSLAHours <= 0 {
+		return time.Time{}, false
+	}
+
+	budget := time.Duration(s.ReviewSLAHours) * time.Hour
+
+	if s.SLABusinessHours {
+		return reviewer.WorkingDeadline(assignedAt, budget), true
+	}
+
+	return assignedAt.Add(budget), true
+}
+
+// DefaultTeamSettings describes a team that never changed its settings
+func DefaultTeamSettings(teamName string) *TeamSettings {
+	return &TeamSettings{
+		TeamName:           teamName,
+		ReviewerStrategy:   DefaultReviewerStrategy,
+		ReviewerCount:      DefaultReviewerCount,
+		UnderstaffedPolicy: UnderstaffedAllow,
+		FallbackTeams:      []string{},
+		WorkingHoursPolicy: WorkingHoursIgnore,
+		NeverPair:          []ReviewerPair{},
+		LoadWeighting:      LoadWeightingCount,
+		WeightLinesStep:    DefaultWeightLinesStep,
+		WeightFilesStep:    DefaultWeightFilesStep,
+		CapPolicy:          CapExceed,
+		MergeApprovals:     MergeApprovalsNone,
+		ApprovalQuorum:     DefaultApprovalQuorum,
+		SLAEscalation:      SLAEscalationNotify,
+		LabelRules:         []LabelRule{},
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"slices"
 	"time"
 )
 
@@ -9,10 +10,27 @@ type User struct {
 	Username  string    `json:"username"`
 	TeamName  string    `json:"team_name"`
 	IsActive  bool      `json:"is_active"`
+	Tags      []string  `json:"tags"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// IANA timezone and weekly schedule used by working hours policies
+	Timezone     string       `json:"timezone"`
+	WorkingHours WorkingHours `json:"working_hours"`
+
+	// Cap on open reviews, nil leaves the cap to team settings
+	MaxOpenReviews *int `json:"max_open_reviews"`
+
+	// Set while an absence keeps the user off, only such users are switched back on after it
+	DeactivatedByAbsence bool `json:"-"`
+
+	// Audit entries of changes not saved yet
+	audit []AuditEntry
 }
 
+// MaxReviewCap bounds caps on open reviews of users and teams
+const MaxReviewCap = 100
+
 func NewUser(userID, username, teamName string, isActive bool) *User {
 	now := time.Now()
 	return &User{
@@ -20,12 +38,51 @@ func NewUser(userID, username, teamName string, isActive bool) *User {
 		Username:  username,
 		TeamName:  teamName,
 		IsActive:  isActive,
+		Tags:      []string{},
 		CreatedAt: now,
 		UpdatedAt: now,
+
+		Timezone:     DefaultTimezone,
+		WorkingHours: WorkingHours{},
 	}
 }
 
+// SetActive sets the flag by hand, an absence no longer switches the user back on
 func (u *User) SetActive(isActive bool) {
 	u.IsActive = isActive
+	u.DeactivatedByAbsence = false
 	u.UpdatedAt = time.Now()
 }
+
+// RecordAudit keeps an audit entry of a change of the user, the user repository
+// writes it to the audit log in the transaction saving the change
+func (u *User) RecordAudit(entry AuditEntry) {
+	u.audit = append(u.audit, entry)
+}
+
+// AuditEntries returns the audit entries recorded since the user was last saved
+func (u *User) AuditEntries() []AuditEntry {
+	return u.audit
+}
+
+// ClearAudit forgets the recorded audit entries once they are saved
+func (u *User) ClearAudit() {
+	u.audit = nil
+}
+
+// HasTag reports whether the user has the expertise tag
+func (u *User) HasTag(tag string) bool {
+	return slices.Contains(u.Tags, tag)
+}
+
+// ReviewerLoad is the open reviews of a user: their count and the sum of their review weights
+type ReviewerLoad struct {
+	Open     int
+	Weighted int
+}
+
+// ReviewerHistory summarises past review assignments of a user
+type ReviewerHistory struct {
+	TotalAssigned  int
+	LastAssignedAt *time.Time
+}
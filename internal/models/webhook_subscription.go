@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+const MaxWebhookURLLength = 2048
+
+// WebhookSubscription is a URL the service delivers events of the chosen types to.
+// Secret signs the deliveries, it is shown only once when the subscription is created
+type WebhookSubscription struct {
+	SubscriptionID int64       `json:"subscription_id"`
+	URL            string      `json:"url"`
+	Secret         string      `json:"-"`
+	Events         []EventType `json:"events"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// Subscribes tells if events of the type are delivered to the subscription
+func (s *WebhookSubscription) Subscribes(eventType EventType) bool {
+	return slices.Contains(s.Events, eventType)
+}
+
+// DeliveryStatus is the state of an event delivery to a subscription
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is waiting for its first or next attempt
+	DeliveryPending DeliveryStatus = "PENDING"
+	// DeliveryDelivered was accepted by the subscriber with a 2xx response
+	DeliveryDelivered DeliveryStatus = "DELIVERED"
+	// DeliveryFailed ran out of attempts, only a replay sends it again
+	DeliveryFailed DeliveryStatus = "FAILED"
+)
+
+func (s DeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryFailed:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is an entry of the delivery log: one event sent to one subscription.
+// Payload is the JSON body exactly as it is signed and sent, ReplayOf points to the
+// delivery a replay was made from
+type WebhookDelivery struct {
+	DeliveryID     int64           `json:"delivery_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventType      EventType       `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	ReplayOf       *int64          `json:"replay_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryFilter narrows the delivery log, zero fields do not filter
+type WebhookDeliveryFilter struct {
+	SubscriptionID int64
+	EventType      EventType
+	Status         DeliveryStatus
+	Limit          int
+}
@@ -0,0 +1,242 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// WorkingHoursPolicy defines how a team takes reviewers' working hours into account
+type WorkingHoursPolicy string
+
+const (
+	// WorkingHoursIgnore assigns reviewers regardless of their working hours
+	WorkingHoursIgnore WorkingHoursPolicy = "IGNORE"
+	// WorkingHoursPrefer picks available reviewers first, the others only fill the gap
+	WorkingHoursPrefer WorkingHoursPolicy = "PREFER"
+	// WorkingHoursRequire picks available reviewers only
+	WorkingHoursRequire WorkingHoursPolicy = "REQUIRE"
+)
+
+// Bounds and defaults of working hours data
+const (
+	DefaultTimezone       = "UTC"
+	MaxWorkingHoursWindow = 72
+)
+
+func (p WorkingHoursPolicy) IsValid() bool {
+	switch p {
+	case WorkingHoursIgnore, WorkingHoursPrefer, WorkingHoursRequire:
+		return true
+	}
+	return false
+}
+
+// WorkingInterval is a part of the week the user works, in the user's timezone.
+// Start and End are "HH:MM", End may be "24:00"
+type WorkingInterval struct {
+	Day   string `json:"day"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// WorkingHours is a weekly schedule, an empty one means the user is always available
+type WorkingHours []WorkingInterval
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+func (i WorkingInterval) Validate() error {
+
+	if _, ok := weekdays[i.Day]; !ok {
+		return fmt.Errorf("unknown day %q, expected MON..SUN", i.Day)
+	}
+
+	start, err := parseClockTime(i.Start)
+
+	if err != nil {
+		return err
+	}
+
+	end, err := parseClockTime(i.End)
+
+	if err != nil {
+		return err
+	}
+
+	if end <= start {
+		return fmt.Errorf("interval %s-%s on %s ends before it starts", i.Start, i.End, i.Day)
+	}
+
+	return nil
+}
+
+func (w WorkingHours) Validate() error {
+
+	for _, interval := range w {
+		if err := interval.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UntilWorking returns how long after at the user starts working: zero when the user
+// is working at that moment or has no schedule. ok is false when the schedule
+// never starts within a week, which only happens for broken data
+func (u *User) UntilWorking(at time.Time) (until time.Duration, ok bool) {
+
+	if len(u.WorkingHours) == 0 {
+		return 0, true
+	}
+
+	location := u.location()
+	local := at.In(location)
+
+	// Today and the 7 days ahead cover every weekday including today's later intervals
+	for offset := 0; offset <= 7; offset++ {
+
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, location)
+
+		for _, span := range u.workingSpansOn(day) {
+
+			if !local.Before(span.end) {
+				continue
+			}
+
+			if !local.Before(span.start) {
+				return 0, true
+			}
+
+			// spans are sorted and days go forward, the first one ahead is the nearest
+			return span.start.Sub(local), true
+		}
+	}
+
+	return 0, false
+}
+
+// WorkingDeadline returns the moment the user has worked for budget since from.
+// Without a schedule every hour counts, so does a schedule that gives no working
+// time within a year, which only happens for broken data
+func (u *User) WorkingDeadline(from time.Time, budget time.Duration) time.Time {
+
+	if len(u.WorkingHours) == 0 || budget <= 0 {
+		return from.Add(budget)
+	}
+
+	location := u.location()
+	local := from.In(location)
+	cursor := local
+	left := budget
+
+	for offset := 0; offset <= maxScheduleDays; offset++ {
+
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, location)
+
+		for _, span := range u.workingSpansOn(day) {
+
+			// the cursor skips overlapping intervals so no time counts twice
+			if !cursor.Before(span.end) {
+				continue
+			}
+
+			if cursor.Before(span.start) {
+				cursor = span.start
+			}
+
+			if worked := span.end.Sub(cursor); worked < left {
+				left -= worked
+				cursor = span.end
+				continue
+			}
+
+			return cursor.Add(left).In(from.Location())
+		}
+	}
+
+	return from.Add(budget)
+}
+
+// IsAvailableWithin reports whether the user works at the moment or starts within window
+func (u *User) IsAvailableWithin(at time.Time, window time.Duration) bool {
+	until, ok := u.UntilWorking(at)
+	return ok && until <= window
+}
+
+// maxScheduleDays bounds the walk over a schedule when counting working time
+const maxScheduleDays = 366
+
+type workingSpan struct {
+	start, end time.Time
+}
+
+func (u *User) location() *time.Location {
+
+	location, err := time.LoadLocation(u.Timezone)
+
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// the user's working intervals on the given local midnight, sorted by start
+func (u *User) workingSpansOn(day time.Time) []workingSpan {
+
+	spans := []workingSpan{}
+
+	for _, interval := range u.WorkingHours {
+
+		if weekdays[interval.Day] != day.Weekday() {
+			continue
+		}
+
+		startMinutes, errStart := parseClockTime(interval.Start)
+		endMinutes, errEnd := parseClockTime(interval.End)
+
+		if errStart != nil || errEnd != nil {
+			continue
+		}
+
+		// time.Date keeps wall clock times right on DST switch days
+		spans = append(spans, workingSpan{
+			start: time.Date(day.Year(), day.Month(), day.Day(), startMinutes/60, startMinutes%60, 0, 0, day.Location()),
+			end:   time.Date(day.Year(), day.Month(), day.Day(), endMinutes/60, endMinutes%60, 0, 0, day.Location()),
+		})
+	}
+
+	slices.SortFunc(spans, func(a, b workingSpan) int {
+		return a.start.Compare(b.start)
+	})
+
+	return spans
+}
+
+// parses "HH:MM" into minutes since midnight, "24:00" is the end of the day
+func parseClockTime(value string) (int, error) {
+
+	var hours, minutes int
+
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil || len(value) != 5 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+
+	total := hours*60 + minutes
+
+	if hours < 0 || minutes < 0 || minutes > 59 || total > 24*60 {
+		return 0, errors.New("time must be between 00:00 and 24:00")
+	}
+
+	return total, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 )
@@ -9,7 +10,8 @@ import (
 /*
 
 Repository interfaces for data access layer.
-Defines contracts for team, user and pull request data operations.
+Defines contracts for team, user, pull request, availability, assignment trace, review SLA,
+code host login, outbound webhook, outbox and audit log data operations.
 
 */
 
@@ -18,6 +20,11 @@ type TeamRepository interface {
 	Create(ctx context.Context, team *models.Team) error
 	GetByName(ctx context.Context, teamName string) (*models.Team, error)
 	Exists(ctx context.Context, teamName string) (bool, error)
+	UpdateStrategy(ctx context.Context, teamName string, strategy models.ReviewerStrategy) error
+	GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	SaveSettings(ctx context.Context, settings *models.TeamSettings) error
+	GetOwnershipRules(ctx context.Context, teamName string) ([]models.OwnershipRule, error)
+	ReplaceOwnershipRules(ctx context.Context, teamName string, rules []models.OwnershipRule) error
 }
 
 // UserRepository defines the interface for user-related data operations
@@ -25,8 +32,19 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	Update(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, userID string) (*models.User, error)
-	GetActiveByTeam(ctx context.Context, teamName string, excludeUserID string) ([]*models.User, error)
+	// GetActiveByTeam returns active members of the team but excludeUserID, skipping those absent at the given time
+	GetActiveByTeam(ctx context.Context, teamName string, excludeUserID string, at time.Time) ([]*models.User, error)
+	// GetTeamExclusions returns the members GetActiveByTeam leaves out with the same arguments and why
+	GetTeamExclusions(ctx context.Context, teamName string, excludeUserID string, at time.Time) ([]models.TraceExclusion, error)
 	GetReviewerLoad(ctx context.Context, userIDs []string) (map[string]int, error)
+	GetReviewerLoads(ctx context.Context, userIDs []string) (map[string]models.ReviewerLoad, error)
+	GetReviewerHistory(ctx context.Context, userIDs []string) (map[string]models.ReviewerHistory, error)
+	GetAuthorReviewCounts(ctx context.Context, authorID string, userIDs []string, since time.Time) (map[string]int, error)
+	GetAssignmentCounts(ctx context.Context, userIDs []string, since time.Time) (map[string]int, error)
+	GetTags(ctx context.Context, userID string) ([]string, error)
+	AddTags(ctx context.Context, userID string, tags []string) error
+	RemoveTags(ctx context.Context, userID string, tags []string) error
+	ReplaceTags(ctx context.Context, userID string, tags []string) error
 }
 
 // PRRepository defines the interface for pull request-related data operations
@@ -36,5 +54,92 @@ type PRRepository interface {
 	GetByID(ctx context.Context, prID string) (*models.PullRequest, error)
 	Exists(ctx context.Context, prID string) (bool, error)
 	GetByReviewer(ctx context.Context, userID string) ([]*models.PullRequest, error)
+	List(ctx context.Context, filter models.PRFilter) ([]*models.PullRequest, error)
 	GetAssignmentStats(ctx context.Context) (map[string]int, error)
+	SubmitReview(ctx context.Context, prID, userID string, state models.ReviewState, at time.Time) error
+	ListPending(ctx context.Context, teamName string) ([]*models.PendingAssignment, error)
+	RecordPendingAttempt(ctx context.Context, prID string, at time.Time) error
+}
+
+// AbsenceRepository defines the interface for out-of-office schedules
+type AbsenceRepository interface {
+	Create(ctx context.Context, absence *models.Absence) error
+	GetByID(ctx context.Context, absenceID int64) (*models.Absence, error)
+	ListByUser(ctx context.Context, userID string, endedAfter time.Time) ([]*models.Absence, error)
+	Cancel(ctx context.Context, absenceID int64, at time.Time) error
+	// SyncActiveFlags switches is_active off for users whose absence has started
+	// and back on for users whose absence has ended or was cancelled, returning the switched users
+	SyncActiveFlags(ctx context.Context, at time.Time) (deactivated, restored []models.ActiveFlagChange, err error)
+}
+
+// AssignmentTraceRepository defines the interface for decision traces of reviewer assignment
+type AssignmentTraceRepository interface {
+	Create(ctx context.Context, trace *models.AssignmentTrace) error
+	ListByPR(ctx context.Context, prID string) ([]*models.AssignmentTrace, error)
+}
+
+// SLARepository defines the interface for review SLA breaches
+type SLARepository interface {
+	// ListAwaitedReviews returns PENDING reviews of OPEN PRs whose team SLA in calendar hours
+	// has run out by at and whose assignment has no breach recorded yet
+	ListAwaitedReviews(ctx context.Context, at time.Time) ([]*models.AwaitedReview, error)
+	// CreateBreach records the breach unless the same assignment already has one, created tells which
+	CreateBreach(ctx context.Context, breach *models.SLABreach) (created bool, err error)
+	ResolveBreach(ctx context.Context, breachID int64, escalatedTo, escalationError string) error
+	ListBreaches(ctx context.Context, filter models.SLABreachFilter) ([]*models.SLABreach, error)
+}
+
+// LoginRepository defines the interface for code host accounts mapped to users
+type LoginRepository interface {
+	// Save maps the login to the user, replacing an earlier mapping of the login
+	Save(ctx context.Context, login *models.CodeHostLogin) error
+	Delete(ctx context.Context, host models.CodeHost, login string) error
+	// FindUserID returns the user the login is mapped to, ErrLoginNotMapped if none
+	FindUserID(ctx context.Context, host models.CodeHost, login string) (string, error)
+	List(ctx context.Context, host models.CodeHost) ([]*models.CodeHostLogin, error)
+}
+
+// WebhookRepository defines the interface for outbound webhook subscriptions and their delivery log
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscription(ctx context.Context, subscriptionID int64) (*models.WebhookSubscription, error)
+	// DeleteSubscription drops the subscription together with its deliveries
+	DeleteSubscription(ctx context.Context, subscriptionID int64) error
+	ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+	// ListSubscribers returns the subscriptions receiving events of the type
+	ListSubscribers(ctx context.Context, eventType models.EventType) ([]*models.WebhookSubscription, error)
+
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, deliveryID int64) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error)
+	// ClaimDueDeliveries returns up to limit PENDING deliveries due by at, oldest first, and moves
+	// their next attempt to at+lease, so other workers skip them while they are being sent
+	ClaimDueDeliveries(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error)
+	// RecordAttempt saves the status, attempts and outcome fields of the delivery
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+// OutboxRepository defines the interface for the transactional outbox of domain events.
+// PRRepository writes the events recorded on a PR in its own transactions
+type OutboxRepository interface {
+	// Append saves events that are not part of a PR change
+	Append(ctx context.Context, events ...models.Event) error
+	// ClaimDue returns up to limit PENDING events due by at that are the oldest PENDING event
+	// of their aggregate, and moves their next attempt to at+lease so other dispatchers skip them
+	ClaimDue(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]*models.OutboxEvent, error)
+	// RecordAttempt saves the status, attempts and outcome fields of the event
+	RecordAttempt(ctx context.Context, event *models.OutboxEvent) error
+	ListDead(ctx context.Context, limit int) ([]*models.OutboxEvent, error)
+	// Requeue makes a DEAD event PENDING again with fresh attempts, ErrOutboxEventNotFound if there is none
+	Requeue(ctx context.Context, eventID int64, at time.Time) error
+}
+
+// AuditRepository defines the interface for the append-only audit log.
+// PRRepository writes the audit entries recorded on a PR in its own transactions
+type AuditRepository interface {
+	// Append saves audit entries of changes that are not PR changes
+	Append(ctx context.Context, entries ...models.AuditEntry) error
+	// List returns up to filter.Limit entries matching the filter latest first,
+	// only entries older than filter.Cursor when it is set
+	List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error)
 }
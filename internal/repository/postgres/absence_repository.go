@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+PostgreSQL implementation for absence repository.
+Stores out-of-office schedules and keeps users.is_active in line with them:
+the applied column remembers absences that switched a user off and
+users.deactivated_by_absence the users they switched off. Only users still marked are
+switched back on when the absence is over: setting is_active by hand clears the marker,
+other changes of the user (working hours, review cap) keep it.
+The sync returns the switched users as they were before and after, for the audit log.
+
+*/
+
+type absenceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAbsenceRepository(db *pgxpool.Pool) repository.AbsenceRepository {
+	return &absenceRepository{db: db}
+}
+
+func (r *absenceRepository) Create(ctx context.Context, absence *models.Absence) error {
+
+	query := `
+		INSERT INTO absences (user_id, starts_at, ends_at, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING absence_id
+	`
+
+	return r.db.QueryRow(ctx, query,
+		absence.UserID, absence.StartsAt, absence.EndsAt, absence.Reason, absence.CreatedAt,
+	).Scan(&absence.AbsenceID)
+}
+
+func (r *absenceRepository) GetByID(ctx context.Context, absenceID int64) (*models.Absence, error) {
+
+	absence := models.Absence{}
+
+	query := `
+		SELECT absence_id, user_id, starts_at, ends_at, reason, cancelled_at, created_at
+		FROM absences WHERE absence_id = $1
+	`
+
+	err := r.db.QueryRow(ctx, query, absenceID).Scan(
+		&absence.AbsenceID, &absence.UserID, &absence.StartsAt, &absence.EndsAt,
+		&absence.Reason, &absence.CancelledAt, &absence.CreatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrAbsenceNotFound
+		}
+		return nil, err
+	}
+
+	return &absence, nil
+}
+
+func (r *absenceRepository) ListByUser(ctx context.Context, userID string, endedAfter time.Time) ([]*models.Absence, error) {
+
+	query := `
+		SELECT absence_id, user_id, starts_at, ends_at, reason, cancelled_at, created_at
+		FROM absences
+		WHERE user_id = $1 AND ends_at > $2
+		ORDER BY starts_at, absence_id
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, endedAfter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	absences := []*models.Absence{}
+
+	for rows.Next() {
+		var absence models.Absence
+
+		err := rows.Scan(
+			&absence.AbsenceID, &absence.UserID, &absence.StartsAt, &absence.EndsAt,
+			&absence.Reason, &absence.CancelledAt, &absence.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		absences = append(absences, &absence)
+	}
+
+	return absences, nil
+}
+
+func (r *absenceRepository) Cancel(ctx context.Context, absenceID int64, at time.Time) error {
+
+	query := `
+		UPDATE absences SET cancelled_at = $2
+		WHERE absence_id = $1 AND cancelled_at IS NULL
+	`
+
+	result, err := r.db.Exec(ctx, query, absenceID, at)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrAbsenceNotFound
+	}
+
+	return nil
+}
+
+func (r *absenceRepository) SyncActiveFlags(ctx context.Context, at time.Time) ([]models.ActiveFlagChange, []models.ActiveFlagChange, error) {
+
+	tx, err := r.db.Begin(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback transaction")
+		}
+	}()
+
+	// Switch users back on once their applied absence is over, unless another applied
+	// absence of theirs is still running or the flag was set by hand since the absence switched them off
+	queryRestore := `
+		WITH closed AS (
+			UPDATE absences SET applied = false
+			WHERE applied AND (ends_at <= $1 OR cancelled_at IS NOT NULL)
+			RETURNING user_id
+		)
+		UPDATE users SET is_active = true, deactivated_by_absence = false, updated_at = $1
+		FROM users old
+		WHERE old.user_id = users.user_id
+		AND users.user_id IN (SELECT user_id FROM closed)
+		AND NOT users.is_active AND users.deactivated_by_absence
+		AND NOT EXISTS (
+			SELECT 1 FROM absences a
+			WHERE a.user_id = users.user_id AND a.applied
+			AND a.cancelled_at IS NULL AND a.ends_at > $1
+		)
+		RETURNING users.user_id, old.updated_at
+	`
+
+	restored, err := switchActiveFlags(ctx, tx, queryRestore, at)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Switch off active users whose absence has started,
+	// users already switched off by hand are left alone
+	queryDeactivate := `
+		WITH started AS (
+			UPDATE absences a SET applied = true
+			FROM users u
+			WHERE u.user_id = a.user_id AND u.is_active
+			AND NOT a.applied AND a.cancelled_at IS NULL
+			AND a.starts_at <= $1 AND a.ends_at > $1
+			RETURNING a.user_id
+		)
+		UPDATE users SET is_active = false, deactivated_by_absence = true, updated_at = $1
+		FROM users old
+		WHERE old.user_id = users.user_id
+		AND users.user_id IN (SELECT user_id FROM started)
+		RETURNING users.user_id, old.updated_at
+	`
+
+	deactivated, err := switchActiveFlags(ctx, tx, queryDeactivate, at)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return deactivated, restored, nil
+}
+
+// switchActiveFlags runs an update of is_active returning user_id and the previous updated_at
+// of the switched users, and reads the users as the update left them
+func switchActiveFlags(ctx context.Context, tx pgx.Tx, query string, at time.Time) ([]models.ActiveFlagChange, error) {
+
+	rows, err := tx.Query(ctx, query, at)
+
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string]time.Time)
+
+	for rows.Next() {
+
+		var userID string
+		var updatedAt time.Time
+
+		if err := rows.Scan(&userID, &updatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		previous[userID] = updatedAt
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(previous) == 0 {
+		return nil, nil
+	}
+
+	userIDs := make([]string, 0, len(previous))
+
+	for userID := range previous {
+		userIDs = append(userIDs, userID)
+	}
+
+	query = `
+		SELECT user_id, username, team_name, is_active, created_at, updated_at, timezone, working_hours, max_open_reviews,
+			ARRAY(SELECT tag FROM user_tags t WHERE t.user_id = users.user_id ORDER BY tag)
+		FROM users WHERE user_id = ANY($1)
+		ORDER BY user_id
+	`
+
+	rows, err = tx.Query(ctx, query, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	changes := make([]models.ActiveFlagChange, 0, len(previous))
+
+	for rows.Next() {
+
+		user := models.User{}
+
+		err := rows.Scan(
+			&user.UserID, &user.Username, &user.TeamName,
+			&user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+			&user.Timezone, &user.WorkingHours, &user.MaxOpenReviews, &user.Tags,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		before := user
+		before.IsActive = !user.IsActive
+		before.UpdatedAt = previous[user.UserID]
+
+		changes = append(changes, models.ActiveFlagChange{Before: &before, After: &user})
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+
+PostgreSQL implementation for assignment trace repository.
+Exclusions and selection rounds are stored as JSONB, they are only read back whole.
+
+*/
+
+type assignmentTraceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAssignmentTraceRepository(db *pgxpool.Pool) repository.AssignmentTraceRepository {
+	return &assignmentTraceRepository{db: db}
+}
+
+func (r *assignmentTraceRepository) Create(ctx context.Context, trace *models.AssignmentTrace) error {
+
+	query := `
+		INSERT INTO assignment_traces
+			(pull_request_id, action, replaced_user_id, strategy, selected, excluded, rounds, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8)
+		RETURNING trace_id
+	`
+
+	return r.db.QueryRow(ctx, query,
+		trace.PullRequestID, trace.Action, trace.ReplacedUserID, trace.Strategy,
+		trace.Selected, trace.Excluded, trace.Rounds, trace.CreatedAt,
+	).Scan(&trace.TraceID)
+}
+
+func (r *assignmentTraceRepository) ListByPR(ctx context.Context, prID string) ([]*models.AssignmentTrace, error) {
+
+	query := `
+		SELECT trace_id, pull_request_id, action, COALESCE(replaced_user_id, ''), strategy,
+			selected, excluded, rounds, created_at
+		FROM assignment_traces
+		WHERE pull_request_id = $1
+		ORDER BY trace_id
+	`
+
+	rows, err := r.db.Query(ctx, query, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	traces := []*models.AssignmentTrace{}
+
+	for rows.Next() {
+		var trace models.AssignmentTrace
+
+		err := rows.Scan(
+			&trace.TraceID, &trace.PullRequestID, &trace.Action, &trace.ReplacedUserID, &trace.Strategy,
+			&trace.Selected, &trace.Excluded, &trace.Rounds, &trace.CreatedAt,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		traces = append(traces, &trace)
+	}
+
+	return traces, nil
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+
+PostgreSQL implementation for audit log repository.
+Entries are only inserted, a trigger rejects updates and deletes of audit_events.
+Pages are keyed by audit_id: a page lists entries older than the last entry of the previous one.
+
+*/
+
+type auditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Append(ctx context.Context, entries ...models.AuditEntry) error {
+	return appendAudit(ctx, r.db, entries)
+}
+
+func (r *auditRepository) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+
+	query := `
+		SELECT audit_id, actor, action, entity_type, entity_id, before_state, after_state, created_at
+		FROM audit_events
+		WHERE ($1 = '' OR actor = $1) AND ($2 = '' OR action = $2)
+			AND ($3 = '' OR entity_type = $3) AND ($4 = '' OR entity_id = $4)
+			AND ($5::timestamp IS NULL OR created_at >= $5) AND ($6::timestamp IS NULL OR created_at < $6)
+			AND ($7 = 0 OR audit_id < $7)
+		ORDER BY audit_id DESC
+		LIMIT $8
+	`
+
+	rows, err := r.db.Query(ctx, query, filter.Actor, filter.Action, filter.EntityType, filter.EntityID,
+		filter.From, filter.To, filter.Cursor, filter.Limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := []*models.AuditEntry{}
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+
+		err := rows.Scan(&entry.AuditID, &entry.Actor, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &entry.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Before = rawState(before)
+		entry.After = rawState(after)
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// writes the audit entries, PR changes pass their transaction
+// commitAudited writes the audit entries of a change and commits the transaction saving it
+func commitAudited(ctx context.Context, tx pgx.Tx, entries []models.AuditEntry) error {
+
+	if err := appendAudit(ctx, tx, entries); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func appendAudit(ctx context.Context, db execer, entries []models.AuditEntry) error {
+
+	query := `
+		INSERT INTO audit_events (actor, action, entity_type, entity_id, before_state, after_state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for _, entry := range entries {
+
+		before, err := marshalState(entry.Before)
+
+		if err != nil {
+			return err
+		}
+
+		after, err := marshalState(entry.After)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(ctx, query, entry.Actor, entry.Action, entry.EntityType, entry.EntityID, before, after, entry.CreatedAt)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// JSON of an entity state, nil stays NULL
+func marshalState(state any) (*string, error) {
+
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(data)
+
+	return &text, nil
+}
+
+// saved JSON of an entity state, NULL stays nil
+func rawState(data []byte) any {
+
+	if data == nil {
+		return nil
+	}
+
+	return json.RawMessage(data)
+}
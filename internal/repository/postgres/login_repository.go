@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+
+PostgreSQL implementation for code host login repository.
+A login belongs to one user per code host, a user may have several logins.
+Logins are stored lowercased, callers normalize them.
+
+*/
+
+type loginRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLoginRepository(db *pgxpool.Pool) repository.LoginRepository {
+	return &loginRepository{db: db}
+}
+
+func (r *loginRepository) Save(ctx context.Context, login *models.CodeHostLogin) error {
+
+	query := `
+		INSERT INTO code_host_logins (code_host, login, user_id, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code_host, login) DO UPDATE
+		SET user_id = EXCLUDED.user_id, created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.db.Exec(ctx, query, login.CodeHost, login.Login, login.UserID, login.CreatedAt)
+
+	return err
+}
+
+func (r *loginRepository) Delete(ctx context.Context, host models.CodeHost, login string) error {
+
+	query := `DELETE FROM code_host_logins WHERE code_host = $1 AND login = $2`
+
+	result, err := r.db.Exec(ctx, query, host, login)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrLoginNotMapped
+	}
+
+	return nil
+}
+
+func (r *loginRepository) FindUserID(ctx context.Context, host models.CodeHost, login string) (string, error) {
+
+	var userID string
+
+	query := `SELECT user_id FROM code_host_logins WHERE code_host = $1 AND login = $2`
+
+	err := r.db.QueryRow(ctx, query, host, login).Scan(&userID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apperrors.ErrLoginNotMapped
+		}
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func (r *loginRepository) List(ctx context.Context, host models.CodeHost) ([]*models.CodeHostLogin, error) {
+
+	query := `
+		SELECT code_host, login, user_id, created_at
+		FROM code_host_logins
+		WHERE code_host = $1
+		ORDER BY login
+	`
+
+	rows, err := r.db.Query(ctx, query, host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	logins := []*models.CodeHostLogin{}
+
+	for rows.Next() {
+		var login models.CodeHostLogin
+
+		if err := rows.Scan(&login.CodeHost, &login.Login, &login.UserID, &login.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		logins = append(logins, &login)
+	}
+
+	return logins, rows.Err()
+}
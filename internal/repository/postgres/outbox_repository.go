@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+
+PostgreSQL implementation for outbox repository.
+Only the oldest PENDING event of an aggregate can be claimed, so the events of a PR
+reach the sinks in the order they happened; a DEAD event steps out of the line.
+Claims use FOR UPDATE SKIP LOCKED and a lease on next_attempt_at like webhook deliveries.
+
+*/
+
+type outboxRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxRepository(db *pgxpool.Pool) repository.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+const outboxColumns = `event_id, aggregate_type, aggregate_id, event_type, payload, occurred_at, status,
+	attempts, next_attempt_at, COALESCE(last_error, ''), dispatched_at`
+
+// runs statements in a transaction or right on the pool
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func (r *outboxRepository) Append(ctx context.Context, events ...models.Event) error {
+	return appendOutbox(ctx, r.db, events)
+}
+
+func (r *outboxRepository) ClaimDue(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]*models.OutboxEvent, error) {
+
+	query := `
+		WITH heads AS (
+			SELECT DISTINCT ON (aggregate_type, aggregate_id) event_id
+			FROM outbox_events
+			WHERE status = 'PENDING'
+			ORDER BY aggregate_type, aggregate_id, event_id
+		)
+		UPDATE outbox_events SET next_attempt_at = $2
+		WHERE event_id IN (
+			SELECT o.event_id FROM outbox_events o
+			JOIN heads h ON h.event_id = o.event_id
+			WHERE o.next_attempt_at <= $1
+			ORDER BY o.event_id
+			LIMIT $3
+			FOR UPDATE OF o SKIP LOCKED
+		)
+		RETURNING ` + outboxColumns
+
+	rows, err := r.db.Query(ctx, query, at, at.Add(lease), limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events, err := collectOutboxEvents(rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING keeps no order, events are dispatched oldest first
+	slices.SortFunc(events, func(a, b *models.OutboxEvent) int {
+		return cmp.Compare(a.EventID, b.EventID)
+	})
+
+	return events, nil
+}
+
+func (r *outboxRepository) RecordAttempt(ctx context.Context, event *models.OutboxEvent) error {
+
+	query := `
+		UPDATE outbox_events
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = NULLIF($5, ''), dispatched_at = $6
+		WHERE event_id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		event.EventID, event.Status, event.Attempts, event.NextAttemptAt, event.LastError, event.DispatchedAt)
+
+	return err
+}
+
+func (r *outboxRepository) ListDead(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+
+	query := `
+		SELECT ` + outboxColumns + `
+		FROM outbox_events
+		WHERE status = 'DEAD'
+		ORDER BY event_id DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return collectOutboxEvents(rows)
+}
+
+func (r *outboxRepository) Requeue(ctx context.Context, eventID int64, at time.Time) error {
+
+	query := `
+		UPDATE outbox_events SET status = 'PENDING', attempts = 0, next_attempt_at = $2
+		WHERE event_id = $1 AND status = 'DEAD'
+	`
+
+	result, err := r.db.Exec(ctx, query, eventID, at)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrOutboxEventNotFound
+	}
+
+	return nil
+}
+
+// writes the events to the outbox, PR changes pass their transaction
+func appendOutbox(ctx context.Context, db execer, events []models.Event) error {
+
+	query := `
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload, occurred_at, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for _, event := range events {
+
+		outboxEvent, err := models.NewOutboxEvent(event)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(ctx, query,
+			outboxEvent.AggregateType, outboxEvent.AggregateID, outboxEvent.EventType, string(outboxEvent.Payload),
+			outboxEvent.OccurredAt, outboxEvent.Status, outboxEvent.NextAttemptAt)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func collectOutboxEvents(rows pgx.Rows) ([]*models.OutboxEvent, error) {
+
+	events := []*models.OutboxEvent{}
+
+	for rows.Next() {
+		var event models.OutboxEvent
+		var payload []byte
+
+		err := rows.Scan(&event.EventID, &event.AggregateType, &event.AggregateID, &event.EventType, &payload,
+			&event.OccurredAt, &event.Status, &event.Attempts, &event.NextAttemptAt, &event.LastError, &event.DispatchedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		event.Payload = payload
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
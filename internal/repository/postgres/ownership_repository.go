@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+PostgreSQL implementation of code ownership rules storage.
+Rules of a team are replaced as a whole, their order is kept in the position column.
+
+*/
+
+func (r *teamRepository) GetOwnershipRules(ctx context.Context, teamName string) ([]models.OwnershipRule, error) {
+
+	query := `
+		SELECT pattern, owner_users, owner_teams FROM ownership_rules
+		WHERE team_name = $1
+		ORDER BY position
+	`
+
+	rows, err := r.db.Query(ctx, query, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	rules := []models.OwnershipRule{}
+
+	for rows.Next() {
+		var rule models.OwnershipRule
+
+		if err := rows.Scan(&rule.Pattern, &rule.OwnerUsers, &rule.OwnerTeams); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (r *teamRepository) ReplaceOwnershipRules(ctx context.Context, teamName string, rules []models.OwnershipRule) error {
+
+	tx, err := r.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback transaction")
+		}
+	}()
+
+	queryDelete := `
+		DELETE FROM ownership_rules WHERE team_name = $1
+	`
+
+	_, err = tx.Exec(ctx, queryDelete, teamName)
+
+	if err != nil {
+		return err
+	}
+
+	queryInsert := `
+		INSERT INTO ownership_rules (team_name, position, pattern, owner_users, owner_teams)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	for i, rule := range rules {
+
+		_, err = tx.Exec(ctx, queryInsert, teamName, i+1, rule.Pattern, rule.OwnerUsers, rule.OwnerTeams)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"errors"
+	"time"
 
 	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
@@ -16,6 +17,8 @@ import (
 
 PostgreSQL implementation for pull request repository.
 Handles PR CRUD operations, reviewer assignments and statistics with transaction support.
+Create and Update write the domain events and audit entries recorded on the PR to the outbox
+and the audit log in the same transaction.
 
 */
 
@@ -42,12 +45,22 @@ func (r *prRepository) Create(ctx context.Context, pr *models.PullRequest) error
 	}()
 
 	queryInsertPR := `
-        INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-        VALUES ($1, $2, $3, $4, $5)
+        INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, understaffed, changed_files, required_tags,
+            lines_added, lines_removed, files_changed, review_weight, awaiting_reviewer, created_at,
+            repository, url, base_branch, labels, description)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), NULLIF($15, ''), NULLIF($16, ''), $17, NULLIF($18, ''))
     `
 
+	var linesAdded, linesRemoved, filesChanged *int
+
+	if pr.Size != nil {
+		linesAdded, linesRemoved, filesChanged = &pr.Size.LinesAdded, &pr.Size.LinesRemoved, &pr.Size.FilesChanged
+	}
+
 	_, err = tx.Exec(ctx, queryInsertPR, pr.PullRequestID, pr.PullRequestName,
-		pr.AuthorID, pr.Status, pr.CreatedAt,
+		pr.AuthorID, pr.Status, pr.Understaffed, nonNil(pr.ChangedFiles), nonNil(pr.RequiredTags),
+		linesAdded, linesRemoved, filesChanged, pr.ReviewWeight, pr.AwaitingReviewer, pr.CreatedAt,
+		pr.Repository, pr.URL, pr.BaseBranch, nonNil(pr.Labels), pr.Description,
 	)
 
 	if err != nil {
@@ -55,18 +68,22 @@ func (r *prRepository) Create(ctx context.Context, pr *models.PullRequest) error
 	}
 
 	queryInsertReviewers := `
-		INSERT INTO pr_reviewers (pull_request_id, user_id)
-        VALUES ($1, $2)
+		INSERT INTO pr_reviewers (pull_request_id, user_id, from_fallback)
+        VALUES ($1, $2, $3)
 	`
 
 	for _, reviewerID := range pr.AssignedReviewers {
-		_, err = tx.Exec(ctx, queryInsertReviewers, pr.PullRequestID, reviewerID)
+		_, err = tx.Exec(ctx, queryInsertReviewers, pr.PullRequestID, reviewerID, pr.IsFallbackReviewer(reviewerID))
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit(ctx)
+	if err := syncPending(ctx, tx, pr); err != nil {
+		return err
+	}
+
+	return commitRecorded(ctx, tx, pr)
 }
 
 func (r *prRepository) Update(ctx context.Context, pr *models.PullRequest) error {
@@ -87,55 +104,77 @@ func (r *prRepository) Update(ctx context.Context, pr *models.PullRequest) error
         UPDATE pull_requests SET
             pull_request_name = $2,
             status = $3,
-            merged_at = $4
+            merged_at = $4,
+            understaffed = $5,
+            awaiting_reviewer = $6,
+            closed_at = $7,
+            repository = NULLIF($8, ''),
+            url = NULLIF($9, ''),
+            base_branch = NULLIF($10, ''),
+            labels = $11,
+            description = NULLIF($12, '')
         WHERE pull_request_id = $1
     `
 
-	_, err = tx.Exec(ctx, queryUpdatePR, pr.PullRequestID, pr.PullRequestName, pr.Status, pr.MergedAt)
+	_, err = tx.Exec(ctx, queryUpdatePR, pr.PullRequestID, pr.PullRequestName, pr.Status, pr.MergedAt, pr.Understaffed, pr.AwaitingReviewer, pr.ClosedAt,
+		pr.Repository, pr.URL, pr.BaseBranch, nonNil(pr.Labels), pr.Description)
 
 	if err != nil {
 		return err
 	}
 
+	// Keep rows of reviewers that stay on the PR so their assigned_at is preserved
+	reviewers := nonNil(pr.AssignedReviewers)
+
 	queryDeleteOld := `
-		DELETE FROM pr_reviewers WHERE pull_request_id = $1
+		DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND NOT (user_id = ANY($2))
 	`
 
-	_, err = tx.Exec(ctx, queryDeleteOld, pr.PullRequestID)
+	_, err = tx.Exec(ctx, queryDeleteOld, pr.PullRequestID, reviewers)
 
 	if err != nil {
 		return err
 	}
 
 	queryInsertNew := `
-        INSERT INTO pr_reviewers (pull_request_id, user_id)
-        VALUES ($1, $2)
+        INSERT INTO pr_reviewers (pull_request_id, user_id, from_fallback)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (pull_request_id, user_id) DO NOTHING
     `
 
 	for _, reviewerID := range pr.AssignedReviewers {
 
-		_, err = tx.Exec(ctx, queryInsertNew, pr.PullRequestID, reviewerID)
+		_, err = tx.Exec(ctx, queryInsertNew, pr.PullRequestID, reviewerID, pr.IsFallbackReviewer(reviewerID))
 
 		if err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit(ctx)
+	if err := syncPending(ctx, tx, pr); err != nil {
+		return err
+	}
+
+	return commitRecorded(ctx, tx, pr)
 }
 
 func (r *prRepository) GetByID(ctx context.Context, prID string) (*models.PullRequest, error) {
 
 	pr := models.PullRequest{}
+	var linesAdded, linesRemoved, filesChanged *int
 
 	query := `
-        SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+        SELECT pull_request_id, pull_request_name, author_id, status, understaffed, changed_files, required_tags,
+            lines_added, lines_removed, files_changed, review_weight, awaiting_reviewer, created_at, merged_at, closed_at,
+            ` + prMetadataColumns + `
         FROM pull_requests WHERE pull_request_id = $1
 	`
 
 	err := r.db.QueryRow(ctx, query, prID).Scan(
 		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID,
-		&pr.Status, &pr.CreatedAt, &pr.MergedAt,
+		&pr.Status, &pr.Understaffed, &pr.ChangedFiles, &pr.RequiredTags,
+		&linesAdded, &linesRemoved, &filesChanged, &pr.ReviewWeight, &pr.AwaitingReviewer, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt,
+		&pr.Repository, &pr.URL, &pr.BaseBranch, &pr.Labels, &pr.Description,
 	)
 
 	if err != nil {
@@ -145,8 +184,10 @@ func (r *prRepository) GetByID(ctx context.Context, prID string) (*models.PullRe
 		return nil, err
 	}
 
+	pr.Size = prSize(linesAdded, linesRemoved, filesChanged)
+
 	queryGetReviewers := `
-	    SELECT user_id FROM pr_reviewers WHERE pull_request_id = $1 ORDER BY assigned_at
+	    SELECT user_id, from_fallback, review_state, reviewed_at FROM pr_reviewers WHERE pull_request_id = $1 ORDER BY assigned_at
 	`
 
 	rows, err := r.db.Query(ctx, queryGetReviewers, prID)
@@ -158,13 +199,23 @@ func (r *prRepository) GetByID(ctx context.Context, prID string) (*models.PullRe
 	defer rows.Close()
 
 	pr.AssignedReviewers = []string{}
+	pr.FallbackReviewers = []string{}
+	pr.Reviews = []models.Review{}
 
 	for rows.Next() {
 		var reviewerID string
-		if err := rows.Scan(&reviewerID); err != nil {
+		var fromFallback bool
+		var state models.ReviewState
+		var reviewedAt *time.Time
+		if err := rows.Scan(&reviewerID, &fromFallback, &state, &reviewedAt); err != nil {
 			return nil, err
 		}
-		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		if fromFallback {
+			pr.AddFallbackReviewer(reviewerID)
+		} else {
+			pr.AddReviewer(reviewerID)
+		}
+		pr.SetReview(reviewerID, state, reviewedAt)
 	}
 
 	return &pr, nil
@@ -186,7 +237,8 @@ func (r *prRepository) Exists(ctx context.Context, prID string) (bool, error) {
 func (r *prRepository) GetByReviewer(ctx context.Context, userID string) ([]*models.PullRequest, error) {
 
 	query := `
-        SELECT DISTINCT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at
+        SELECT DISTINCT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at,
+            r.review_state, r.reviewed_at
         FROM pull_requests p
         JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id
         WHERE r.user_id = $1
@@ -205,17 +257,138 @@ func (r *prRepository) GetByReviewer(ctx context.Context, userID string) ([]*mod
 
 	for rows.Next() {
 		pr := models.PullRequest{}
+		review := models.Review{UserID: userID}
 
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt); err != nil {
+		err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &pr.CreatedAt,
+			&review.State, &review.SubmittedAt)
+
+		if err != nil {
 			return nil, err
 		}
 
+		// Only the review of the user is known here
+		pr.Reviews = []models.Review{review}
+
 		prs = append(prs, &pr)
 	}
 
 	return prs, nil
 }
 
+// optional metadata columns, NULL ones are read as empty strings
+const prMetadataColumns = `COALESCE(repository, ''), COALESCE(url, ''), COALESCE(base_branch, ''), labels, COALESCE(description, '')`
+
+// size columns are written together, one of them tells whether the size is known
+func prSize(linesAdded, linesRemoved, filesChanged *int) *models.PRSize {
+
+	if linesAdded == nil {
+		return nil
+	}
+
+	return &models.PRSize{LinesAdded: *linesAdded, LinesRemoved: *linesRemoved, FilesChanged: *filesChanged}
+}
+
+func (r *prRepository) List(ctx context.Context, filter models.PRFilter) ([]*models.PullRequest, error) {
+
+	query := `
+        SELECT pull_request_id, pull_request_name, author_id, status, understaffed, changed_files, required_tags,
+            lines_added, lines_removed, files_changed, review_weight, awaiting_reviewer, created_at, merged_at, closed_at,
+            ` + prMetadataColumns + `
+        FROM pull_requests
+        WHERE ($1 = '' OR repository = $1) AND ($2 = '' OR $2 = ANY(labels))
+            AND ($3 = '' OR status = $3) AND ($4 = '' OR author_id = $4)
+        ORDER BY created_at DESC, pull_request_id
+        LIMIT $5
+    `
+
+	rows, err := r.db.Query(ctx, query, filter.Repository, filter.Label, filter.Status, filter.AuthorID, filter.Limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	prs := []*models.PullRequest{}
+	byID := make(map[string]*models.PullRequest)
+
+	for rows.Next() {
+		pr := models.PullRequest{
+			AssignedReviewers: []string{},
+			FallbackReviewers: []string{},
+			Reviews:           []models.Review{},
+		}
+		var linesAdded, linesRemoved, filesChanged *int
+
+		err := rows.Scan(
+			&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID,
+			&pr.Status, &pr.Understaffed, &pr.ChangedFiles, &pr.RequiredTags,
+			&linesAdded, &linesRemoved, &filesChanged, &pr.ReviewWeight, &pr.AwaitingReviewer, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt,
+			&pr.Repository, &pr.URL, &pr.BaseBranch, &pr.Labels, &pr.Description,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pr.Size = prSize(linesAdded, linesRemoved, filesChanged)
+		prs = append(prs, &pr)
+		byID[pr.PullRequestID] = &pr
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(prs) == 0 {
+		return prs, nil
+	}
+
+	// Reviewers of all listed PRs in one query
+	queryGetReviewers := `
+        SELECT pull_request_id, user_id, from_fallback, review_state, reviewed_at
+        FROM pr_reviewers WHERE pull_request_id = ANY($1)
+        ORDER BY assigned_at
+    `
+
+	ids := make([]string, len(prs))
+
+	for i, pr := range prs {
+		ids[i] = pr.PullRequestID
+	}
+
+	reviewerRows, err := r.db.Query(ctx, queryGetReviewers, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer reviewerRows.Close()
+
+	for reviewerRows.Next() {
+		var prID, reviewerID string
+		var fromFallback bool
+		var state models.ReviewState
+		var reviewedAt *time.Time
+
+		if err := reviewerRows.Scan(&prID, &reviewerID, &fromFallback, &state, &reviewedAt); err != nil {
+			return nil, err
+		}
+
+		pr := byID[prID]
+
+		if fromFallback {
+			pr.AddFallbackReviewer(reviewerID)
+		} else {
+			pr.AddReviewer(reviewerID)
+		}
+
+		pr.SetReview(reviewerID, state, reviewedAt)
+	}
+
+	return prs, reviewerRows.Err()
+}
+
 func (r *prRepository) GetAssignmentStats(ctx context.Context) (map[string]int, error) {
 
 	query := `
@@ -247,3 +420,126 @@ func (r *prRepository) GetAssignmentStats(ctx context.Context) (map[string]int,
 
 	return stats, nil
 }
+
+func (r *prRepository) SubmitReview(ctx context.Context, prID, userID string, state models.ReviewState, at time.Time) error {
+
+	query := `
+        UPDATE pr_reviewers SET review_state = $3, reviewed_at = $4
+        WHERE pull_request_id = $1 AND user_id = $2
+    `
+
+	result, err := r.db.Exec(ctx, query, prID, userID, state, at)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrNotAssigned
+	}
+
+	return nil
+}
+
+func (r *prRepository) ListPending(ctx context.Context, teamName string) ([]*models.PendingAssignment, error) {
+
+	query := `
+        SELECT q.pull_request_id, p.pull_request_name, p.author_id, u.team_name, q.reason,
+            q.enqueued_at, q.attempts, q.last_attempt_at,
+            COALESCE(ARRAY_AGG(r.user_id ORDER BY r.assigned_at) FILTER (WHERE r.user_id IS NOT NULL), '{}')
+        FROM pending_assignments q
+        JOIN pull_requests p ON p.pull_request_id = q.pull_request_id
+        JOIN users u ON u.user_id = p.author_id
+        LEFT JOIN pr_reviewers r ON r.pull_request_id = q.pull_request_id
+        WHERE $1 = '' OR u.team_name = $1
+        GROUP BY q.pull_request_id, p.pull_request_name, p.author_id, u.team_name
+        ORDER BY q.enqueued_at, q.pull_request_id
+    `
+
+	rows, err := r.db.Query(ctx, query, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	pending := []*models.PendingAssignment{}
+
+	for rows.Next() {
+		item := models.PendingAssignment{}
+
+		err := rows.Scan(&item.PullRequestID, &item.PullRequestName, &item.AuthorID, &item.TeamName, &item.Reason,
+			&item.EnqueuedAt, &item.Attempts, &item.LastAttemptAt, &item.AssignedReviewers)
+
+		if err != nil {
+			return nil, err
+		}
+
+		pending = append(pending, &item)
+	}
+
+	return pending, rows.Err()
+}
+
+func (r *prRepository) RecordPendingAttempt(ctx context.Context, prID string, at time.Time) error {
+
+	query := `
+        UPDATE pending_assignments SET attempts = attempts + 1, last_attempt_at = $2
+        WHERE pull_request_id = $1
+    `
+
+	_, err := r.db.Exec(ctx, query, prID, at.UTC())
+
+	return err
+}
+
+// keeps the pending assignment queue in line with the PR flags, in the transaction that writes the PR
+func syncPending(ctx context.Context, tx pgx.Tx, pr *models.PullRequest) error {
+
+	reason, pending := pr.PendingReason()
+
+	if !pending {
+		_, err := tx.Exec(ctx, `DELETE FROM pending_assignments WHERE pull_request_id = $1`, pr.PullRequestID)
+		return err
+	}
+
+	// a PR that stays in the queue keeps its enqueued_at and attempts
+	query := `
+        INSERT INTO pending_assignments (pull_request_id, reason, enqueued_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (pull_request_id) DO UPDATE SET reason = EXCLUDED.reason
+    `
+
+	_, err := tx.Exec(ctx, query, pr.PullRequestID, reason)
+
+	return err
+}
+
+// writes the events and audit entries recorded on the PR and commits the change with them
+func commitRecorded(ctx context.Context, tx pgx.Tx, pr *models.PullRequest) error {
+
+	if err := appendOutbox(ctx, tx, pr.Events()); err != nil {
+		return err
+	}
+
+	if err := appendAudit(ctx, tx, pr.AuditEntries()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	pr.ClearRecorded()
+
+	return nil
+}
+
+// pgx sends nil slices as NULL, array columns and ANY() need an empty array
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+
+PostgreSQL implementation for review SLA repository.
+A breach is unique per assignment of a reviewer, so a breach claimed by one check
+is never escalated again while a reviewer assigned anew gets a fresh SLA.
+Awaited reviews are narrowed by the calendar SLA in SQL, the business hours
+deadline of a reviewer is never earlier and is left to the service.
+
+*/
+
+type slaRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSLARepository(db *pgxpool.Pool) repository.SLARepository {
+	return &slaRepository{db: db}
+}
+
+func (r *slaRepository) ListAwaitedReviews(ctx context.Context, at time.Time) ([]*models.AwaitedReview, error) {
+
+	query := `
+		SELECT r.pull_request_id, p.author_id, u.team_name, r.user_id, r.assigned_at
+		FROM pr_reviewers r
+		JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		JOIN users u ON u.user_id = p.author_id
+		JOIN team_settings s ON s.team_name = u.team_name
+		WHERE p.status = 'OPEN' AND r.review_state = 'PENDING' AND s.review_sla_hours > 0
+			AND r.assigned_at <= $1 - make_interval(hours => s.review_sla_hours)
+			AND NOT EXISTS (
+				SELECT 1 FROM review_sla_breaches b
+				WHERE b.pull_request_id = r.pull_request_id AND b.user_id = r.user_id AND b.assigned_at = r.assigned_at
+			)
+		ORDER BY r.assigned_at, r.pull_request_id, r.user_id
+	`
+
+	rows, err := r.db.Query(ctx, query, at)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	reviews := []*models.AwaitedReview{}
+
+	for rows.Next() {
+		review := models.AwaitedReview{}
+
+		err := rows.Scan(&review.PullRequestID, &review.AuthorID, &review.TeamName, &review.UserID, &review.AssignedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, &review)
+	}
+
+	return reviews, rows.Err()
+}
+
+func (r *slaRepository) CreateBreach(ctx context.Context, breach *models.SLABreach) (bool, error) {
+
+	query := `
+		INSERT INTO review_sla_breaches
+			(pull_request_id, user_id, team_name, assigned_at, deadline, detected_at, escalation)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (pull_request_id, user_id, assigned_at) DO NOTHING
+		RETURNING breach_id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		breach.PullRequestID, breach.UserID, breach.TeamName, breach.AssignedAt,
+		breach.Deadline, breach.DetectedAt, breach.Escalation,
+	).Scan(&breach.BreachID)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *slaRepository) ResolveBreach(ctx context.Context, breachID int64, escalatedTo, escalationError string) error {
+
+	query := `
+		UPDATE review_sla_breaches SET escalated_to = NULLIF($2, ''), escalation_error = NULLIF($3, '')
+		WHERE breach_id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query, breachID, escalatedTo, escalationError)
+
+	return err
+}
+
+func (r *slaRepository) ListBreaches(ctx context.Context, filter models.SLABreachFilter) ([]*models.SLABreach, error) {
+
+	query := `
+		SELECT breach_id, pull_request_id, user_id, team_name, assigned_at, deadline, detected_at,
+			escalation, COALESCE(escalated_to, ''), COALESCE(escalation_error, '')
+		FROM review_sla_breaches
+		WHERE ($1 = '' OR team_name = $1) AND ($2 = '' OR user_id = $2) AND detected_at >= $3
+			AND ($4 = '' OR pull_request_id = $4)
+		ORDER BY detected_at DESC, breach_id DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, filter.TeamName, filter.UserID, filter.Since, filter.PullRequestID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	breaches := []*models.SLABreach{}
+
+	for rows.Next() {
+		breach := models.SLABreach{}
+
+		err := rows.Scan(
+			&breach.BreachID, &breach.PullRequestID, &breach.UserID, &breach.TeamName, &breach.AssignedAt,
+			&breach.Deadline, &breach.DetectedAt, &breach.Escalation, &breach.EscalatedTo, &breach.EscalationError,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		breaches = append(breaches, &breach)
+	}
+
+	return breaches, rows.Err()
+}
@@ -15,7 +15,8 @@ import (
 /*
 
 PostgreSQL implementation for team repository.
-Handles team creation, retrieval and existence checks with member data.
+Handles team creation, retrieval and existence checks with member data
+and the team's reviewer selection strategy.
 
 */
 
@@ -42,17 +43,23 @@ func (r *teamRepository) Create(ctx context.Context, team *models.Team) error {
 	}()
 
 	query := `
-	    INSERT INTO teams (team_name, created_at)
-        VALUES ($1, $2)
+	    INSERT INTO teams (team_name, reviewer_strategy, created_at)
+        VALUES ($1, $2, $3)
 	`
 
-	_, err = tx.Exec(ctx, query, team.TeamName, team.CreatedAt)
+	_, err = tx.Exec(ctx, query, team.TeamName, team.ReviewerStrategy, team.CreatedAt)
 
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	if err := commitAudited(ctx, tx, team.AuditEntries()); err != nil {
+		return err
+	}
+
+	team.ClearAudit()
+
+	return nil
 }
 
 func (r *teamRepository) GetByName(ctx context.Context, teamName string) (*models.Team, error) {
@@ -60,10 +67,10 @@ func (r *teamRepository) GetByName(ctx context.Context, teamName string) (*model
 	team := models.Team{}
 
 	queryGetTeam := `
-		SELECT team_name, created_at FROM teams WHERE team_name = $1
+		SELECT team_name, reviewer_strategy, created_at FROM teams WHERE team_name = $1
 	`
 
-	err := r.db.QueryRow(ctx, queryGetTeam, teamName).Scan(&team.TeamName, &team.CreatedAt)
+	err := r.db.QueryRow(ctx, queryGetTeam, teamName).Scan(&team.TeamName, &team.ReviewerStrategy, &team.CreatedAt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -115,3 +122,22 @@ func (r *teamRepository) Exists(ctx context.Context, teamName string) (bool, err
 
 	return exists, err
 }
+
+func (r *teamRepository) UpdateStrategy(ctx context.Context, teamName string, strategy models.ReviewerStrategy) error {
+
+	query := `
+		UPDATE teams SET reviewer_strategy = $2 WHERE team_name = $1
+	`
+
+	result, err := r.db.Exec(ctx, query, teamName, strategy)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrTeamNotFound
+	}
+
+	return nil
+}
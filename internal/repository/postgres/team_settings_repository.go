@@ -0,0 +1,348 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+PostgreSQL implementation of team settings storage.
+Settings live in team_settings, the selection strategy stays in teams,
+the ordered fallback pools in team_fallbacks, never pair rules in team_never_pairs
+and label rules in team_label_rules.
+Teams without a settings row get the defaults.
+
+*/
+
+func (r *teamRepository) GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+
+	settings := models.DefaultTeamSettings(teamName)
+
+	var reviewerCount, workingHoursWindow, authorSpreadDays, authorSpreadPenalty, fairnessWindowDays, fairnessWeight, weightLinesStep, weightFilesStep, maxOpenReviews, approvalQuorum, reviewSLAHours *int
+	var slaBusinessHours *bool
+	var policy *models.UnderstaffedPolicy
+	var workingHoursPolicy *models.WorkingHoursPolicy
+	var loadWeighting *models.LoadWeighting
+	var capPolicy *models.CapPolicy
+	var mergeApprovals *models.MergeApprovals
+	var slaEscalation *models.SLAEscalation
+
+	query := `
+		SELECT t.reviewer_strategy, s.reviewer_count, s.understaffed_policy,
+			s.working_hours_policy, s.working_hours_window, s.author_spread_days, s.author_spread_penalty,
+			s.fairness_window_days, s.fairness_weight, s.load_weighting, s.weight_lines_step, s.weight_files_step,
+			s.max_open_reviews, s.cap_policy, s.merge_approvals, s.approval_quorum,
+			s.review_sla_hours, s.sla_business_hours, s.sla_escalation, COALESCE(s.updated_at, t.created_at)
+		FROM teams t
+		LEFT JOIN team_settings s ON s.team_name = t.team_name
+		WHERE t.team_name = $1
+	`
+
+	err := r.db.QueryRow(ctx, query, teamName).Scan(
+		&settings.ReviewerStrategy, &reviewerCount, &policy,
+		&workingHoursPolicy, &workingHoursWindow, &authorSpreadDays, &authorSpreadPenalty,
+		&fairnessWindowDays, &fairnessWeight, &loadWeighting, &weightLinesStep, &weightFilesStep,
+		&maxOpenReviews, &capPolicy, &mergeApprovals, &approvalQuorum,
+		&reviewSLAHours, &slaBusinessHours, &slaEscalation, &settings.UpdatedAt,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrTeamNotFound
+		}
+		return nil, err
+	}
+
+	if reviewerCount != nil {
+		settings.ReviewerCount = *reviewerCount
+	}
+
+	if policy != nil {
+		settings.UnderstaffedPolicy = *policy
+	}
+
+	if workingHoursPolicy != nil {
+		settings.WorkingHoursPolicy = *workingHoursPolicy
+	}
+
+	if workingHoursWindow != nil {
+		settings.WorkingHoursWindow = *workingHoursWindow
+	}
+
+	if authorSpreadDays != nil {
+		settings.AuthorSpreadDays = *authorSpreadDays
+	}
+
+	if authorSpreadPenalty != nil {
+		settings.AuthorSpreadPenalty = *authorSpreadPenalty
+	}
+
+	if fairnessWindowDays != nil {
+		settings.FairnessWindowDays = *fairnessWindowDays
+	}
+
+	if fairnessWeight != nil {
+		settings.FairnessWeight = *fairnessWeight
+	}
+
+	if loadWeighting != nil {
+		settings.LoadWeighting = *loadWeighting
+	}
+
+	if weightLinesStep != nil {
+		settings.WeightLinesStep = *weightLinesStep
+	}
+
+	if weightFilesStep != nil {
+		settings.WeightFilesStep = *weightFilesStep
+	}
+
+	if maxOpenReviews != nil {
+		settings.MaxOpenReviews = *maxOpenReviews
+	}
+
+	if capPolicy != nil {
+		settings.CapPolicy = *capPolicy
+	}
+
+	if mergeApprovals != nil {
+		settings.MergeApprovals = *mergeApprovals
+	}
+
+	if approvalQuorum != nil {
+		settings.ApprovalQuorum = *approvalQuorum
+	}
+
+	if reviewSLAHours != nil {
+		settings.ReviewSLAHours = *reviewSLAHours
+	}
+
+	if slaBusinessHours != nil {
+		settings.SLABusinessHours = *slaBusinessHours
+	}
+
+	if slaEscalation != nil {
+		settings.SLAEscalation = *slaEscalation
+	}
+
+	queryGetFallbacks := `
+		SELECT fallback_team FROM team_fallbacks WHERE team_name = $1 ORDER BY position
+	`
+
+	rows, err := r.db.Query(ctx, queryGetFallbacks, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var fallbackTeam string
+
+		if err := rows.Scan(&fallbackTeam); err != nil {
+			return nil, err
+		}
+
+		settings.FallbackTeams = append(settings.FallbackTeams, fallbackTeam)
+	}
+
+	queryGetNeverPairs := `
+		SELECT user_a, user_b FROM team_never_pairs WHERE team_name = $1 ORDER BY user_a, user_b
+	`
+
+	pairRows, err := r.db.Query(ctx, queryGetNeverPairs, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer pairRows.Close()
+
+	for pairRows.Next() {
+		var pair models.ReviewerPair
+
+		if err := pairRows.Scan(&pair.UserA, &pair.UserB); err != nil {
+			return nil, err
+		}
+
+		settings.NeverPair = append(settings.NeverPair, pair)
+	}
+
+	queryGetLabelRules := `
+		SELECT label, reviewer_team FROM team_label_rules WHERE team_name = $1 ORDER BY position
+	`
+
+	ruleRows, err := r.db.Query(ctx, queryGetLabelRules, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer ruleRows.Close()
+
+	for ruleRows.Next() {
+		var rule models.LabelRule
+
+		if err := ruleRows.Scan(&rule.Label, &rule.Team); err != nil {
+			return nil, err
+		}
+
+		settings.LabelRules = append(settings.LabelRules, rule)
+	}
+
+	return settings, nil
+}
+
+func (r *teamRepository) SaveSettings(ctx context.Context, settings *models.TeamSettings) error {
+
+	tx, err := r.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback transaction")
+		}
+	}()
+
+	queryUpdateStrategy := `
+		UPDATE teams SET reviewer_strategy = $2 WHERE team_name = $1
+	`
+
+	result, err := tx.Exec(ctx, queryUpdateStrategy, settings.TeamName, settings.ReviewerStrategy)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrTeamNotFound
+	}
+
+	queryUpsert := `
+		INSERT INTO team_settings (team_name, reviewer_count, understaffed_policy,
+			working_hours_policy, working_hours_window, author_spread_days, author_spread_penalty,
+			fairness_window_days, fairness_weight, load_weighting, weight_lines_step, weight_files_step,
+			max_open_reviews, cap_policy, merge_approvals, approval_quorum,
+			review_sla_hours, sla_business_hours, sla_escalation, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		ON CONFLICT (team_name) DO UPDATE SET
+			reviewer_count = EXCLUDED.reviewer_count,
+			understaffed_policy = EXCLUDED.understaffed_policy,
+			working_hours_policy = EXCLUDED.working_hours_policy,
+			working_hours_window = EXCLUDED.working_hours_window,
+			author_spread_days = EXCLUDED.author_spread_days,
+			author_spread_penalty = EXCLUDED.author_spread_penalty,
+			fairness_window_days = EXCLUDED.fairness_window_days,
+			fairness_weight = EXCLUDED.fairness_weight,
+			load_weighting = EXCLUDED.load_weighting,
+			weight_lines_step = EXCLUDED.weight_lines_step,
+			weight_files_step = EXCLUDED.weight_files_step,
+			max_open_reviews = EXCLUDED.max_open_reviews,
+			cap_policy = EXCLUDED.cap_policy,
+			merge_approvals = EXCLUDED.merge_approvals,
+			approval_quorum = EXCLUDED.approval_quorum,
+			review_sla_hours = EXCLUDED.review_sla_hours,
+			sla_business_hours = EXCLUDED.sla_business_hours,
+			sla_escalation = EXCLUDED.sla_escalation,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = tx.Exec(ctx, queryUpsert, settings.TeamName, settings.ReviewerCount, settings.UnderstaffedPolicy,
+		settings.WorkingHoursPolicy, settings.WorkingHoursWindow, settings.AuthorSpreadDays, settings.AuthorSpreadPenalty,
+		settings.FairnessWindowDays, settings.FairnessWeight, settings.LoadWeighting,
+		settings.WeightLinesStep, settings.WeightFilesStep, settings.MaxOpenReviews, settings.CapPolicy,
+		settings.MergeApprovals, settings.ApprovalQuorum,
+		settings.ReviewSLAHours, settings.SLABusinessHours, settings.SLAEscalation, settings.UpdatedAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	queryDeleteFallbacks := `
+		DELETE FROM team_fallbacks WHERE team_name = $1
+	`
+
+	_, err = tx.Exec(ctx, queryDeleteFallbacks, settings.TeamName)
+
+	if err != nil {
+		return err
+	}
+
+	queryInsertFallback := `
+		INSERT INTO team_fallbacks (team_name, fallback_team, position)
+		VALUES ($1, $2, $3)
+	`
+
+	for i, fallbackTeam := range settings.FallbackTeams {
+
+		_, err = tx.Exec(ctx, queryInsertFallback, settings.TeamName, fallbackTeam, i+1)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	queryDeleteNeverPairs := `
+		DELETE FROM team_never_pairs WHERE team_name = $1
+	`
+
+	_, err = tx.Exec(ctx, queryDeleteNeverPairs, settings.TeamName)
+
+	if err != nil {
+		return err
+	}
+
+	queryInsertNeverPair := `
+		INSERT INTO team_never_pairs (team_name, user_a, user_b)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, pair := range settings.NeverPair {
+
+		pair = pair.Normalized()
+
+		_, err = tx.Exec(ctx, queryInsertNeverPair, settings.TeamName, pair.UserA, pair.UserB)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	queryDeleteLabelRules := `
+		DELETE FROM team_label_rules WHERE team_name = $1
+	`
+
+	_, err = tx.Exec(ctx, queryDeleteLabelRules, settings.TeamName)
+
+	if err != nil {
+		return err
+	}
+
+	queryInsertLabelRule := `
+		INSERT INTO team_label_rules (team_name, label, reviewer_team, position)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	for i, rule := range settings.LabelRules {
+
+		_, err = tx.Exec(ctx, queryInsertLabelRule, settings.TeamName, rule.Label, rule.Team, i+1)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
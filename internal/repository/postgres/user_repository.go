@@ -3,18 +3,26 @@ package postgres
 import (
 	"context"
 	"errors"
+	"time"
 
 	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
 )
 
 /*
 
 PostgreSQL implementation for user repository.
 Handles user CRUD operations, team member queries and reviewer workload tracking.
+Users are loaded together with their expertise tags (see user_tags_repository.go).
+Update writes back deactivated_by_absence as loaded, so only SetActive clears the marker
+an absence left (see absence_repository.go); a team upsert clears it when it flips is_active.
+Team candidates skip users with a running absence even before the availability
+worker has switched their is_active flag off.
+Audit entries recorded on a user are written in the transaction saving it.
 
 */
 
@@ -28,6 +36,18 @@ func NewUserRepository(db *pgxpool.Pool) repository.UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 
+	tx, err := r.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to rollback transaction")
+		}
+	}()
+
 	query := `
         INSERT INTO users (user_id, username, team_name, is_active, created_at, updated_at)
         VALUES ($1, $2, $3, $4, $5, $6)
@@ -35,27 +55,56 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
             username = EXCLUDED.username,
             team_name = EXCLUDED.team_name,
             is_active = EXCLUDED.is_active,
-            updated_at = EXCLUDED.updated_at
+            updated_at = EXCLUDED.updated_at,
+            deactivated_by_absence = users.deactivated_by_absence AND users.is_active = EXCLUDED.is_active
     `
 
-	_, err := r.db.Exec(ctx, query, user.UserID, user.Username, user.TeamName, user.IsActive, user.CreatedAt, user.UpdatedAt)
-	return err
+	_, err = tx.Exec(ctx, query, user.UserID, user.Username, user.TeamName, user.IsActive, user.CreatedAt, user.UpdatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	if err := commitAudited(ctx, tx, user.AuditEntries()); err != nil {
+		return err
+	}
+
+	user.ClearAudit()
+
+	return nil
 }
 
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 
+	tx, err := r.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to rollback transaction")
+		}
+	}()
+
 	query := `
         UPDATE users SET
             username = $2,
             team_name = $3,
             is_active = $4,
-            updated_at = $5
+            updated_at = $5,
+            timezone = $6,
+            working_hours = $7,
+            max_open_reviews = $8,
+            deactivated_by_absence = $9
         WHERE user_id = $1
     `
 
-	result, err := r.db.Exec(ctx, query,
+	result, err := tx.Exec(ctx, query,
 		user.UserID, user.Username, user.TeamName,
-		user.IsActive, user.UpdatedAt,
+		user.IsActive, user.UpdatedAt, user.Timezone, nonNilWorkingHours(user.WorkingHours), user.MaxOpenReviews,
+		user.DeactivatedByAbsence,
 	)
 
 	if err != nil {
@@ -66,6 +115,12 @@ func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 		return apperrors.ErrUserNotFound
 	}
 
+	if err := commitAudited(ctx, tx, user.AuditEntries()); err != nil {
+		return err
+	}
+
+	user.ClearAudit()
+
 	return nil
 }
 
@@ -74,13 +129,15 @@ func (r *userRepository) GetByID(ctx context.Context, userID string) (*models.Us
 	user := models.User{}
 
 	query := `
-        SELECT user_id, username, team_name, is_active, created_at, updated_at
+        SELECT user_id, username, team_name, is_active, created_at, updated_at, timezone, working_hours, max_open_reviews,
+            ARRAY(SELECT tag FROM user_tags t WHERE t.user_id = users.user_id ORDER BY tag), deactivated_by_absence
         FROM users WHERE user_id = $1
     `
 
 	err := r.db.QueryRow(ctx, query, userID).Scan(
 		&user.UserID, &user.Username, &user.TeamName,
 		&user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.Timezone, &user.WorkingHours, &user.MaxOpenReviews, &user.Tags, &user.DeactivatedByAbsence,
 	)
 
 	if err != nil {
@@ -93,16 +150,31 @@ func (r *userRepository) GetByID(ctx context.Context, userID string) (*models.Us
 	return &user, nil
 }
 
-func (r *userRepository) GetActiveByTeam(ctx context.Context, teamName string, excludeUserID string) ([]*models.User, error) {
+// memberExclusion is the filter of team candidates: the reason a member of the team is left out
+// ($2 is the excluded user, $3 the time absences are checked at), NULL for candidates.
+// GetActiveByTeam and GetTeamExclusions share it, so a trace names the filter that ran
+const memberExclusion = `
+            CASE
+                WHEN users.user_id = $2 THEN 'AUTHOR'
+                WHEN NOT users.is_active THEN 'INACTIVE'
+                WHEN EXISTS (
+                    SELECT 1 FROM absences a
+                    WHERE a.user_id = users.user_id AND a.cancelled_at IS NULL
+                    AND a.starts_at <= $3 AND a.ends_at > $3
+                ) THEN 'ABSENT'
+            END`
+
+func (r *userRepository) GetActiveByTeam(ctx context.Context, teamName string, excludeUserID string, at time.Time) ([]*models.User, error) {
 
 	query := `
-        SELECT user_id, username, team_name, is_active, created_at, updated_at
+        SELECT user_id, username, team_name, is_active, created_at, updated_at, timezone, working_hours, max_open_reviews,
+            ARRAY(SELECT tag FROM user_tags t WHERE t.user_id = users.user_id ORDER BY tag)
         FROM users
-        WHERE team_name = $1 AND is_active = true AND user_id != $2
+        WHERE team_name = $1 AND (` + memberExclusion + `) IS NULL
         ORDER BY username
     `
 
-	rows, err := r.db.Query(ctx, query, teamName, excludeUserID)
+	rows, err := r.db.Query(ctx, query, teamName, excludeUserID, at)
 
 	if err != nil {
 		return nil, err
@@ -118,6 +190,7 @@ func (r *userRepository) GetActiveByTeam(ctx context.Context, teamName string, e
 		err := rows.Scan(
 			&user.UserID, &user.Username, &user.TeamName,
 			&user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+			&user.Timezone, &user.WorkingHours, &user.MaxOpenReviews, &user.Tags,
 		)
 
 		if err != nil {
@@ -130,6 +203,45 @@ func (r *userRepository) GetActiveByTeam(ctx context.Context, teamName string, e
 	return users, nil
 }
 
+// GetTeamExclusions returns the members of the team GetActiveByTeam leaves out with the same arguments
+// and the filter that left each of them out
+func (r *userRepository) GetTeamExclusions(ctx context.Context, teamName string, excludeUserID string, at time.Time) ([]models.TraceExclusion, error) {
+
+	query := `
+        SELECT user_id, reason
+        FROM (
+            SELECT user_id, username, ` + memberExclusion + ` AS reason
+            FROM users
+            WHERE team_name = $1
+        ) members
+        WHERE reason IS NOT NULL
+        ORDER BY username
+    `
+
+	rows, err := r.db.Query(ctx, query, teamName, excludeUserID, at)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	exclusions := []models.TraceExclusion{}
+
+	for rows.Next() {
+		var exclusion models.TraceExclusion
+
+		if err := rows.Scan(&exclusion.UserID, &exclusion.Reason); err != nil {
+			return nil, err
+		}
+
+		exclusions = append(exclusions, exclusion)
+	}
+
+	return exclusions, rows.Err()
+}
+
+// GetReviewerLoad sums review weights of the OPEN PRs each user reviews
 func (r *userRepository) GetReviewerLoad(ctx context.Context, userIDs []string) (map[string]int, error) {
 
 	if len(userIDs) == 0 {
@@ -137,7 +249,7 @@ func (r *userRepository) GetReviewerLoad(ctx context.Context, userIDs []string)
 	}
 
 	query := `
-        SELECT r.user_id, COUNT(*) FROM pr_reviewers r
+        SELECT r.user_id, SUM(p.review_weight) FROM pr_reviewers r
         JOIN pull_requests p ON r.pull_request_id = p.pull_request_id
         WHERE r.user_id = ANY($1) AND p.status = 'OPEN'
         GROUP BY r.user_id
@@ -174,3 +286,187 @@ func (r *userRepository) GetReviewerLoad(ctx context.Context, userIDs []string)
 
 	return load, nil
 }
+
+// GetReviewerLoads reads both the count and the weight of open reviews of all the users in one query
+func (r *userRepository) GetReviewerLoads(ctx context.Context, userIDs []string) (map[string]models.ReviewerLoad, error) {
+
+	loads := make(map[string]models.ReviewerLoad, len(userIDs))
+
+	for _, userID := range userIDs {
+		loads[userID] = models.ReviewerLoad{}
+	}
+
+	if len(userIDs) == 0 {
+		return loads, nil
+	}
+
+	query := `
+        SELECT r.user_id, COUNT(*), SUM(p.review_weight) FROM pr_reviewers r
+        JOIN pull_requests p ON r.pull_request_id = p.pull_request_id
+        WHERE r.user_id = ANY($1) AND p.status = 'OPEN'
+        GROUP BY r.user_id
+    `
+
+	rows, err := r.db.Query(ctx, query, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var load models.ReviewerLoad
+
+		if err := rows.Scan(&userID, &load.Open, &load.Weighted); err != nil {
+			return nil, err
+		}
+
+		loads[userID] = load
+	}
+
+	return loads, nil
+}
+
+func (r *userRepository) GetReviewerHistory(ctx context.Context, userIDs []string) (map[string]models.ReviewerHistory, error) {
+
+	if len(userIDs) == 0 {
+		return map[string]models.ReviewerHistory{}, nil
+	}
+
+	query := `
+        SELECT user_id, COUNT(*), MAX(assigned_at) FROM pr_reviewers
+        WHERE user_id = ANY($1)
+        GROUP BY user_id
+    `
+
+	rows, err := r.db.Query(ctx, query, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := make(map[string]models.ReviewerHistory)
+
+	for rows.Next() {
+		var userID string
+		var total int
+		var lastAssignedAt time.Time
+
+		err := rows.Scan(&userID, &total, &lastAssignedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		history[userID] = models.ReviewerHistory{
+			TotalAssigned:  total,
+			LastAssignedAt: &lastAssignedAt,
+		}
+	}
+
+	for _, userID := range userIDs {
+		if _, exists := history[userID]; !exists {
+			history[userID] = models.ReviewerHistory{}
+		}
+	}
+
+	return history, nil
+}
+
+// GetAuthorReviewCounts counts PRs of the author each user was assigned to since the given time
+func (r *userRepository) GetAuthorReviewCounts(ctx context.Context, authorID string, userIDs []string, since time.Time) (map[string]int, error) {
+
+	counts := make(map[string]int, len(userIDs))
+
+	for _, userID := range userIDs {
+		counts[userID] = 0
+	}
+
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	// assigned_at has no timezone, it is written by NOW() in the session timezone
+	query := `
+        SELECT r.user_id, COUNT(*) FROM pr_reviewers r
+        JOIN pull_requests p ON r.pull_request_id = p.pull_request_id
+        WHERE r.user_id = ANY($1) AND p.author_id = $2 AND r.assigned_at >= $3::timestamptz::timestamp
+        GROUP BY r.user_id
+    `
+
+	rows, err := r.db.Query(ctx, query, userIDs, authorID, since)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var count int
+
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+
+		counts[userID] = count
+	}
+
+	return counts, nil
+}
+
+// GetAssignmentCounts counts PRs each user was assigned to since the given time
+func (r *userRepository) GetAssignmentCounts(ctx context.Context, userIDs []string, since time.Time) (map[string]int, error) {
+
+	counts := make(map[string]int, len(userIDs))
+
+	for _, userID := range userIDs {
+		counts[userID] = 0
+	}
+
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	// assigned_at has no timezone, it is written by NOW() in the session timezone
+	query := `
+        SELECT user_id, COUNT(*) FROM pr_reviewers
+        WHERE user_id = ANY($1) AND assigned_at >= $2::timestamptz::timestamp
+        GROUP BY user_id
+    `
+
+	rows, err := r.db.Query(ctx, query, userIDs, since)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID string
+		var count int
+
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+
+		counts[userID] = count
+	}
+
+	return counts, nil
+}
+
+func nonNilWorkingHours(hours models.WorkingHours) models.WorkingHours {
+
+	if hours == nil {
+		return models.WorkingHours{}
+	}
+
+	return hours
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+PostgreSQL implementation of user expertise tags storage.
+Tags are expected to be normalized by the service layer, user existence is checked there too.
+
+*/
+
+func (r *userRepository) GetTags(ctx context.Context, userID string) ([]string, error) {
+
+	query := `
+		SELECT tag FROM user_tags
+		WHERE user_id = $1
+		ORDER BY tag
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := []string{}
+
+	for rows.Next() {
+		var tag string
+
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+func (r *userRepository) AddTags(ctx context.Context, userID string, tags []string) error {
+
+	query := `
+		INSERT INTO user_tags (user_id, tag)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, nonNil(tags))
+	return err
+}
+
+func (r *userRepository) RemoveTags(ctx context.Context, userID string, tags []string) error {
+
+	query := `
+		DELETE FROM user_tags WHERE user_id = $1 AND tag = ANY($2)
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, nonNil(tags))
+	return err
+}
+
+func (r *userRepository) ReplaceTags(ctx context.Context, userID string, tags []string) error {
+
+	tx, err := r.db.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("failed to rollback transaction")
+		}
+	}()
+
+	queryDelete := `
+		DELETE FROM user_tags WHERE user_id = $1
+	`
+
+	_, err = tx.Exec(ctx, queryDelete, userID)
+
+	if err != nil {
+		return err
+	}
+
+	queryInsert := `
+		INSERT INTO user_tags (user_id, tag)
+		SELECT $1, unnest($2::text[])
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err = tx.Exec(ctx, queryInsert, userID, nonNil(tags))
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,292 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+/*
+
+PostgreSQL implementation for outbound webhook repository.
+Deliveries are claimed with FOR UPDATE SKIP LOCKED and a lease on next_attempt_at,
+so several workers never send the same delivery at once and a delivery of a worker
+that died is picked up again once the lease runs out.
+
+*/
+
+type webhookRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookRepository(db *pgxpool.Pool) repository.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const subscriptionColumns = `subscription_id, url, secret, events, created_at`
+
+const deliveryColumns = `delivery_id, subscription_id, event_type, payload, status, attempts,
+	next_attempt_at, last_attempt_at, COALESCE(response_status, 0), COALESCE(last_error, ''),
+	replay_of, created_at, delivered_at`
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, events, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING subscription_id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		subscription.URL, subscription.Secret, eventNames(subscription.Events), subscription.CreatedAt,
+	).Scan(&subscription.SubscriptionID)
+
+	return err
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, subscriptionID int64) (*models.WebhookSubscription, error) {
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE subscription_id = $1`
+
+	subscription, err := scanSubscription(r.db.QueryRow(ctx, query, subscriptionID))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, subscriptionID int64) error {
+
+	query := `DELETE FROM webhook_subscriptions WHERE subscription_id = $1`
+
+	result, err := r.db.Exec(ctx, query, subscriptionID)
+
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return apperrors.ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions ORDER BY subscription_id`
+
+	return r.listSubscriptions(ctx, query)
+}
+
+func (r *webhookRepository) ListSubscribers(ctx context.Context, eventType models.EventType) ([]*models.WebhookSubscription, error) {
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE $1 = ANY(events)
+		ORDER BY subscription_id
+	`
+
+	return r.listSubscriptions(ctx, query, string(eventType))
+}
+
+func (r *webhookRepository) listSubscriptions(ctx context.Context, query string, args ...any) ([]*models.WebhookSubscription, error) {
+
+	rows, err := r.db.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	subscriptions := []*models.WebhookSubscription{}
+
+	for rows.Next() {
+
+		subscription, err := scanSubscription(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+
+	query := `
+		INSERT INTO webhook_deliveries
+			(subscription_id, event_type, payload, status, attempts, next_attempt_at, replay_of, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING delivery_id
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		delivery.SubscriptionID, delivery.EventType, string(delivery.Payload), delivery.Status,
+		delivery.Attempts, delivery.NextAttemptAt, delivery.ReplayOf, delivery.CreatedAt,
+	).Scan(&delivery.DeliveryID)
+
+	return err
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, deliveryID int64) (*models.WebhookDelivery, error) {
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE delivery_id = $1`
+
+	delivery, err := scanDelivery(r.db.QueryRow(ctx, query, deliveryID))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperrors.ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE ($1 = 0 OR subscription_id = $1) AND ($2 = '' OR event_type = $2) AND ($3 = '' OR status = $3)
+		ORDER BY delivery_id DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, filter.SubscriptionID, string(filter.EventType), string(filter.Status), filter.Limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return collectDeliveries(rows)
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+
+	query := `
+		UPDATE webhook_deliveries SET next_attempt_at = $2
+		WHERE delivery_id IN (
+			SELECT delivery_id FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, delivery_id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns
+
+	rows, err := r.db.Query(ctx, query, at, at.Add(lease), limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return collectDeliveries(rows)
+}
+
+func (r *webhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+			response_status = NULLIF($6, 0), last_error = NULLIF($7, ''), delivered_at = $8
+		WHERE delivery_id = $1
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		delivery.DeliveryID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastAttemptAt,
+		delivery.ResponseStatus, delivery.LastError, delivery.DeliveredAt,
+	)
+
+	return err
+}
+
+func scanSubscription(row pgx.Row) (*models.WebhookSubscription, error) {
+
+	var subscription models.WebhookSubscription
+	var events []string
+
+	err := row.Scan(&subscription.SubscriptionID, &subscription.URL, &subscription.Secret, &events, &subscription.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Events = make([]models.EventType, len(events))
+
+	for i, event := range events {
+		subscription.Events[i] = models.EventType(event)
+	}
+
+	return &subscription, nil
+}
+
+func scanDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+
+	var delivery models.WebhookDelivery
+	var payload []byte
+
+	err := row.Scan(&delivery.DeliveryID, &delivery.SubscriptionID, &delivery.EventType, &payload,
+		&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastAttemptAt,
+		&delivery.ResponseStatus, &delivery.LastError, &delivery.ReplayOf, &delivery.CreatedAt, &delivery.DeliveredAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = payload
+
+	return &delivery, nil
+}
+
+func collectDeliveries(rows pgx.Rows) ([]*models.WebhookDelivery, error) {
+
+	deliveries := []*models.WebhookDelivery{}
+
+	for rows.Next() {
+
+		delivery, err := scanDelivery(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// event types as plain strings, pgx encodes them as a text array
+func eventNames(events []models.EventType) []string {
+
+	names := make([]string, len(events))
+
+	for i, event := range events {
+		names[i] = string(event)
+	}
+
+	return names
+}
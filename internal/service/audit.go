@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Audit log of changes.
+Team creation, user activation and deactivation (by hand, by a member upsert or by an absence),
+PR creation, merge and every change of a PR's reviewers (reassigned, added or removed)
+are recorded with the actor, the entity before and after the change and the time.
+Entries are recorded on the PR, team or user and saved in the transaction of the change, so the
+reviewers a PR had before a reassignment are kept even though pr_reviewers is rewritten;
+users switched by absences are written right after the availability sync.
+
+The actor comes from the context: claimed:<X-Actor> for API requests (anonymous without one),
+webhook:<code host> for code host deliveries and system for background workers.
+The API does not authenticate its callers, so X-Actor is only what the caller claims to be
+and is labelled as such.
+
+*/
+
+const (
+	// SystemActor makes the changes of background workers
+	SystemActor = "system"
+	// AnonymousActor makes the changes of API requests without X-Actor
+	AnonymousActor = "anonymous"
+	// ClaimedActorPrefix labels actors the caller names itself
+	ClaimedActorPrefix = "claimed:"
+
+	// MaxActorLength is the most characters of an actor kept, longer ones are cut
+	MaxActorLength = 255
+
+	defaultAuditListLimit = 50
+	maxAuditListLimit     = 500
+)
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are made by the actor
+func WithActor(ctx context.Context, actor string) context.Context {
+
+	actor = strings.TrimSpace(actor)
+
+	// Cut on a character boundary, a multibyte character must not be split
+	if runes := []rune(actor); len(runes) > MaxActorLength {
+		actor = string(runes[:MaxActorLength])
+	}
+
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithClaimedActor returns a context whose changes are made by an actor the caller
+// names itself, nothing checks the claim so the actor is labelled as claimed
+func WithClaimedActor(ctx context.Context, claimed string) context.Context {
+
+	claimed = strings.TrimSpace(claimed)
+
+	if claimed == "" {
+		return WithActor(ctx, AnonymousActor)
+	}
+
+	return WithActor(ctx, ClaimedActorPrefix+claimed)
+}
+
+// ActorFrom tells who makes the changes of the context, SystemActor when nobody is set
+func ActorFrom(ctx context.Context) string {
+
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+
+	return SystemActor
+}
+
+// AuditRecorder saves audit entries of changes whose repository does not save them itself
+type AuditRecorder interface {
+	Record(ctx context.Context, entry models.AuditEntry) error
+}
+
+type AuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry) error {
+	return s.auditRepo.Append(ctx, entry)
+}
+
+// List returns a page of the audit log latest first, 50 entries unless the filter has a limit
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter) (*models.AuditPage, error) {
+
+	if filter.Action != "" && !filter.Action.IsValid() {
+		return nil, fmt.Errorf("%w: unknown action %q", apperrors.ErrInvalidAuditFilter, filter.Action)
+	}
+
+	if filter.EntityType != "" && !filter.EntityType.IsValid() {
+		return nil, fmt.Errorf("%w: entity_type must be team, user or pull_request", apperrors.ErrInvalidAuditFilter)
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", apperrors.ErrInvalidAuditFilter)
+	}
+
+	if filter.Cursor < 0 {
+		return nil, fmt.Errorf("%w: invalid cursor", apperrors.ErrInvalidAuditFilter)
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxAuditListLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", apperrors.ErrInvalidAuditFilter, maxAuditListLimit)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditListLimit
+	}
+
+	// One entry more tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+
+	entries, err := s.auditRepo.List(ctx, filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.AuditPage{Entries: entries}
+
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		page.NextCursor = &page.Entries[limit-1].AuditID
+	}
+
+	return page, nil
+}
+
+// builds an audit entry of a change made by the actor of the context
+func newAuditEntry(ctx context.Context, action models.AuditAction, entityType models.AuditEntity, entityID string, before, after any, at time.Time) models.AuditEntry {
+	return models.AuditEntry{
+		Actor:      ActorFrom(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     before,
+		After:      after,
+		CreatedAt:  at.UTC(),
+	}
+}
+
+// saves an audit entry of a saved change, services may run without a recorder
+func recordAudit(ctx context.Context, recorder AuditRecorder, entry models.AuditEntry) {
+
+	if recorder == nil {
+		return
+	}
+
+	if err := recorder.Record(ctx, entry); err != nil {
+		log.Error().Err(err).Str("action", string(entry.Action)).Str("entity_id", entry.EntityID).
+			Msg("Failed to record audit entry")
+	}
+}
+
+// records an audit entry of a change of the PR, it is saved together with the change.
+// before is a Snapshot of the PR taken before the change, nil for a new PR
+func (s *PRService) recordPRAudit(ctx context.Context, pr *models.PullRequest, action models.AuditAction, before *models.PullRequest) {
+
+	var state any
+
+	if before != nil {
+		state = before
+	}
+
+	pr.RecordAudit(newAuditEntry(ctx, action, models.AuditEntityPullRequest, pr.PullRequestID, state, pr.Snapshot(), s.clock.Now()))
+}
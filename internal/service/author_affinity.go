@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+)
+
+/*
+
+Author-reviewer affinity rules of a team.
+
+- never pair      - hard constraint, the other user of a pair is never a candidate
+                    for the author's PRs, whatever step of the pipeline picks
+- author spread   - soft penalty, every PR of the same author a candidate was assigned
+                    in the last author_spread_days days adds author_spread_penalty to
+                    the candidate's load. It moves people down the queue of load based
+                    strategies, ROUND_ROBIN is a strict rotation and does not use load
+
+*/
+
+// neverPairSelector leaves out candidates a never pair rule forbids for the author
+type neverPairSelector struct {
+	inner    ReviewerSelector
+	settings *models.TeamSettings
+	authorID string
+}
+
+func newNeverPairSelector(inner ReviewerSelector, settings *models.TeamSettings, authorID string) ReviewerSelector {
+
+	if len(settings.NeverPair) == 0 {
+		return inner
+	}
+
+	return &neverPairSelector{inner: inner, settings: settings, authorID: authorID}
+}
+
+func (s *neverPairSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	allowed := slices.DeleteFunc(slices.Clone(candidates), func(u *models.User) bool {
+
+		if s.settings.NeverPaired(s.authorID, u.UserID) {
+			traceFrom(ctx).exclude(u.UserID, models.ExcludedNeverPair)
+			return true
+		}
+
+		return false
+	})
+
+	return s.inner.Select(ctx, allowed, count)
+}
+
+// adds the penalty for recent reviews of the author to the scores,
+// strategies ranking by them balance reviewers across authors
+func addAuthorSpread(ctx context.Context, userRepo repository.UserRepository, settings *models.TeamSettings,
+	authorID string, userIDs []string, now time.Time, scores map[string]int) error {
+
+	recent, err := userRepo.GetAuthorReviewCounts(ctx, authorID, userIDs, now.AddDate(0, 0, -settings.AuthorSpreadDays))
+
+	if err != nil {
+		return err
+	}
+
+	for userID := range scores {
+		scores[userID] += recent[userID] * settings.AuthorSpreadPenalty
+	}
+
+	return nil
+}
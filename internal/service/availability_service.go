@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+)
+
+/*
+
+Availability service for out-of-office schedules.
+An absence is a [starts_at, ends_at) range, while it runs the user gets no reviews.
+The is_active flag follows the schedule: it is switched off when an absence starts
+and back on when it ends or is cancelled (see AvailabilityWorker), so nobody has to
+remember to flip it after a vacation. Every switch is recorded in the audit log,
+made by the actor of the request or by system for the worker.
+
+*/
+
+type AvailabilityService struct {
+	absenceRepo repository.AbsenceRepository
+	userRepo    repository.UserRepository
+	clock       Clock
+	staffing    StaffingNotifier
+	audit       AuditRecorder
+}
+
+// NewAvailabilityService creates the service, staffing may be nil when nobody waits for returning users
+func NewAvailabilityService(absenceRepo repository.AbsenceRepository, userRepo repository.UserRepository, clock Clock,
+	staffing StaffingNotifier, audit AuditRecorder) *AvailabilityService {
+	return &AvailabilityService{
+		absenceRepo: absenceRepo,
+		userRepo:    userRepo,
+		clock:       clock,
+		staffing:    staffing,
+		audit:       audit,
+	}
+}
+
+// ListAbsences returns scheduled and ongoing absences of the user, ended ones only with includePast
+func (s *AvailabilityService) ListAbsences(ctx context.Context, userID string, includePast bool) ([]*models.Absence, error) {
+
+	// Verify user exists
+	_, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	endedAfter := now
+
+	if includePast {
+		endedAfter = time.Time{}
+	}
+
+	absences, err := s.absenceRepo.ListByUser(ctx, userID, endedAfter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, absence := range absences {
+		absence.Status = absence.StatusAt(now)
+	}
+
+	return absences, nil
+}
+
+func (s *AvailabilityService) CreateAbsence(ctx context.Context, userID string, startsAt, endsAt time.Time, reason string) (*models.Absence, error) {
+
+	now := s.clock.Now()
+
+	if !endsAt.After(startsAt) || !endsAt.After(now) {
+		return nil, apperrors.ErrInvalidAbsence
+	}
+
+	// Verify user exists
+	_, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	absence := models.NewAbsence(userID, startsAt, endsAt, reason)
+
+	if err := s.absenceRepo.Create(ctx, absence); err != nil {
+		return nil, err
+	}
+
+	// An absence starting right away switches the user off immediately
+	if !startsAt.After(now) {
+		if _, _, err := s.syncActiveFlags(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+
+	absence.Status = absence.StatusAt(now)
+
+	return absence, nil
+}
+
+func (s *AvailabilityService) CancelAbsence(ctx context.Context, absenceID int64) (*models.Absence, error) {
+
+	absence, err := s.absenceRepo.GetByID(ctx, absenceID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+
+	if absence.IsClosed(now) {
+		return nil, apperrors.ErrAbsenceClosed
+	}
+
+	if err := s.absenceRepo.Cancel(ctx, absenceID, now); err != nil {
+		return nil, err
+	}
+
+	// A cancelled ongoing absence gives the user back right away
+	if absence.StatusAt(now) == models.AbsenceOngoing {
+		if _, _, err := s.syncActiveFlags(ctx, now); err != nil {
+			return nil, err
+		}
+
+		notifyStaffing(s.staffing)
+	}
+
+	absence.CancelledAt = &now
+	absence.Status = models.AbsenceCancelled
+
+	return absence, nil
+}
+
+// SyncAvailability brings is_active flags in line with the absences running now
+func (s *AvailabilityService) SyncAvailability(ctx context.Context) (deactivated, restored int, err error) {
+
+	deactivated, restored, err = s.syncActiveFlags(ctx, s.clock.Now())
+
+	if err == nil && restored > 0 {
+		notifyStaffing(s.staffing)
+	}
+
+	return deactivated, restored, err
+}
+
+// syncActiveFlags switches is_active flags and records every switch in the audit log
+func (s *AvailabilityService) syncActiveFlags(ctx context.Context, now time.Time) (deactivated, restored int, err error) {
+
+	deactivatedUsers, restoredUsers, err := s.absenceRepo.SyncActiveFlags(ctx, now)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, change := range deactivatedUsers {
+		recordAudit(ctx, s.audit, newAuditEntry(ctx, models.AuditUserDeactivated, models.AuditEntityUser,
+			change.After.UserID, change.Before, change.After, now))
+	}
+
+	for _, change := range restoredUsers {
+		recordAudit(ctx, s.audit, newAuditEntry(ctx, models.AuditUserActivated, models.AuditEntityUser,
+			change.After.UserID, change.Before, change.After, now))
+	}
+
+	return len(deactivatedUsers), len(restoredUsers), nil
+}
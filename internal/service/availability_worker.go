@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Background worker applying out-of-office schedules.
+Every interval it switches is_active off for users whose absence has started
+and back on for users whose absence has ended. Runs until the context is cancelled.
+
+*/
+
+type AvailabilityWorker struct {
+	availabilityService *AvailabilityService
+	interval            time.Duration
+}
+
+func NewAvailabilityWorker(availabilityService *AvailabilityService, interval time.Duration) *AvailabilityWorker {
+	return &AvailabilityWorker{
+		availabilityService: availabilityService,
+		interval:            interval,
+	}
+}
+
+func (w *AvailabilityWorker) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.sync(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *AvailabilityWorker) sync(ctx context.Context) {
+
+	deactivated, restored, err := w.availabilityService.SyncAvailability(ctx)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to sync user availability")
+		}
+		return
+	}
+
+	if deactivated > 0 || restored > 0 {
+		log.Info().Int("deactivated", deactivated).Int("restored", restored).Msg("User availability synced")
+	}
+}
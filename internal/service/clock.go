@@ -0,0 +1,15 @@
+package service
+
+import "time"
+
+// Clock tells the current time, tests substitute a fixed one
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
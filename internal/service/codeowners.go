@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+CODEOWNERS parsing and path matching.
+Each non-empty line is "<pattern> <owner>...", '#' starts a comment.
+Owners are "@user_id" for users and "@org/team_name" for teams
+(the part before the slash is ignored, as there is a single organisation).
+
+Patterns follow the gitignore subset used by CODEOWNERS:
+- "*" matches within one path segment, "**" matches any number of segments
+- a leading "/" (or a "/" in the middle) anchors the pattern to the repository root,
+  otherwise it matches at any depth
+- a pattern matching a directory matches every file below it
+- the last matching rule wins, a rule without owners clears ownership
+
+*/
+
+func ParseCodeowners(text string) ([]models.OwnershipRule, error) {
+
+	rules := []models.OwnershipRule{}
+
+	for i, line := range strings.Split(text, "\n") {
+
+		// Drop comments and surrounding whitespace
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		rule := models.OwnershipRule{
+			Pattern:    fields[0],
+			OwnerUsers: []string{},
+			OwnerTeams: []string{},
+		}
+
+		if _, err := compileOwnershipPattern(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", apperrors.ErrInvalidOwnership, i+1, err)
+		}
+
+		for _, owner := range fields[1:] {
+
+			if !strings.HasPrefix(owner, "@") || len(owner) == 1 {
+				return nil, fmt.Errorf("%w: line %d: owner %q must start with @", apperrors.ErrInvalidOwnership, i+1, owner)
+			}
+
+			owner = owner[1:]
+
+			if idx := strings.LastIndex(owner, "/"); idx != -1 {
+				rule.OwnerTeams = append(rule.OwnerTeams, owner[idx+1:])
+			} else {
+				rule.OwnerUsers = append(rule.OwnerUsers, owner)
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// MatchOwnershipRule returns the rule deciding ownership of the path, nil if none matches
+func MatchOwnershipRule(rules []models.OwnershipRule, path string) *models.OwnershipRule {
+
+	path = strings.TrimPrefix(path, "/")
+
+	for i := len(rules) - 1; i >= 0; i-- {
+
+		re, err := compileOwnershipPattern(rules[i].Pattern)
+
+		if err != nil {
+			continue
+		}
+
+		if re.MatchString(path) {
+			return &rules[i]
+		}
+	}
+
+	return nil
+}
+
+func compileOwnershipPattern(pattern string) (*regexp.Regexp, error) {
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty pattern %q", pattern)
+	}
+
+	var b strings.Builder
+
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "/**"):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			b.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+
+	// A matched directory owns everything below it
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(b.String())
+}
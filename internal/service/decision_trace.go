@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Decision traces of reviewer assignment.
+While reviewers are being picked the trace travels in the context: the pipeline
+records which team members were left out and why, tracingSelector records every
+call of the team's strategy with each candidate's load as the strategy saw it (taken
+from the load snapshot of the selection) and, for strategies that rank by load,
+whether a tie on load had to be broken. Without a trace in the context all of
+this is a no-op, so the pipeline does not depend on tracing being enabled.
+
+The finished trace is saved after the PR, it is diagnostic data: a failure to
+save it is logged and does not undo the assignment.
+
+*/
+
+type traceContextKey struct{}
+
+type decisionTrace struct {
+	trace *models.AssignmentTrace
+	step  models.AssignmentStep
+}
+
+// starts tracing of a reviewer change, nothing is traced without a trace repository
+func (s *PRService) startTrace(ctx context.Context, prID string, action models.TraceAction) (context.Context, *decisionTrace) {
+
+	if s.traceRepo == nil {
+		return ctx, nil
+	}
+
+	return newTraceContext(ctx, prID, action, s.clock)
+}
+
+func newTraceContext(ctx context.Context, prID string, action models.TraceAction, clock Clock) (context.Context, *decisionTrace) {
+
+	trace := &decisionTrace{trace: models.NewAssignmentTrace(prID, action), step: models.StepTeam}
+	trace.trace.CreatedAt = clock.Now()
+
+	return context.WithValue(ctx, traceContextKey{}, trace), trace
+}
+
+// saves the trace of a change that has been persisted
+func (s *PRService) saveTrace(ctx context.Context, trace *decisionTrace, selected ...string) {
+
+	if trace == nil {
+		return
+	}
+
+	trace.trace.Selected = append(trace.trace.Selected, selected...)
+
+	if err := s.traceRepo.Create(ctx, trace.trace); err != nil {
+		log.Error().Err(err).Str("pull_request_id", trace.trace.PullRequestID).Msg("Failed to save assignment trace")
+	}
+}
+
+// records the team members GetActiveByTeam left out with the same arguments, the repository
+// tells which of its filters (author, inactive, absent) left out each of them
+func (s *PRService) traceTeamExclusions(ctx context.Context, teamName, excludeUserID string, at time.Time) error {
+
+	trace := traceFrom(ctx)
+
+	if trace == nil {
+		return nil
+	}
+
+	exclusions, err := s.userRepo.GetTeamExclusions(ctx, teamName, excludeUserID, at)
+
+	if err != nil {
+		return err
+	}
+
+	for _, exclusion := range exclusions {
+		trace.exclude(exclusion.UserID, exclusion.Reason)
+	}
+
+	return nil
+}
+
+func traceFrom(ctx context.Context) *decisionTrace {
+	trace, _ := ctx.Value(traceContextKey{}).(*decisionTrace)
+	return trace
+}
+
+func (t *decisionTrace) enter(step models.AssignmentStep) {
+	if t != nil {
+		t.step = step
+	}
+}
+
+func (t *decisionTrace) replaces(userID string) {
+	if t != nil {
+		t.trace.ReplacedUserID = userID
+	}
+}
+
+func (t *decisionTrace) setStrategy(strategy models.ReviewerStrategy) {
+	if t != nil {
+		t.trace.Strategy = strategy
+	}
+}
+
+// records an exclusion once per user, reason and step
+func (t *decisionTrace) exclude(userID string, reason models.ExclusionReason) {
+
+	if t == nil {
+		return
+	}
+
+	exclusion := models.TraceExclusion{UserID: userID, Step: t.step, Reason: reason}
+
+	if !slices.Contains(t.trace.Excluded, exclusion) {
+		t.trace.Excluded = append(t.trace.Excluded, exclusion)
+	}
+}
+
+// records a strategy call: every candidate with its load and whether it was picked.
+// When the strategy ranks by load, a candidate tied on load with someone on the other
+// side of the cut won or lost the tie-break
+func (t *decisionTrace) addRound(candidates, selected []*models.User, load map[string]int, wanted int, byLoad bool) {
+
+	if t == nil {
+		return
+	}
+
+	picked := make(map[string]bool, len(selected))
+	pickedLoads := make(map[int]bool)
+	passedLoads := make(map[int]bool)
+
+	for _, user := range selected {
+		picked[user.UserID] = true
+	}
+
+	for _, candidate := range candidates {
+		if picked[candidate.UserID] {
+			pickedLoads[load[candidate.UserID]] = true
+		} else {
+			passedLoads[load[candidate.UserID]] = true
+		}
+	}
+
+	round := models.SelectionRound{Step: t.step, Wanted: wanted, Candidates: make([]models.TracedCandidate, 0, len(candidates))}
+
+	for _, candidate := range candidates {
+
+		traced := models.TracedCandidate{
+			UserID:   candidate.UserID,
+			Load:     load[candidate.UserID],
+			Selected: picked[candidate.UserID],
+		}
+
+		switch {
+		case !byLoad:
+			// The order did not come from the load, there was no tie to break
+		case traced.Selected && passedLoads[traced.Load]:
+			traced.TieBreak = models.TieBreakWon
+		case !traced.Selected && pickedLoads[traced.Load]:
+			traced.TieBreak = models.TieBreakLost
+		}
+
+		round.Candidates = append(round.Candidates, traced)
+	}
+
+	t.trace.Rounds = append(t.trace.Rounds, round)
+}
+
+// tracingSelector records the calls of the strategy it wraps into the trace of the context
+type tracingSelector struct {
+	inner    ReviewerSelector
+	userRepo repository.UserRepository
+	usesLoad bool
+}
+
+func (s *tracingSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	trace := traceFrom(ctx)
+
+	if trace == nil || len(candidates) == 0 || count <= 0 {
+		return s.inner.Select(ctx, candidates, count)
+	}
+
+	// The same scores the strategy ranks by
+	load, err := reviewerScores(ctx, s.userRepo, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := s.inner.Select(ctx, candidates, count)
+
+	if err != nil {
+		return nil, err
+	}
+
+	trace.addRound(candidates, selected, load, count, s.usesLoad)
+
+	return selected, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Domain events.
+Events reach the sinks (log, outbound webhooks, file) through the outbox (see outbox.go).
+PRService records the events of a change on the PR, the PR repository saves them to the
+outbox in the transaction of the change, so an event is never lost nor published for
+a change that was rolled back. Other services publish their events to the outbox through
+an EventPublisher once the change has been saved, a failed publication is logged and
+never undoes the change.
+
+*/
+
+// EventPublisher delivers domain events to the outside world
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.Event) error
+}
+
+// LogEventPublisher writes events to the log, it is used while no other sink is configured
+type LogEventPublisher struct{}
+
+func (LogEventPublisher) Publish(ctx context.Context, event models.Event) error {
+
+	log.Info().Str("event_type", string(event.Type)).Time("occurred_at", event.OccurredAt).
+		Interface("data", event.Data).Msg("Event published")
+
+	return nil
+}
+
+// EventPublishers publishes every event to each of the publishers in turn,
+// the first failure is returned after all of them have been tried
+type EventPublishers []EventPublisher
+
+func (p EventPublishers) Publish(ctx context.Context, event models.Event) error {
+
+	var first error
+
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+// publishes an event of a saved change, services may run without a publisher
+func publishEvent(ctx context.Context, publisher EventPublisher, eventType models.EventType, at time.Time, data any) {
+
+	if publisher == nil {
+		return
+	}
+
+	event := models.Event{Type: eventType, OccurredAt: at, Data: data}
+
+	if err := publisher.Publish(ctx, event); err != nil {
+		log.Error().Err(err).Str("event_type", string(eventType)).Msg("Failed to publish event")
+	}
+}
+
+// OutboxNotifier is told that events were saved to the outbox
+type OutboxNotifier interface {
+	NotifyOutbox()
+}
+
+// tells the notifier about saved events, services may run without one
+func notifyOutbox(notifier OutboxNotifier) {
+	if notifier != nil {
+		notifier.NotifyOutbox()
+	}
+}
+
+// records an event of a change of the PR, it is saved together with the change
+func (s *PRService) recordEvent(pr *models.PullRequest, eventType models.EventType, data any) {
+	pr.RecordEvent(models.Event{Type: eventType, OccurredAt: s.clock.Now(), Data: data})
+}
+
+// records reviewer.assigned for every reviewer added to the PR by the action
+func (s *PRService) recordAssigned(pr *models.PullRequest, action models.TraceAction, reviewers ...string) {
+
+	for _, reviewerID := range reviewers {
+		s.recordEvent(pr, models.EventReviewerAssigned, models.ReviewerEventData{
+			PullRequestID: pr.PullRequestID,
+			AuthorID:      pr.AuthorID,
+			ReviewerID:    reviewerID,
+			Action:        action,
+		})
+	}
+}
+
+// records reviewer.reassigned for a reviewer replaced by the action
+func (s *PRService) recordReassigned(pr *models.PullRequest, action models.TraceAction, oldUserID, newUserID string) {
+
+	s.recordEvent(pr, models.EventReviewerReassigned, models.ReviewerEventData{
+		PullRequestID:      pr.PullRequestID,
+		AuthorID:           pr.AuthorID,
+		ReviewerID:         newUserID,
+		ReplacedReviewerID: oldUserID,
+		Action:             action,
+	})
+}
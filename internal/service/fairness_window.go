@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+)
+
+/*
+
+Sliding window fairness of a team.
+Open load alone forgets everything merged: a reviewer who closed ten reviews
+yesterday looks as idle as one who got none for a month. With fairness_window_days
+and fairness_weight set, every assignment of the last fairness_window_days days
+adds fairness_weight to the candidate's load, so load based strategies balance
+the recent total as well. The same score is reported by /stats/assignments.
+
+*/
+
+// adds the weighted number of assignments within the team's fairness window to the scores
+func addWindowFairness(ctx context.Context, userRepo repository.UserRepository, settings *models.TeamSettings,
+	userIDs []string, now time.Time, scores map[string]int) error {
+
+	recent, err := userRepo.GetAssignmentCounts(ctx, userIDs, now.AddDate(0, 0, -settings.FairnessWindowDays))
+
+	if err != nil {
+		return err
+	}
+
+	for userID, load := range scores {
+		scores[userID] = settings.FairnessScore(load, recent[userID])
+	}
+
+	return nil
+}
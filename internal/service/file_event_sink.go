@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+File event sink.
+Appends every event as one JSON line to a file (EVENT_FILE_PATH), the file is created
+when missing. An event is written with a single write, so lines of concurrent
+publishers do not interleave.
+
+*/
+
+type FileEventSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileEventSink opens the file for appending, Close releases it
+func NewFileEventSink(path string) (*FileEventSink, error) {
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileEventSink{file: file}, nil
+}
+
+func (s *FileEventSink) Publish(ctx context.Context, event models.Event) error {
+
+	line, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.file.Write(append(line, '\n'))
+
+	return err
+}
+
+func (s *FileEventSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Outbound webhooks.
+Subscribers register a URL with the event types they want (pr.created, reviewer.assigned,
+reviewer.reassigned, pr.merged, pr.closed, user.deactivated, review.sla_breached). The service is
+an EventPublisher: every published event is queued as a PENDING delivery for each
+subscription of its type and WebhookDeliveryWorker sends it.
+
+A delivery is a POST of the event JSON with the headers:
+
+- X-Webhook-Event         - event type
+- X-Webhook-Delivery      - delivery id, the same for every attempt of a delivery
+- X-Webhook-Signature-256 - "sha256=" and the hex HMAC-SHA256 of the body keyed by the subscription secret
+
+A 2xx response delivers it. Otherwise the attempt is retried after 30s, doubled on every
+failed attempt up to an hour; after MaxDeliveryAttempts attempts the delivery is FAILED.
+The delivery log keeps every delivery with its last outcome, a replay queues a copy
+of a delivery (signed with the current secret) whatever its status.
+
+*/
+
+const (
+	// MaxDeliveryAttempts is how many times a delivery is sent before it is FAILED
+	MaxDeliveryAttempts = 8
+
+	deliveryRetryBase = 30 * time.Second
+	deliveryRetryMax  = time.Hour
+
+	// a claimed delivery is not sent by other workers for this long
+	deliveryLease     = 2 * time.Minute
+	deliveryBatchSize = 50
+	deliveryTimeout   = 10 * time.Second
+
+	defaultDeliveryListLimit = 100
+	maxDeliveryListLimit     = 1000
+)
+
+type OutboundWebhookService struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	clock       Clock
+	queued      chan struct{}
+}
+
+// NewOutboundWebhookService creates the service, client may be nil for a client
+// with a 10 seconds timeout
+func NewOutboundWebhookService(webhookRepo repository.WebhookRepository, client *http.Client, clock Clock) *OutboundWebhookService {
+
+	if client == nil {
+		client = &http.Client{Timeout: deliveryTimeout}
+	}
+
+	return &OutboundWebhookService{
+		webhookRepo: webhookRepo,
+		client:      client,
+		clock:       clock,
+		queued:      make(chan struct{}, 1),
+	}
+}
+
+// Publish queues the event for every subscription of its type
+func (s *OutboundWebhookService) Publish(ctx context.Context, event models.Event) error {
+
+	subscriptions, err := s.webhookRepo.ListSubscribers(ctx, event.Type)
+
+	if err != nil {
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+
+	for _, subscription := range subscriptions {
+
+		delivery := &models.WebhookDelivery{
+			SubscriptionID: subscription.SubscriptionID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         models.DeliveryPending,
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+		}
+
+		if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	s.notifyQueued()
+
+	return nil
+}
+
+// DeliveriesQueued receives a value after deliveries were queued
+func (s *OutboundWebhookService) DeliveriesQueued() <-chan struct{} {
+	return s.queued
+}
+
+// wakes up the delivery worker without blocking, like NotifyStaffing
+func (s *OutboundWebhookService) notifyQueued() {
+
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe registers an http(s) URL for the event types. A secret is generated
+// when none is given, the returned subscription carries it
+func (s *OutboundWebhookService) Subscribe(ctx context.Context, rawURL string, events []models.EventType, secret string) (*models.WebhookSubscription, error) {
+
+	rawURL = strings.TrimSpace(rawURL)
+
+	if err := validateWebhookURL(rawURL); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", apperrors.ErrInvalidSubscription)
+	}
+
+	unique := []models.EventType{}
+
+	for _, event := range events {
+
+		if !event.IsValid() {
+			return nil, fmt.Errorf("%w: unknown event %q", apperrors.ErrInvalidSubscription, event)
+		}
+
+		if !slices.Contains(unique, event) {
+			unique = append(unique, event)
+		}
+	}
+
+	if secret == "" {
+
+		generated, err := generateSecret()
+
+		if err != nil {
+			return nil, err
+		}
+
+		secret = generated
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    unique,
+		CreatedAt: s.clock.Now(),
+	}
+
+	if err := s.webhookRepo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// Unsubscribe drops the subscription, its pending deliveries are not sent anymore
+func (s *OutboundWebhookService) Unsubscribe(ctx context.Context, subscriptionID int64) error {
+	return s.webhookRepo.DeleteSubscription(ctx, subscriptionID)
+}
+
+func (s *OutboundWebhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	return s.webhookRepo.ListSubscriptions(ctx)
+}
+
+// ListDeliveries lists the delivery log latest first, 100 deliveries unless the filter has a limit
+func (s *OutboundWebhookService) ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, fmt.Errorf("%w: status must be PENDING, DELIVERED or FAILED", apperrors.ErrInvalidSubscription)
+	}
+
+	if filter.EventType != "" && !filter.EventType.IsValid() {
+		return nil, fmt.Errorf("%w: unknown event %q", apperrors.ErrInvalidSubscription, filter.EventType)
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxDeliveryListLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", apperrors.ErrInvalidSubscription, maxDeliveryListLimit)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultDeliveryListLimit
+	}
+
+	return s.webhookRepo.ListDeliveries(ctx, filter)
+}
+
+// Replay queues the payload of a delivery again as a new delivery to the same subscription
+func (s *OutboundWebhookService) Replay(ctx context.Context, deliveryID int64) (*models.WebhookDelivery, error) {
+
+	original, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+
+	replay := &models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &now,
+		ReplayOf:       &original.DeliveryID,
+		CreatedAt:      now,
+	}
+
+	if err := s.webhookRepo.CreateDelivery(ctx, replay); err != nil {
+		return nil, err
+	}
+
+	s.notifyQueued()
+
+	return replay, nil
+}
+
+// DeliverDue sends the deliveries that are due and returns how many of them were delivered.
+// A failed attempt is recorded in its delivery and does not stop the others
+func (s *OutboundWebhookService) DeliverDue(ctx context.Context) (int, error) {
+
+	delivered := 0
+	subscriptions := map[int64]*models.WebhookSubscription{}
+
+	for {
+		deliveries, err := s.webhookRepo.ClaimDueDeliveries(ctx, s.clock.Now(), deliveryLease, deliveryBatchSize)
+
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, delivery := range deliveries {
+
+			subscription, ok := subscriptions[delivery.SubscriptionID]
+
+			if !ok {
+				subscription, err = s.webhookRepo.GetSubscription(ctx, delivery.SubscriptionID)
+
+				// Unsubscribed meanwhile, its deliveries are gone with it
+				if errors.Is(err, apperrors.ErrSubscriptionNotFound) {
+					continue
+				}
+
+				if err != nil {
+					return delivered, err
+				}
+
+				subscriptions[delivery.SubscriptionID] = subscription
+			}
+
+			s.attempt(ctx, subscription, delivery)
+
+			// Shutting down, the lease runs out and the delivery is sent again later
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+
+			if err := s.webhookRepo.RecordAttempt(ctx, delivery); err != nil {
+				return delivered, err
+			}
+
+			if delivery.Status == models.DeliveryDelivered {
+				delivered++
+			} else {
+				log.Warn().Int64("delivery_id", delivery.DeliveryID).Int("attempts", delivery.Attempts).
+					Str("status", string(delivery.Status)).Str("error", delivery.LastError).Msg("Webhook delivery failed")
+			}
+		}
+
+		if len(deliveries) < deliveryBatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// sends the delivery once and sets its status, attempts and outcome
+func (s *OutboundWebhookService) attempt(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+
+	status, err := s.send(ctx, subscription, delivery)
+	now := s.clock.Now()
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= MaxDeliveryAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	next := now.Add(backoffDelay(deliveryRetryBase, deliveryRetryMax, delivery.Attempts))
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttemptAt = &next
+}
+
+// POSTs the signed payload, returns the response status (0 without a response)
+// and an error unless it is 2xx
+func (s *OutboundWebhookService) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, strings.NewReader(string(delivery.Payload)))
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set("X-Webhook-Signature-256", "sha256="+signPayload(subscription.Secret, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	// Drained so the connection is reused, subscribers have nothing to tell in the body
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// hex HMAC-SHA256 of the payload keyed by the secret
+func signPayload(secret string, payload []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateSecret() (string, error) {
+
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+func validateWebhookURL(rawURL string) error {
+
+	if rawURL == "" || len(rawURL) > models.MaxWebhookURLLength {
+		return fmt.Errorf("%w: url must be 1 to %d characters", apperrors.ErrInvalidSubscription, models.MaxWebhookURLLength)
+	}
+
+	parsed, err := url.Parse(rawURL)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", apperrors.ErrInvalidSubscription)
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Transactional outbox.
+Events are saved to the outbox_events table and OutboxDispatcher drains it to the sinks
+(log, outbound webhooks, file - see EVENT_SINKS) with at-least-once semantics: an event
+is marked DISPATCHED only after every sink accepted it, so a sink may get an event again
+when another sink failed or the process died in between (Event.ID tells duplicates apart).
+
+Events of one aggregate (a PR or a user) are dispatched one after another in the order
+they were saved, a failed event holds back the later events of its aggregate.
+A failed dispatch is retried after 5s, doubled on every failure up to 10 minutes; after
+maxAttempts failures the event is DEAD (dead-lettered), the later events of its aggregate
+move on and the event stays in the outbox until it is requeued.
+
+*/
+
+const (
+	outboxRetryBase = 5 * time.Second
+	outboxRetryMax  = 10 * time.Minute
+
+	// a claimed event is not dispatched by other dispatchers for this long
+	outboxLease     = time.Minute
+	outboxBatchSize = 100
+
+	defaultDeadListLimit = 100
+)
+
+type OutboxService struct {
+	outboxRepo  repository.OutboxRepository
+	sink        EventPublisher
+	clock       Clock
+	maxAttempts int
+	saved       chan struct{}
+}
+
+// NewOutboxService creates the service dispatching to the sink, EventPublishers combines several
+func NewOutboxService(outboxRepo repository.OutboxRepository, sink EventPublisher, clock Clock, maxAttempts int) *OutboxService {
+	return &OutboxService{
+		outboxRepo:  outboxRepo,
+		sink:        sink,
+		clock:       clock,
+		maxAttempts: maxAttempts,
+		saved:       make(chan struct{}, 1),
+	}
+}
+
+// Publish saves an event to the outbox, for services whose changes are not saved by the PR repository
+func (s *OutboxService) Publish(ctx context.Context, event models.Event) error {
+
+	if err := s.outboxRepo.Append(ctx, event); err != nil {
+		return err
+	}
+
+	s.NotifyOutbox()
+
+	return nil
+}
+
+// NotifyOutbox wakes up the dispatcher without blocking, notifications coming
+// before it runs again are merged into one
+func (s *OutboxService) NotifyOutbox() {
+
+	select {
+	case s.saved <- struct{}{}:
+	default:
+	}
+}
+
+// EventsSaved receives a value after NotifyOutbox
+func (s *OutboxService) EventsSaved() <-chan struct{} {
+	return s.saved
+}
+
+// Dispatch sends the due events to the sink and returns how many of them were dispatched.
+// A failed event is recorded and does not stop events of other aggregates
+func (s *OutboxService) Dispatch(ctx context.Context) (int, error) {
+
+	dispatched := 0
+
+	// Every round claims the next event of the aggregates, until none is due
+	for {
+		events, err := s.outboxRepo.ClaimDue(ctx, s.clock.Now(), outboxLease, outboxBatchSize)
+
+		if err != nil {
+			return dispatched, err
+		}
+
+		if len(events) == 0 {
+			return dispatched, nil
+		}
+
+		for _, event := range events {
+
+			err := s.sink.Publish(ctx, event.Event())
+
+			// Shutting down, the lease runs out and the event is dispatched again later
+			if ctx.Err() != nil {
+				return dispatched, ctx.Err()
+			}
+
+			s.recordOutcome(event, err)
+
+			if err := s.outboxRepo.RecordAttempt(ctx, event); err != nil {
+				return dispatched, err
+			}
+
+			switch event.Status {
+			case models.OutboxDispatched:
+				dispatched++
+			case models.OutboxDead:
+				log.Error().Int64("event_id", event.EventID).Str("event_type", string(event.EventType)).
+					Str("error", event.LastError).Msg("Outbox event dead-lettered")
+			default:
+				log.Warn().Int64("event_id", event.EventID).Int("attempts", event.Attempts).
+					Str("error", event.LastError).Msg("Outbox event dispatch failed")
+			}
+		}
+	}
+}
+
+// sets the status, attempts and outcome of a dispatch attempt
+func (s *OutboxService) recordOutcome(event *models.OutboxEvent, err error) {
+
+	now := s.clock.Now()
+	event.Attempts++
+
+	if err == nil {
+		event.Status = models.OutboxDispatched
+		event.NextAttemptAt = nil
+		event.LastError = ""
+		event.DispatchedAt = &now
+		return
+	}
+
+	event.LastError = err.Error()
+
+	if event.Attempts >= s.maxAttempts {
+		event.Status = models.OutboxDead
+		event.NextAttemptAt = nil
+		return
+	}
+
+	next := now.Add(backoffDelay(outboxRetryBase, outboxRetryMax, event.Attempts))
+	event.NextAttemptAt = &next
+}
+
+// ListDeadLetters lists DEAD events latest first
+func (s *OutboxService) ListDeadLetters(ctx context.Context) ([]*models.OutboxEvent, error) {
+	return s.outboxRepo.ListDead(ctx, defaultDeadListLimit)
+}
+
+// Requeue gives a DEAD event a fresh set of attempts, it is dispatched right away
+func (s *OutboxService) Requeue(ctx context.Context, eventID int64) error {
+
+	if err := s.outboxRepo.Requeue(ctx, eventID, s.clock.Now()); err != nil {
+		return err
+	}
+
+	s.NotifyOutbox()
+
+	return nil
+}
+
+// delay after the given number of failed attempts: base, doubled on every next one up to limit
+func backoffDelay(base, limit time.Duration, attempts int) time.Duration {
+
+	delay := base
+
+	for i := 1; i < attempts && delay < limit; i++ {
+		delay *= 2
+	}
+
+	return min(delay, limit)
+}
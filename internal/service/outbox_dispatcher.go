@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Background worker draining the outbox to the event sinks.
+It dispatches the due events whenever new ones are saved and every interval
+in between, which is when retries come due. Runs until the context is cancelled.
+
+*/
+
+type OutboxDispatcher struct {
+	outboxService *OutboxService
+	interval      time.Duration
+}
+
+func NewOutboxDispatcher(outboxService *OutboxService, interval time.Duration) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		outboxService: outboxService,
+		interval:      interval,
+	}
+}
+
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.outboxService.EventsSaved():
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *OutboxDispatcher) dispatch(ctx context.Context) {
+
+	dispatched, err := d.outboxService.Dispatch(ctx)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to dispatch outbox events")
+		}
+		return
+	}
+
+	if dispatched > 0 {
+		log.Debug().Int("dispatched", dispatched).Msg("Outbox events dispatched")
+	}
+}
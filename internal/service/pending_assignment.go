@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Pending assignment queue.
+OPEN PRs left with empty reviewer slots (understaffed or awaiting a reviewer at
+the cap) are kept in the queue by the PR repository on every write. Changes that
+may give such PRs a reviewer - a user activated, members joining a team, a cap raised
+or reviews closed - notify the staffing signal, PendingAssignmentWorker then runs
+the assignment pipeline again for every queued PR. It also runs on a timer, for changes
+the signal does not see (e.g. loads falling out of the fairness window), and then retries
+only PRs whose backoff is over: the pause after a failed attempt doubles with every attempt.
+
+A retry keeps the assigned reviewers and only fills the empty slots, with the current
+settings of the author's team. A retry never rejects: the understaffed policy FAIL acts as
+ALLOW and the cap policy FAIL as QUEUE, so reviewers found are kept even if slots stay empty.
+A PR that still cannot be fully staffed stays in the queue and the attempt is counted.
+
+*/
+
+// StaffingNotifier is told about changes that may free reviewers for waiting PRs
+type StaffingNotifier interface {
+	NotifyStaffing()
+}
+
+// tells the notifier about a staffing change, services may run without one
+func notifyStaffing(notifier StaffingNotifier) {
+	if notifier != nil {
+		notifier.NotifyStaffing()
+	}
+}
+
+// NotifyStaffing wakes up the pending assignment worker, it never blocks:
+// notifications coming before the worker runs again are merged into one
+func (s *PRService) NotifyStaffing() {
+
+	select {
+	case s.staffing <- struct{}{}:
+	default:
+	}
+}
+
+// StaffingChanged receives a value after NotifyStaffing
+func (s *PRService) StaffingChanged() <-chan struct{} {
+	return s.staffing
+}
+
+// ListPending returns PRs waiting for reviewers, oldest first, of all teams when teamName is empty
+func (s *PRService) ListPending(ctx context.Context, teamName string) ([]*models.PendingAssignment, error) {
+
+	if teamName != "" {
+
+		exists, err := s.teamRepo.Exists(ctx, teamName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, apperrors.ErrTeamNotFound
+		}
+	}
+
+	return s.prRepo.ListPending(ctx, teamName)
+}
+
+// StaffPending retries the assignment of queued PRs whose backoff is over and returns
+// how many of them left the queue. Failures of single PRs are logged and do not stop the others
+func (s *PRService) StaffPending(ctx context.Context) (int, error) {
+	return s.staffPending(ctx, false)
+}
+
+// StaffAllPending retries every queued PR regardless of its backoff, for changes that may
+// have freed reviewers
+func (s *PRService) StaffAllPending(ctx context.Context) (int, error) {
+	return s.staffPending(ctx, true)
+}
+
+func (s *PRService) staffPending(ctx context.Context, all bool) (int, error) {
+
+	pending, err := s.prRepo.ListPending(ctx, "")
+
+	if err != nil {
+		return 0, err
+	}
+
+	staffed := 0
+	now := s.clock.Now()
+
+	for _, item := range pending {
+
+		if !all && !item.RetryDue(now) {
+			continue
+		}
+
+		done, err := s.staffPR(ctx, item.PullRequestID)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return staffed, ctx.Err()
+			}
+
+			log.Error().Err(err).Str("pull_request_id", item.PullRequestID).Msg("Failed to retry reviewer assignment")
+			continue
+		}
+
+		if done {
+			staffed++
+		}
+	}
+
+	return staffed, nil
+}
+
+// fills the empty reviewer slots of a queued PR, reports whether the PR left the queue
+func (s *PRService) staffPR(ctx context.Context, prID string) (bool, error) {
+
+	pr, err := s.getOpenPR(ctx, prID)
+
+	if err != nil {
+		return false, err
+	}
+
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return false, err
+	}
+
+	settings = retrySettings(settings)
+	before := pr.Snapshot()
+	wasUnderstaffed, wasAwaiting := pr.Understaffed, pr.AwaitingReviewer
+	assigned := len(pr.AssignedReviewers)
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, models.TraceActionRetry)
+	trace.setStrategy(settings.ReviewerStrategy)
+
+	pr.Understaffed, pr.AwaitingReviewer = false, false
+
+	err = s.assignReviewers(withCapState(ctx, settings), pr, settings)
+
+	// Nobody has a required tag yet, the PR keeps waiting
+	if errors.Is(err, apperrors.ErrTagNotCovered) {
+		return false, s.prRepo.RecordPendingAttempt(ctx, prID, s.clock.Now())
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	added := pr.AssignedReviewers[assigned:]
+
+	if len(added) == 0 && pr.Understaffed == wasUnderstaffed && pr.AwaitingReviewer == wasAwaiting {
+		return false, s.prRepo.RecordPendingAttempt(ctx, prID, s.clock.Now())
+	}
+
+	s.recordAssigned(pr, models.TraceActionRetry, added...)
+
+	if len(added) > 0 {
+		s.recordPRAudit(ctx, pr, models.AuditReviewerAdded, before)
+	}
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return false, err
+	}
+
+	if len(added) > 0 {
+		s.saveTrace(ctx, trace, added...)
+		notifyOutbox(s.outbox)
+	}
+
+	_, pending := pr.PendingReason()
+
+	if pending {
+		return false, s.prRepo.RecordPendingAttempt(ctx, prID, s.clock.Now())
+	}
+
+	return true, nil
+}
+
+// settings of a retry: the policies that reject a PR leave its slots empty instead
+func retrySettings(settings *models.TeamSettings) *models.TeamSettings {
+
+	retry := *settings
+
+	if retry.UnderstaffedPolicy == models.UnderstaffedFail {
+		retry.UnderstaffedPolicy = models.UnderstaffedAllow
+	}
+
+	if retry.CapPolicy == models.CapFail {
+		retry.CapPolicy = models.CapQueue
+	}
+
+	return &retry
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Background worker staffing the pending assignment queue.
+It retries every queued PR on start and whenever the staffing signal of PRService
+fires, every interval in between only PRs whose backoff is over. Runs until the
+context is cancelled.
+
+*/
+
+type PendingAssignmentWorker struct {
+	prService *PRService
+	interval  time.Duration
+}
+
+func NewPendingAssignmentWorker(prService *PRService, interval time.Duration) *PendingAssignmentWorker {
+	return &PendingAssignmentWorker{
+		prService: prService,
+		interval:  interval,
+	}
+}
+
+func (w *PendingAssignmentWorker) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	all := true
+
+	for {
+		w.staff(ctx, all)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.prService.StaffingChanged():
+			all = true
+		case <-ticker.C:
+			all = false
+		}
+	}
+}
+
+func (w *PendingAssignmentWorker) staff(ctx context.Context, all bool) {
+
+	staff := w.prService.StaffPending
+
+	if all {
+		staff = w.prService.StaffAllPending
+	}
+
+	staffed, err := staff(ctx)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to staff pending PRs")
+		}
+		return
+	}
+
+	if staffed > 0 {
+		log.Info().Int("staffed", staffed).Msg("Pending PRs staffed")
+	}
+}
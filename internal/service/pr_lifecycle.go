@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+PR lifecycle beyond merging.
+Transitions are enforced by models.PullRequest, an illegal one fails with INVALID_TRANSITION.
+A DRAFT has no reviewers until it is marked ready, then the whole assignment pipeline runs
+as on creation. Closing abandons a PR: it stays in the history with its reviewers, but no
+longer counts as their load, so waiting PRs are retried; pr.closed is recorded as an event
+and in the audit log. A reopened PR keeps the reviewers
+it had and gets the missing ones with the current settings of the author's team.
+
+*/
+
+// MarkReady opens a DRAFT PR for review and assigns its reviewers
+func (s *PRService) MarkReady(ctx context.Context, prID string) (*models.PullRequest, error) {
+
+	pr, err := s.prRepo.GetByID(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pr.MarkReady(); err != nil {
+		return nil, err
+	}
+
+	return s.openForReview(ctx, pr, models.TraceActionReady)
+}
+
+// ClosePR abandons a DRAFT or OPEN PR without merging, closing a CLOSED PR changes nothing
+func (s *PRService) ClosePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+
+	pr, err := s.prRepo.GetByID(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.Status == models.PRStatusClosed {
+		return pr, nil
+	}
+
+	before := pr.Snapshot()
+
+	if err := pr.Close(); err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(pr, models.EventPRClosed, models.PREventData{PullRequest: pr})
+	s.recordPRAudit(ctx, pr, models.AuditPRClosed, before)
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	notifyOutbox(s.outbox)
+
+	// Reviews of the PR no longer count as load
+	s.NotifyStaffing()
+
+	return pr, nil
+}
+
+// ReopenPR brings a CLOSED PR back to review, filling the reviewer slots it is missing
+func (s *PRService) ReopenPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+
+	pr, err := s.prRepo.GetByID(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pr.Reopen(); err != nil {
+		return nil, err
+	}
+
+	return s.openForReview(ctx, pr, models.TraceActionReopen)
+}
+
+// runs the assignment pipeline for a PR that has just become OPEN and saves it,
+// reviewers it already has keep their slots
+func (s *PRService) openForReview(ctx context.Context, pr *models.PullRequest, action models.TraceAction) (*models.PullRequest, error) {
+
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, action)
+	trace.setStrategy(settings.ReviewerStrategy)
+
+	before := pr.Snapshot()
+	assigned := len(pr.AssignedReviewers)
+	pr.Understaffed, pr.AwaitingReviewer = false, false
+
+	if err := s.assignReviewers(withCapState(ctx, settings), pr, settings); err != nil {
+		return nil, err
+	}
+
+	s.recordAssigned(pr, action, pr.AssignedReviewers[assigned:]...)
+
+	if len(pr.AssignedReviewers) > assigned {
+		s.recordPRAudit(ctx, pr, models.AuditReviewerAdded, before)
+	}
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	s.saveTrace(ctx, trace, pr.AssignedReviewers[assigned:]...)
+	notifyOutbox(s.outbox)
+
+	return pr, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+PR metadata: repository, URL, base branch, labels and description of a PR
+on its code host. Metadata may change at any time, a change of labels of an OPEN PR
+runs the label rules of the author's team again: rules matched by new labels get their
+reviewers, reviewers of labels taken off stay. A rule left without a reviewer does not
+fail the update, the PR is marked and waits in the pending assignment queue.
+
+*/
+
+// PRMetadataUpdate holds metadata to change, nil fields keep their current value.
+// Labels replace the whole list
+type PRMetadataUpdate struct {
+	PullRequestName *string   `json:"pull_request_name"`
+	Repository      *string   `json:"repository"`
+	URL             *string   `json:"url"`
+	BaseBranch      *string   `json:"base_branch"`
+	Labels          *[]string `json:"labels"`
+	Description     *string   `json:"description"`
+}
+
+// UpdateMetadata changes the metadata of a PR in any status
+func (s *PRService) UpdateMetadata(ctx context.Context, prID string, update PRMetadataUpdate) (*models.PullRequest, error) {
+
+	pr, err := s.prRepo.GetByID(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	before := pr.Snapshot()
+
+	if update.PullRequestName != nil {
+
+		if *update.PullRequestName == "" {
+			return nil, fmt.Errorf("%w: pull_request_name cannot be empty", apperrors.ErrInvalidMetadata)
+		}
+
+		pr.PullRequestName = *update.PullRequestName
+	}
+
+	metadata := pr.PRMetadata
+
+	if update.Repository != nil {
+		metadata.Repository = *update.Repository
+	}
+
+	if update.URL != nil {
+		metadata.URL = *update.URL
+	}
+
+	if update.BaseBranch != nil {
+		metadata.BaseBranch = *update.BaseBranch
+	}
+
+	if update.Labels != nil {
+		metadata.Labels = *update.Labels
+	}
+
+	if update.Description != nil {
+		metadata.Description = *update.Description
+	}
+
+	if err := metadata.Normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidMetadata, err)
+	}
+
+	labelsChanged := !slices.Equal(pr.Labels, metadata.Labels)
+	pr.PRMetadata = metadata
+
+	if !labelsChanged || !pr.IsOpen() {
+		return pr, s.prRepo.Update(ctx, pr)
+	}
+
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := len(pr.AssignedReviewers)
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, models.TraceActionLabel)
+	trace.setStrategy(settings.ReviewerStrategy)
+	ctx = withCapState(ctx, settings)
+
+	unfilled, err := s.assignLabelled(ctx, pr, settings)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if unfilled > 0 && capReached(ctx) {
+		pr.AwaitingReviewer = true
+	} else if unfilled > 0 {
+		pr.Understaffed = true
+	}
+
+	added := pr.AssignedReviewers[assigned:]
+	s.recordAssigned(pr, models.TraceActionLabel, added...)
+
+	if len(added) > 0 {
+		s.recordPRAudit(ctx, pr, models.AuditReviewerAdded, before)
+	}
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	if len(added) > 0 {
+		s.saveTrace(ctx, trace, added...)
+		notifyOutbox(s.outbox)
+	}
+
+	return pr, nil
+}
+
+// ListPRs returns PRs matching the filter, newest first, up to DefaultPRListLimit without a limit
+func (s *PRService) ListPRs(ctx context.Context, filter models.PRFilter) ([]*models.PullRequest, error) {
+
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultPRListLimit
+	}
+
+	filter.Limit = min(filter.Limit, models.MaxPRListLimit)
+	filter.Label = models.NormalizeLabel(filter.Label)
+
+	return s.prRepo.List(ctx, filter)
+}
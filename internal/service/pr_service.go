@@ -2,13 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/rand"
-	"sort"
-	"time"
 
 	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
 )
 
 /*
@@ -19,40 +20,125 @@ Key features of reviewer assignment:
 1. Automatic reviewer selection when creating PR:
    - PR author is excluded from candidate list
    - Only active users from the same team are selected
-   - Up to 2 reviewers are assigned
+   - The team's reviewer_count (2 by default) reviewers are assigned
+   - When changed files are given, code owners from the team's ownership
+     rules are picked first (one per matched rule)
+   - Label rules of the team add a reviewer of another team to PRs having the label
+   - Every required tag of the PR is covered by at least one reviewer having it,
+     load is balanced by the strategy among those, the team fills the rest
+   - With too few candidates the team's understaffed policy applies:
+     FAIL rejects the PR, FALLBACK fills the gap from the fallback teams
+     (in their order, reviewers are marked as fallback ones),
+     ALLOW (and FALLBACK that still falls short) marks the PR as understaffed
 
 2. Load balancing:
-   - Current number of OPEN PRs per reviewer is considered
-   - Candidates are sorted by ascending load
+   - Each team picks a ReviewerSelector strategy (see reviewer_selector.go)
+   - The team's working hours policy may put reviewers who work now (or soon) first
+     or leave the others out (see working_hours_selector.go)
+   - Never pair rules of the team forbid reviewers for the author, the author spread
+     penalty makes recent reviewers of the author look busier (see author_affinity.go)
+   - The default one sorts candidates by ascending number of OPEN PRs
    - Random selection is used for equal load
+   - Every change of reviewers records a decision trace (see decision_trace.go),
+     ExplainAssignment returns them
+   - PreviewPR runs the same assignment without saving anything
 
 3. Reviewer reassignment:
    - Current reviewers and author are excluded during replacement
-   - Candidate with minimum load is selected from available ones
-   - Reassignment is prohibited for merged PRs
+   - Replacement is picked by the strategy of the old reviewer's team
+   - Required tags only the old reviewer covered must be covered by the replacement
+   - With FALLBACK policy an empty team hands over to its fallback teams
+   - Reassignment is prohibited for merged PRs, reviewers of DRAFT and CLOSED PRs do not change
+   - A replacement may also be chosen explicitly (ReassignReviewerTo), reviewers may be
+     added or removed by hand within the team's reviewer count and understaffed policy
+   - ReassignOpenReviews moves all OPEN reviews of a user at once (e.g. on deactivation),
+     PR by PR, reporting the replacement or the failure reason of each one
+
+4. Reviews and merging:
+   - Every assigned reviewer has a review state, PENDING until they submit
+     APPROVED, CHANGES_REQUESTED or COMMENTED (SubmitReview)
+   - The merge_approvals setting of the author's team may require approvals
+     of all assigned reviewers or of a quorum before MergePR succeeds
+
+5. Lifecycle (see pr_lifecycle.go):
+   - A PR may be created as a DRAFT, reviewers are picked when it is marked ready
+   - CLOSED PRs are abandoned without merging and free their reviewers' load,
+     a reopened PR keeps its reviewers and gets the missing ones
+
+6. Pending assignment queue:
+   - OPEN PRs with empty slots wait in the queue, PendingAssignmentWorker retries them
+     when reviewers may have been freed (see pending_assignment.go)
+
+7. Review SLA (see review_sla.go):
+   - Late reviews may get an extra reviewer (AddEscalationReviewer) or be reassigned
+
+8. Events (see events.go):
+   - pr.created, pr.merged, pr.closed, reviewer.assigned and reviewer.reassigned are recorded
+     on the PR and saved to the outbox together with the change
+
+9. Audit (see audit.go):
+   - Creation, merge, closing and every change of the reviewers record an audit entry with the PR
+     before and after the change, saved to the audit log together with the change
 
 The algorithm ensures even distribution of PRs among team reviewers.
 */
 
+// CreatePRRequest describes a PR being registered, optional fields may be left empty
+type CreatePRRequest struct {
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	ChangedFiles    []string `json:"changed_files"`
+	RequiredTags    []string `json:"required_tags"`
+
+	// A draft gets its reviewers when it is marked ready
+	Draft bool `json:"draft"`
+
+	// Optional, used by teams weighing PRs by size
+	Size         *models.PRSize `json:"size"`
+	ReviewWeight *int           `json:"review_weight"`
+
+	// Optional code host metadata, labels may ask for reviewers of other teams
+	models.PRMetadata
+}
+
 type PRService struct {
-	prRepo   repository.PRRepository
-	userRepo repository.UserRepository
-	teamRepo repository.TeamRepository
-	rand     *rand.Rand
+	prRepo    repository.PRRepository
+	userRepo  repository.UserRepository
+	teamRepo  repository.TeamRepository
+	traceRepo repository.AssignmentTraceRepository
+	outbox    OutboxNotifier
+	clock     Clock
+	rand      *rand.Rand
+	selectors map[models.ReviewerStrategy]ReviewerSelector
+	staffing  chan struct{}
 }
 
-func NewPRService(prRepo repository.PRRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository) *PRService {
+// NewPRService creates the service, traceRepo may be nil to leave assignments untraced
+// and outbox may be nil when no dispatcher waits for saved events
+func NewPRService(prRepo repository.PRRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository,
+	traceRepo repository.AssignmentTraceRepository, outbox OutboxNotifier, clock Clock) *PRService {
+
+	// Shared by concurrent requests
+	rnd := rand.New(newLockedSource(clock.Now().UnixNano()))
+
 	return &PRService{
-		prRepo:   prRepo,
-		userRepo: userRepo,
-		teamRepo: teamRepo,
-		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		prRepo:    prRepo,
+		userRepo:  userRepo,
+		teamRepo:  teamRepo,
+		traceRepo: traceRepo,
+		outbox:    outbox,
+		clock:     clock,
+		rand:      rnd,
+		selectors: newReviewerSelectors(userRepo, rnd),
+		staffing:  make(chan struct{}, 1),
 	}
 }
 
-func (s *PRService) CreatePR(ctx context.Context, prID, prName, authorID string) (*models.PullRequest, error) {
+func (s *PRService) CreatePR(ctx context.Context, req CreatePRRequest) (*models.PullRequest, error) {
+
 	// Check if PR exists
-	exists, err := s.prRepo.Exists(ctx, prID)
+	exists, err := s.prRepo.Exists(ctx, req.PullRequestID)
 
 	if err != nil {
 		return nil, err
@@ -62,35 +148,132 @@ func (s *PRService) CreatePR(ctx context.Context, prID, prName, authorID string)
 		return nil, apperrors.ErrPRExists
 	}
 
+	ctx, trace := s.startTrace(ctx, req.PullRequestID, models.TraceActionCreate)
+
+	pr, err := s.buildPR(ctx, req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordEvent(pr, models.EventPRCreated, models.PREventData{PullRequest: pr})
+	s.recordAssigned(pr, models.TraceActionCreate, pr.AssignedReviewers...)
+	s.recordPRAudit(ctx, pr, models.AuditPRCreated, nil)
+
+	// Save PR
+	if err := s.prRepo.Create(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	s.saveTrace(ctx, trace, pr.AssignedReviewers...)
+	notifyOutbox(s.outbox)
+
+	return pr, nil
+}
+
+// PreviewPR runs the whole assignment for a PR that is not registered, nothing is saved.
+// Strategies with random tie-breaks may pick differently when the PR is actually created
+func (s *PRService) PreviewPR(ctx context.Context, req CreatePRRequest) (*models.PullRequest, *models.AssignmentTrace, error) {
+
+	ctx, trace := newTraceContext(ctx, req.PullRequestID, models.TraceActionCreate, s.clock)
+
+	pr, err := s.buildPR(ctx, req)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trace.trace.Selected = append(trace.trace.Selected, pr.AssignedReviewers...)
+
+	return pr, trace.trace, nil
+}
+
+// builds the PR of the request with reviewers assigned by the author's team
+func (s *PRService) buildPR(ctx context.Context, req CreatePRRequest) (*models.PullRequest, error) {
+
+	requiredTags, err := normalizeTags(req.RequiredTags)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateSize(req.Size, req.ReviewWeight); err != nil {
+		return nil, err
+	}
+
+	metadata := req.PRMetadata
+
+	if err := metadata.Normalize(); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidMetadata, err)
+	}
+
 	// Get author
-	author, err := s.userRepo.GetByID(ctx, authorID)
+	author, err := s.userRepo.GetByID(ctx, req.AuthorID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Create PR
-	pr := models.NewPullRequest(prID, prName, authorID)
+	// Get author's team settings: strategy, reviewer count and understaffed policy
+	settings, err := s.teamRepo.GetSettings(ctx, author.TeamName)
 
-	// Assign reviewers
-	reviewers, err := s.selectReviewers(ctx, author.TeamName, authorID)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, reviewer := range reviewers {
-		pr.AddReviewer(reviewer.UserID)
+	traceFrom(ctx).setStrategy(settings.ReviewerStrategy)
+
+	// Create PR
+	pr := models.NewPullRequest(req.PullRequestID, req.PullRequestName, req.AuthorID)
+
+	if req.ChangedFiles != nil {
+		pr.ChangedFiles = req.ChangedFiles
 	}
 
-	// Save PR
-	if err := s.prRepo.Create(ctx, pr); err != nil {
+	pr.RequiredTags = requiredTags
+	pr.Size = req.Size
+	pr.ReviewWeight = settings.ReviewWeight(req.Size, req.ReviewWeight)
+	pr.PRMetadata = metadata
+
+	if req.Draft {
+		pr.Status = models.PRStatusDraft
+		return pr, nil
+	}
+
+	// Assign reviewers
+	if err := s.assignReviewers(withCapState(ctx, settings), pr, settings); err != nil {
 		return nil, err
 	}
 
 	return pr, nil
 }
 
+func validateSize(size *models.PRSize, weight *int) error {
+
+	if size != nil && (size.LinesAdded < 0 || size.LinesRemoved < 0 || size.FilesChanged < 0) {
+		return fmt.Errorf("%w: size must not be negative", apperrors.ErrInvalidSize)
+	}
+
+	if weight != nil && (*weight < models.DefaultReviewWeight || *weight > models.MaxReviewWeight) {
+		return fmt.Errorf("%w: review_weight must be between %d and %d",
+			apperrors.ErrInvalidSize, models.DefaultReviewWeight, models.MaxReviewWeight)
+	}
+
+	return nil
+}
+
+// MergePR merges a PR once it has the approvals merge_approvals of the author's team require
 func (s *PRService) MergePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return s.mergePR(ctx, prID, true)
+}
+
+// RecordExternalMerge records a merge that already happened on the code host. The approvals
+// are not enforced, a PR merged without them is only logged: rejecting would leave it OPEN forever
+func (s *PRService) RecordExternalMerge(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return s.mergePR(ctx, prID, false)
+}
+
+func (s *PRService) mergePR(ctx context.Context, prID string, enforceApprovals bool) (*models.PullRequest, error) {
 
 	pr, err := s.prRepo.GetByID(ctx, prID)
 
@@ -103,27 +286,93 @@ func (s *PRService) MergePR(ctx context.Context, prID string) (*models.PullReque
 		return pr, nil
 	}
 
-	pr.Merge()
+	before := pr.Snapshot()
+
+	// DRAFT and CLOSED PRs cannot be merged
+	if err := pr.Merge(); err != nil {
+		return nil, err
+	}
+
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkApprovals(settings, pr); err != nil {
+
+		if enforceApprovals {
+			return nil, err
+		}
+
+		log.Warn().Err(err).Str("pull_request_id", pr.PullRequestID).Msg("PR merged on the code host without the required approvals")
+	}
+
+	s.recordEvent(pr, models.EventPRMerged, models.PREventData{PullRequest: pr})
+	s.recordPRAudit(ctx, pr, models.AuditPRMerged, before)
 
 	if err := s.prRepo.Update(ctx, pr); err != nil {
 		return nil, err
 	}
 
+	notifyOutbox(s.outbox)
+
+	// Reviews of the PR are closed, its reviewers may be under their caps again
+	s.NotifyStaffing()
+
 	return pr, nil
 }
 
-func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error) {
+// reports whether the PR has the approvals merge_approvals of the team require
+func checkApprovals(settings *models.TeamSettings, pr *models.PullRequest) error {
 
-	// Get pr
-	pr, err := s.prRepo.GetByID(ctx, prID)
+	// Nobody could approve, a reviewer has to be added first
+	if settings.NeedsApprovals() && len(pr.AssignedReviewers) == 0 {
+		return fmt.Errorf("%w: merge_approvals is %s, add a reviewer first", apperrors.ErrNoApprovers, settings.MergeApprovals)
+	}
+
+	if missing := settings.MissingApprovals(pr); missing > 0 {
+		return fmt.Errorf("%w: %d more approvals needed (%s)", apperrors.ErrNotApproved, missing, settings.MergeApprovals)
+	}
+
+	return nil
+}
+
+// SubmitReview records the review of an assigned reviewer, a later review replaces the earlier one
+func (s *PRService) SubmitReview(ctx context.Context, prID, userID string, state models.ReviewState) (*models.PullRequest, error) {
+
+	if !state.IsSubmitted() {
+		return nil, fmt.Errorf("%w: state must be APPROVED, CHANGES_REQUESTED or COMMENTED", apperrors.ErrInvalidReviewState)
+	}
+
+	pr, err := s.getOpenPR(ctx, prID)
 
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	// Check if pr is merged
-	if pr.IsMerged() {
-		return nil, "", apperrors.ErrPRMerged
+	if !pr.HasReviewer(userID) {
+		return nil, apperrors.ErrNotAssigned
+	}
+
+	now := s.clock.Now()
+
+	if err := s.prRepo.SubmitReview(ctx, prID, userID, state, now); err != nil {
+		return nil, err
+	}
+
+	pr.SetReview(userID, state, &now)
+
+	return pr, nil
+}
+
+func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error) {
+
+	// Get pr, reviewers change only while it is OPEN
+	pr, err := s.getOpenPR(ctx, prID)
+
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Check if old reviewer is assigned
@@ -138,137 +387,451 @@ func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldUserID string
 		return nil, "", err
 	}
 
+	settings, err := s.teamRepo.GetSettings(ctx, oldReviewer.TeamName)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, models.TraceActionReassign)
+	trace.setStrategy(settings.ReviewerStrategy)
+	trace.replaces(oldUserID)
+	ctx = withCapState(ctx, settings)
+
 	// Get candidates from the same team (excluding author and current reviewers)
-	excludeIDs := append(pr.AssignedReviewers, pr.AuthorID)
-	candidates, err := s.getCandidatesExcluding(ctx, oldReviewer.TeamName, excludeIDs)
+	candidates, err := s.getCandidatesExcluding(ctx, oldReviewer.TeamName, pr)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Keep required tags covered
+	candidates, err = s.narrowByLostTags(ctx, pr, oldUserID, candidates)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Select new reviewer with the team's strategy
+	selected, err := s.selectorFor(settings, pr.AuthorID).Select(ctx, candidates, 1)
 
 	if err != nil {
 		return nil, "", err
 	}
 
-	if len(candidates) == 0 {
+	// A replacement for a fallback reviewer stays a fallback reviewer
+	fromFallback := pr.IsFallbackReviewer(oldUserID)
+
+	// Nobody left in the team, try the fallback pools
+	if len(selected) == 0 && settings.UnderstaffedPolicy == models.UnderstaffedFallback {
+
+		selected, err = s.selectFromFallbacks(ctx, settings, pr, 1)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		fromFallback = true
+	}
+
+	// A replacement cannot wait in a queue
+	if len(selected) == 0 && capReached(ctx) {
+		return nil, "", apperrors.ErrReviewerCapReached
+	}
+
+	if len(selected) == 0 {
 		return nil, "", apperrors.ErrNoCandidate
 	}
 
-	// Select new reviewer with load balancing
-	newReviewer := s.selectBestCandidate(ctx, candidates)
+	newReviewer := selected[0]
+	before := pr.Snapshot()
 
 	// Replace reviewer
 	pr.RemoveReviewer(oldUserID)
-	pr.AddReviewer(newReviewer.UserID)
+
+	if fromFallback {
+		pr.AddFallbackReviewer(newReviewer.UserID)
+	} else {
+		pr.AddReviewer(newReviewer.UserID)
+	}
+
+	s.recordReassigned(pr, models.TraceActionReassign, oldUserID, newReviewer.UserID)
+	s.recordPRAudit(ctx, pr, models.AuditReviewerReassigned, before)
 
 	// Update pr
 	if err := s.prRepo.Update(ctx, pr); err != nil {
 		return nil, "", err
 	}
 
+	s.saveTrace(ctx, trace, newReviewer.UserID)
+	notifyOutbox(s.outbox)
+	s.NotifyStaffing()
+
 	return pr, newReviewer.UserID, nil
 }
 
-// selects up to 2 reviewers from team
-// using load balancing
-func (s *PRService) selectReviewers(ctx context.Context, teamName, excludeUserID string) ([]*models.User, error) {
+// ReassignReviewerTo replaces a reviewer with the given user instead of letting
+// the strategy pick. The new reviewer must be an active member of the old reviewer's
+// team, not the author nor never paired with the author by the author's team and under
+// the open review cap of the old reviewer's team. The explicit choice is not checked
+// against tags or working hours
+func (s *PRService) ReassignReviewerTo(ctx context.Context, prID, oldUserID, newUserID string) (*models.PullRequest, error) {
 
-	candidates, err := s.userRepo.GetActiveByTeam(ctx, teamName, excludeUserID)
+	pr, err := s.getOpenPR(ctx, prID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if len(candidates) == 0 {
-		return []*models.User{}, nil
+	if !pr.HasReviewer(oldUserID) {
+		return nil, apperrors.ErrNotAssigned
 	}
 
-	// Get current load for all candidates
-	userIDs := make([]string, len(candidates))
+	oldReviewer, err := s.userRepo.GetByID(ctx, oldUserID)
 
-	for i, u := range candidates {
-		userIDs[i] = u.UserID
+	if err != nil {
+		return nil, err
 	}
 
-	load, err := s.userRepo.GetReviewerLoad(ctx, userIDs)
+	settings, err := s.teamRepo.GetSettings(ctx, oldReviewer.TeamName)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort by load (ascending) and shuffle users with same load
-	sort.Slice(candidates, func(i, j int) bool {
-		loadI := load[candidates[i].UserID]
-		loadJ := load[candidates[j].UserID]
-		if loadI == loadJ {
-			return s.rand.Intn(2) == 0
-		}
-		return loadI < loadJ
-	})
+	authorSettings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newReviewer, err := s.checkReviewerCandidate(ctx, pr, authorSettings, newUserID)
+
+	if err != nil {
+		return nil, err
+	}
 
-	// Select up to 2 reviewers
-	count := min(len(candidates), 2)
+	if newReviewer.TeamName != oldReviewer.TeamName {
+		return nil, fmt.Errorf("%w: %s is not a member of team %s", apperrors.ErrInvalidReviewer, newUserID, oldReviewer.TeamName)
+	}
+
+	if err := s.checkReviewCap(ctx, settings, newReviewer); err != nil {
+		return nil, err
+	}
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, models.TraceActionManualReassign)
+	trace.replaces(oldUserID)
+
+	before := pr.Snapshot()
+	fromFallback := pr.IsFallbackReviewer(oldUserID)
+	pr.RemoveReviewer(oldUserID)
+
+	if fromFallback {
+		pr.AddFallbackReviewer(newUserID)
+	} else {
+		pr.AddReviewer(newUserID)
+	}
 
-	return candidates[:count], nil
+	s.recordReassigned(pr, models.TraceActionManualReassign, oldUserID, newUserID)
+	s.recordPRAudit(ctx, pr, models.AuditReviewerReassigned, before)
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	s.saveTrace(ctx, trace, newUserID)
+	notifyOutbox(s.outbox)
+	s.NotifyStaffing()
+
+	return pr, nil
 }
 
-// gets active users from team excluding specified IDs
-func (s *PRService) getCandidatesExcluding(ctx context.Context, teamName string, excludeIDs []string) ([]*models.User, error) {
+// AddReviewer puts a chosen user on the PR on top of the assigned ones,
+// as long as the PR has fewer reviewers than the author's team requires
+// and the user is under the open review cap of the author's team
+func (s *PRService) AddReviewer(ctx context.Context, prID, userID string) (*models.PullRequest, error) {
 
-	allCandidates, err := s.userRepo.GetActiveByTeam(ctx, teamName, "")
+	pr, err := s.getOpenPR(ctx, prID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Filter out excluded IDs
-	excludeMap := make(map[string]bool)
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, err
+	}
 
-	for _, id := range excludeIDs {
-		excludeMap[id] = true
+	if len(pr.AssignedReviewers) >= settings.ReviewerCount {
+		return nil, apperrors.ErrReviewerLimit
 	}
 
-	filtered := []*models.User{}
+	user, err := s.checkReviewerCandidate(ctx, pr, settings, userID)
 
-	for _, candidate := range allCandidates {
-		if !excludeMap[candidate.UserID] {
-			filtered = append(filtered, candidate)
-		}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkReviewCap(ctx, settings, user); err != nil {
+		return nil, err
+	}
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, models.TraceActionManualAdd)
+
+	before := pr.Snapshot()
+	pr.AddReviewer(userID)
+	pr.Understaffed = len(pr.AssignedReviewers) < settings.ReviewerCount
+	pr.AwaitingReviewer = pr.AwaitingReviewer && pr.Understaffed
+	s.recordAssigned(pr, models.TraceActionManualAdd, userID)
+	s.recordPRAudit(ctx, pr, models.AuditReviewerAdded, before)
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	s.saveTrace(ctx, trace, userID)
+	notifyOutbox(s.outbox)
+
+	return pr, nil
+}
+
+// RemoveReviewer takes a reviewer off the PR without a replacement.
+// Going below the team's reviewer count is refused with the FAIL policy,
+// otherwise the PR is marked as understaffed
+func (s *PRService) RemoveReviewer(ctx context.Context, prID, userID string) (*models.PullRequest, error) {
+
+	pr, err := s.getOpenPR(ctx, prID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !pr.HasReviewer(userID) {
+		return nil, apperrors.ErrNotAssigned
+	}
+
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := len(pr.AssignedReviewers) - 1
+
+	if remaining < settings.ReviewerCount && settings.UnderstaffedPolicy == models.UnderstaffedFail {
+		return nil, apperrors.ErrNotEnoughReviewers
+	}
+
+	before := pr.Snapshot()
+	pr.RemoveReviewer(userID)
+	pr.Understaffed = remaining < settings.ReviewerCount
+	s.recordPRAudit(ctx, pr, models.AuditReviewerRemoved, before)
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	s.NotifyStaffing()
+
+	return pr, nil
+}
+
+// ExplainAssignment returns the decision traces of the PR's reviewer changes, oldest first
+func (s *PRService) ExplainAssignment(ctx context.Context, prID string) ([]*models.AssignmentTrace, error) {
+
+	exists, err := s.prRepo.Exists(ctx, prID)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return filtered, nil
+	if !exists {
+		return nil, apperrors.ErrPRNotFound
+	}
+
+	if s.traceRepo == nil {
+		return []*models.AssignmentTrace{}, nil
+	}
+
+	return s.traceRepo.ListByPR(ctx, prID)
 }
 
-// selects the candidate with lowest load
-func (s *PRService) selectBestCandidate(ctx context.Context, candidates []*models.User) *models.User {
+// gets a PR whose reviewers may still change
+func (s *PRService) getOpenPR(ctx context.Context, prID string) (*models.PullRequest, error) {
 
-	if len(candidates) == 0 {
-		return nil
+	pr, err := s.prRepo.GetByID(ctx, prID)
+
+	if err != nil {
+		return nil, err
 	}
 
-	userIDs := make([]string, len(candidates))
+	if pr.IsMerged() {
+		return nil, apperrors.ErrPRMerged
+	}
 
-	for i, u := range candidates {
-		userIDs[i] = u.UserID
+	if !pr.IsOpen() {
+		return nil, fmt.Errorf("%w: PR is %s", apperrors.ErrPRNotOpen, pr.Status)
 	}
 
-	load, err := s.userRepo.GetReviewerLoad(ctx, userIDs)
+	return pr, nil
+}
+
+// gets the settings of the PR author's team, they define the reviewer count
+func (s *PRService) getAuthorSettings(ctx context.Context, pr *models.PullRequest) (*models.TeamSettings, error) {
+
+	author, err := s.userRepo.GetByID(ctx, pr.AuthorID)
 
 	if err != nil {
-		// Fallback to random selection
-		return candidates[s.rand.Intn(len(candidates))]
+		return nil, err
+	}
+
+	return s.teamRepo.GetSettings(ctx, author.TeamName)
+}
+
+// checks that a manually chosen user can review the PR, never pair rules
+// of the author's team are hard constraints for manual choices too
+func (s *PRService) checkReviewerCandidate(ctx context.Context, pr *models.PullRequest, settings *models.TeamSettings, userID string) (*models.User, error) {
+
+	if userID == pr.AuthorID {
+		return nil, fmt.Errorf("%w: author cannot review own PR", apperrors.ErrInvalidReviewer)
+	}
+
+	if settings.NeverPaired(pr.AuthorID, userID) {
+		return nil, fmt.Errorf("%w: %s must never review PRs of %s", apperrors.ErrInvalidReviewer, userID, pr.AuthorID)
+	}
+
+	if pr.HasReviewer(userID) {
+		return nil, apperrors.ErrAlreadyAssigned
 	}
 
-	// Find candidates with minimum load
-	minLoad := load[candidates[0].UserID]
-	minLoadCandidates := []*models.User{}
+	user, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, fmt.Errorf("%w: %s is not active", apperrors.ErrInvalidReviewer, userID)
+	}
+
+	return user, nil
+}
 
-	for _, candidate := range candidates {
-		candidateLoad := load[candidate.UserID]
-		if candidateLoad < minLoad {
-			minLoad = candidateLoad
-			minLoadCandidates = []*models.User{candidate}
-		} else if candidateLoad == minLoad {
-			minLoadCandidates = append(minLoadCandidates, candidate)
+// ReassignmentResult is the outcome of handing one PR over to another reviewer
+type ReassignmentResult struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReplacedBy    string `json:"replaced_by,omitempty"`
+	ErrorCode     string `json:"error_code,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// ReassignmentReport lists per PR results of ReassignOpenReviews.
+// Every PR is reassigned in its own transaction, so Partial means that
+// the failed PRs still have the user as a reviewer while the others were handed over
+type ReassignmentReport struct {
+	UserID     string               `json:"user_id"`
+	Results    []ReassignmentResult `json:"results"`
+	Reassigned int                  `json:"reassigned"`
+	Failed     int                  `json:"failed"`
+	Partial    bool                 `json:"partial"`
+}
+
+// ReassignOpenReviews hands every OPEN review of the user over to a replacement
+// picked the same way as ReassignReviewer does. Failures of single PRs are
+// reported and do not stop the others. When the request itself is gone the report
+// of the PRs handed over so far comes back with the error, the rest are reported as failed
+func (s *PRService) ReassignOpenReviews(ctx context.Context, userID string) (*ReassignmentReport, error) {
+
+	prs, err := s.prRepo.GetByReviewer(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReassignmentReport{
+		UserID:  userID,
+		Results: []ReassignmentResult{},
+	}
+
+	var stopped error
+
+	for _, pr := range prs {
+
+		if pr.Status != models.PRStatusOpen {
+			continue
+		}
+
+		result := ReassignmentResult{PullRequestID: pr.PullRequestID}
+
+		// The request itself is gone, the remaining PRs keep the user
+		if stopped != nil {
+			report.Results = append(report.Results, failedReassignment(result, stopped))
+			report.Failed++
+			continue
+		}
+
+		_, replacedBy, err := s.ReassignReviewer(ctx, pr.PullRequestID, userID)
+
+		if err != nil {
+
+			if ctx.Err() != nil {
+				stopped = ctx.Err()
+				err = stopped
+			}
+
+			result = failedReassignment(result, err)
+			report.Failed++
+		} else {
+			result.ReplacedBy = replacedBy
+			report.Reassigned++
 		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	report.Partial = report.Failed > 0
+
+	return report, stopped
+}
+
+func failedReassignment(result ReassignmentResult, err error) ReassignmentResult {
+	result.ErrorCode = reassignmentErrorCode(err)
+	result.Message = err.Error()
+	return result
+}
+
+// maps domain errors of a reassignment to their API codes, anything else is internal
+func reassignmentErrorCode(err error) string {
+
+	switch {
+	case errors.Is(err, apperrors.ErrNoCandidate), errors.Is(err, apperrors.ErrTagNotCovered),
+		errors.Is(err, apperrors.ErrPRMerged), errors.Is(err, apperrors.ErrNotAssigned),
+		errors.Is(err, apperrors.ErrPRNotFound), errors.Is(err, apperrors.ErrUserNotFound),
+		errors.Is(err, apperrors.ErrTeamNotFound), errors.Is(err, apperrors.ErrReviewerCapReached),
+		errors.Is(err, apperrors.ErrPRNotOpen):
+		return string(apperrors.GetErrorCode(err))
+	default:
+		return "INTERNAL_ERROR"
 	}
+}
+
+// resolves the selector for team settings and the PR author: the team's strategy
+// (unknown strategies fall back to the default one), traced, wrapped by its working hours
+// policy, review caps and never pair rules. The loads of the candidates are read once
+// per call, adjusted by the fairness window and author spread when they are on
+func (s *PRService) selectorFor(settings *models.TeamSettings, authorID string) ReviewerSelector {
+
+	strategy := settings.ReviewerStrategy
+
+	if _, ok := s.selectors[strategy]; !ok {
+		strategy = models.DefaultReviewerStrategy
+	}
+
+	var selector ReviewerSelector = &tracingSelector{inner: s.selectors[strategy], userRepo: s.userRepo, usesLoad: strategy.UsesLoad()}
+	selector = newWorkingHoursSelector(selector, s.clock, settings)
+	selector = newReviewCapSelector(selector, s.userRepo, settings)
+	selector = newLoadSnapshotSelector(selector, s.userRepo, settings, strategy, authorID, s.clock.Now())
 
-	// Random selection among candidates with minimum load
-	return minLoadCandidates[s.rand.Intn(len(minLoadCandidates))]
+	return newNeverPairSelector(selector, settings, authorID)
 }
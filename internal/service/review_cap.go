@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Caps on open reviews.
+A candidate's cap is its own max_open_reviews or, without one, the max_open_reviews
+of the team whose settings drive the selection. Candidates at their cap are skipped,
+open reviews come from the load snapshot of the selection.
+
+When the candidates under the cap cannot fill the slots the team's cap_policy decides:
+
+- QUEUE  - the slots stay empty, the PR is marked as awaiting a reviewer
+- EXCEED - capped candidates are picked anyway, with a warning in the log
+- FAIL   - the assignment is rejected with REVIEWER_CAP_REACHED
+
+A reviewer chosen by hand (AddReviewer, ReassignReviewerTo) cannot wait in a queue:
+at the cap the choice is rejected with REVIEWER_CAP_REACHED unless the policy is EXCEED.
+
+*/
+
+type capContextKey struct{}
+
+// capState remembers whether a QUEUE policy left slots empty during one assignment
+type capState struct {
+	reached bool
+}
+
+// only the QUEUE policy needs the state, the context is left as is otherwise
+func withCapState(ctx context.Context, settings *models.TeamSettings) context.Context {
+
+	if settings.CapPolicy != models.CapQueue {
+		return ctx
+	}
+
+	return context.WithValue(ctx, capContextKey{}, &capState{})
+}
+
+// reports whether slots were left empty because of caps
+func capReached(ctx context.Context) bool {
+	state, _ := ctx.Value(capContextKey{}).(*capState)
+	return state != nil && state.reached
+}
+
+func markCapReached(ctx context.Context) {
+	if state, _ := ctx.Value(capContextKey{}).(*capState); state != nil {
+		state.reached = true
+	}
+}
+
+// reviewCapSelector keeps candidates at their cap out of the selection
+type reviewCapSelector struct {
+	inner    ReviewerSelector
+	userRepo repository.UserRepository
+	settings *models.TeamSettings
+}
+
+func newReviewCapSelector(inner ReviewerSelector, userRepo repository.UserRepository, settings *models.TeamSettings) ReviewerSelector {
+	return &reviewCapSelector{inner: inner, userRepo: userRepo, settings: settings}
+}
+
+func (s *reviewCapSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	capped := slices.DeleteFunc(slices.Clone(candidates), func(u *models.User) bool {
+		return s.settings.ReviewCap(u) == 0
+	})
+
+	if len(capped) == 0 || count <= 0 {
+		return s.inner.Select(ctx, candidates, count)
+	}
+
+	open, err := openReviews(ctx, s.userRepo, capped)
+
+	if err != nil {
+		return nil, err
+	}
+
+	atCap := []*models.User{}
+
+	underCap := slices.DeleteFunc(slices.Clone(candidates), func(u *models.User) bool {
+
+		limit := s.settings.ReviewCap(u)
+
+		if limit > 0 && open[u.UserID] >= limit {
+			atCap = append(atCap, u)
+			return true
+		}
+
+		return false
+	})
+
+	selected, err := s.inner.Select(ctx, underCap, count)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(selected) < count && len(atCap) > 0 {
+
+		switch s.settings.CapPolicy {
+		case models.CapFail:
+			return nil, apperrors.ErrReviewerCapReached
+		case models.CapQueue:
+			markCapReached(ctx)
+		default:
+			extra, err := s.inner.Select(ctx, atCap, count-len(selected))
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, reviewer := range extra {
+				log.Warn().Str("user_id", reviewer.UserID).Str("team_name", s.settings.TeamName).
+					Int("open_reviews", open[reviewer.UserID]).Int("cap", s.settings.ReviewCap(reviewer)).
+					Msg("Reviewer assigned over the open review cap")
+			}
+
+			selected = append(selected, extra...)
+		}
+	}
+
+	for _, candidate := range atCap {
+		if !slices.Contains(selected, candidate) {
+			traceFrom(ctx).exclude(candidate.UserID, models.ExcludedAtCap)
+		}
+	}
+
+	return selected, nil
+}
+
+// checkReviewCap rejects a reviewer chosen by hand at their cap, EXCEED lets the choice through with a warning
+func (s *PRService) checkReviewCap(ctx context.Context, settings *models.TeamSettings, user *models.User) error {
+
+	limit := settings.ReviewCap(user)
+
+	if limit == 0 {
+		return nil
+	}
+
+	open, err := openReviews(ctx, s.userRepo, []*models.User{user})
+
+	if err != nil {
+		return err
+	}
+
+	if open[user.UserID] < limit {
+		return nil
+	}
+
+	if settings.CapPolicy != models.CapExceed {
+		return apperrors.ErrReviewerCapReached
+	}
+
+	log.Warn().Str("user_id", user.UserID).Str("team_name", settings.TeamName).
+		Int("open_reviews", open[user.UserID]).Int("cap", limit).
+		Msg("Reviewer assigned over the open review cap")
+
+	return nil
+}
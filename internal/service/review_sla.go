@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Review SLA.
+A team may give reviewers of its PRs review_sla_hours to submit a review, counted
+from the assignment in calendar hours or, with sla_business_hours, in the working
+hours of the reviewer (reviewers without a schedule count every hour).
+SLAWorker checks PENDING reviews of OPEN PRs on a timer. A late review is recorded
+as a breach once per assignment, then the team's sla_escalation applies:
+
+- NOTIFY       - nothing else happens
+- ADD_REVIEWER - one more reviewer is picked by the team's strategy, the late one stays.
+                 A PR gets one escalation reviewer at most, later breaches of it are only published
+- REASSIGN     - the late reviewer is replaced the way ReassignReviewer does
+
+A review.sla_breached event with the breach and its outcome is published in every case.
+A failed escalation is kept in the breach and is not retried.
+
+*/
+
+// errAlreadyEscalated keeps ADD_REVIEWER from piling reviewers on a PR whose escalation reviewers are late too
+var errAlreadyEscalated = errors.New("the PR already has an escalation reviewer")
+
+// ReviewEscalator changes the reviewers of a PR whose review is late
+type ReviewEscalator interface {
+	ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error)
+	AddEscalationReviewer(ctx context.Context, prID, lateUserID string) (*models.PullRequest, string, error)
+}
+
+type SLAService struct {
+	slaRepo   repository.SLARepository
+	userRepo  repository.UserRepository
+	teamRepo  repository.TeamRepository
+	escalator ReviewEscalator
+	publisher EventPublisher
+	clock     Clock
+}
+
+func NewSLAService(slaRepo repository.SLARepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository,
+	escalator ReviewEscalator, publisher EventPublisher, clock Clock) *SLAService {
+
+	return &SLAService{
+		slaRepo:   slaRepo,
+		userRepo:  userRepo,
+		teamRepo:  teamRepo,
+		escalator: escalator,
+		publisher: publisher,
+		clock:     clock,
+	}
+}
+
+// CheckSLA records and escalates the reviews that are late now and returns how many
+// breaches were found. Failures of single reviews are logged and do not stop the others
+func (s *SLAService) CheckSLA(ctx context.Context) (int, error) {
+
+	now := s.clock.Now()
+	reviews, err := s.slaRepo.ListAwaitedReviews(ctx, now)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Reviews of one check share team settings and reviewers
+	settingsByTeam := make(map[string]*models.TeamSettings)
+	reviewers := make(map[string]*models.User)
+	breached := 0
+
+	for _, review := range reviews {
+
+		found, err := s.checkReview(ctx, review, settingsByTeam, reviewers)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return breached, ctx.Err()
+			}
+
+			log.Error().Err(err).Str("pull_request_id", review.PullRequestID).Str("user_id", review.UserID).
+				Msg("Failed to check review SLA")
+			continue
+		}
+
+		if found {
+			breached++
+		}
+	}
+
+	return breached, nil
+}
+
+// ListBreaches returns recorded breaches, latest first
+func (s *SLAService) ListBreaches(ctx context.Context, filter models.SLABreachFilter) ([]*models.SLABreach, error) {
+
+	if filter.TeamName != "" {
+
+		exists, err := s.teamRepo.Exists(ctx, filter.TeamName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, apperrors.ErrTeamNotFound
+		}
+	}
+
+	return s.slaRepo.ListBreaches(ctx, filter)
+}
+
+// records and escalates one review if its deadline has passed, reports whether it had
+func (s *SLAService) checkReview(ctx context.Context, review *models.AwaitedReview,
+	settingsByTeam map[string]*models.TeamSettings, reviewers map[string]*models.User) (bool, error) {
+
+	settings, ok := settingsByTeam[review.TeamName]
+
+	if !ok {
+
+		var err error
+		settings, err = s.teamRepo.GetSettings(ctx, review.TeamName)
+
+		if err != nil {
+			return false, err
+		}
+
+		settingsByTeam[review.TeamName] = settings
+	}
+
+	reviewer, ok := reviewers[review.UserID]
+
+	if !ok {
+
+		var err error
+		reviewer, err = s.userRepo.GetByID(ctx, review.UserID)
+
+		if err != nil {
+			return false, err
+		}
+
+		reviewers[review.UserID] = reviewer
+	}
+
+	now := s.clock.Now()
+	deadline, ok := settings.ReviewDeadline(reviewer, review.AssignedAt)
+
+	if !ok || now.Before(deadline) {
+		return false, nil
+	}
+
+	breach := &models.SLABreach{
+		PullRequestID: review.PullRequestID,
+		UserID:        review.UserID,
+		TeamName:      review.TeamName,
+		AssignedAt:    review.AssignedAt,
+		Deadline:      deadline,
+		DetectedAt:    now,
+		Escalation:    settings.SLAEscalation,
+	}
+
+	// Claimed before escalating, so a breach is never escalated twice
+	created, err := s.slaRepo.CreateBreach(ctx, breach)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !created {
+		return false, nil
+	}
+
+	escalatedTo, err := s.escalate(ctx, breach)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+
+		breach.EscalationError = err.Error()
+
+		if !errors.Is(err, errAlreadyEscalated) {
+			log.Warn().Err(err).Str("pull_request_id", breach.PullRequestID).Str("user_id", breach.UserID).
+				Str("escalation", string(breach.Escalation)).Msg("Review SLA escalation failed")
+		}
+	}
+
+	breach.EscalatedTo = escalatedTo
+
+	if err := s.slaRepo.ResolveBreach(ctx, breach.BreachID, breach.EscalatedTo, breach.EscalationError); err != nil {
+		return true, err
+	}
+
+	event := models.Event{Type: models.EventReviewSLABreached, OccurredAt: now, Data: breach}
+
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		log.Error().Err(err).Int64("breach_id", breach.BreachID).Msg("Failed to publish review SLA breach")
+	}
+
+	return true, nil
+}
+
+// applies the escalation of the breach, returns the reviewer it brought in
+func (s *SLAService) escalate(ctx context.Context, breach *models.SLABreach) (string, error) {
+
+	switch breach.Escalation {
+	case models.SLAEscalationAddReviewer:
+
+		escalated, err := s.hasEscalationReviewer(ctx, breach)
+
+		if err != nil {
+			return "", err
+		}
+
+		if escalated {
+			return "", errAlreadyEscalated
+		}
+
+		_, added, err := s.escalator.AddEscalationReviewer(ctx, breach.PullRequestID, breach.UserID)
+		return added, err
+	case models.SLAEscalationReassign:
+		_, replacedBy, err := s.escalator.ReassignReviewer(ctx, breach.PullRequestID, breach.UserID)
+		return replacedBy, err
+	case models.SLAEscalationNotify:
+		return "", nil
+	}
+
+	return "", fmt.Errorf("unknown escalation %q", breach.Escalation)
+}
+
+// reports whether an earlier breach of the PR has already brought in a reviewer by ADD_REVIEWER
+func (s *SLAService) hasEscalationReviewer(ctx context.Context, breach *models.SLABreach) (bool, error) {
+
+	breaches, err := s.slaRepo.ListBreaches(ctx, models.SLABreachFilter{PullRequestID: breach.PullRequestID})
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, earlier := range breaches {
+
+		if earlier.BreachID != breach.BreachID && earlier.Escalation == models.SLAEscalationAddReviewer && earlier.EscalatedTo != "" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AddEscalationReviewer puts one more reviewer on an OPEN PR whose review by lateUserID
+// is overdue. The reviewer is picked by the strategy of the author's team among its
+// members, the fallback teams are tried with the FALLBACK policy. The reviewer count
+// of the team does not limit it, the late reviewer stays on the PR
+func (s *PRService) AddEscalationReviewer(ctx context.Context, prID, lateUserID string) (*models.PullRequest, string, error) {
+
+	pr, err := s.getOpenPR(ctx, prID)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !pr.HasReviewer(lateUserID) {
+		return nil, "", apperrors.ErrNotAssigned
+	}
+
+	settings, err := s.getAuthorSettings(ctx, pr)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, trace := s.startTrace(ctx, pr.PullRequestID, models.TraceActionEscalate)
+	trace.setStrategy(settings.ReviewerStrategy)
+	ctx = withCapState(ctx, settings)
+
+	candidates, err := s.getCandidatesExcluding(ctx, settings.TeamName, pr)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	selected, err := s.selectorFor(settings, pr.AuthorID).Select(ctx, candidates, 1)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	fromFallback := false
+
+	if len(selected) == 0 && settings.UnderstaffedPolicy == models.UnderstaffedFallback {
+
+		selected, err = s.selectFromFallbacks(ctx, settings, pr, 1)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		fromFallback = true
+	}
+
+	if len(selected) == 0 && capReached(ctx) {
+		return nil, "", apperrors.ErrReviewerCapReached
+	}
+
+	if len(selected) == 0 {
+		return nil, "", apperrors.ErrNoCandidate
+	}
+
+	added := selected[0].UserID
+	before := pr.Snapshot()
+
+	if fromFallback {
+		pr.AddFallbackReviewer(added)
+	} else {
+		pr.AddReviewer(added)
+	}
+
+	s.recordAssigned(pr, models.TraceActionEscalate, added)
+	s.recordPRAudit(ctx, pr, models.AuditReviewerAdded, before)
+
+	if err := s.prRepo.Update(ctx, pr); err != nil {
+		return nil, "", err
+	}
+
+	s.saveTrace(ctx, trace, added)
+	notifyOutbox(s.outbox)
+
+	return pr, added, nil
+}
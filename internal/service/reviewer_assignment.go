@@ -0,0 +1,541 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+Reviewer assignment pipeline of PRService.
+Every step fills only the slots the previous ones left free:
+
+1. code owners      - one owner for every ownership rule matched by changed files
+2. required tags    - every required tag gets at least one reviewer having it,
+                      this step may go beyond the team's reviewer count
+3. team members     - the team's strategy fills the remaining slots
+4. fallback teams   - with FALLBACK policy, in their order
+5. label rules      - every label rule matched by the PR's labels gets a reviewer
+                      of the rule's team unless one is assigned already,
+                      on top of the team's reviewer count
+
+A label rule left without a reviewer counts as a missing reviewer.
+Then the understaffed policy decides whether a short PR fails or is marked,
+unless slots stayed empty because of review caps with the QUEUE cap policy:
+such a PR is marked as awaiting a reviewer instead.
+
+*/
+
+// assigns the number of reviewers required by team settings,
+// applies the understaffed policy when the team has too few candidates
+func (s *PRService) assignReviewers(ctx context.Context, pr *models.PullRequest, settings *models.TeamSettings) error {
+
+	if len(pr.ChangedFiles) > 0 {
+		if err := s.assignOwners(ctx, pr, settings); err != nil {
+			return err
+		}
+	}
+
+	missing := settings.ReviewerCount - len(pr.AssignedReviewers)
+
+	if missing > 0 || len(pr.RequiredTags) > 0 {
+
+		now := s.clock.Now()
+		candidates, err := s.userRepo.GetActiveByTeam(ctx, settings.TeamName, pr.AuthorID, now)
+
+		if err != nil {
+			return err
+		}
+
+		traceFrom(ctx).enter(models.StepTeam)
+
+		if err := s.traceTeamExclusions(ctx, settings.TeamName, pr.AuthorID, now); err != nil {
+			return err
+		}
+
+		if err := s.assignTagged(ctx, pr, settings, candidates); err != nil {
+			return err
+		}
+
+		missing = settings.ReviewerCount - len(pr.AssignedReviewers)
+		traceFrom(ctx).enter(models.StepTeam)
+
+		reviewers, err := s.selectReviewers(ctx, settings, pr, candidates, missing)
+
+		if err != nil {
+			return err
+		}
+
+		for _, reviewer := range reviewers {
+			pr.AddReviewer(reviewer.UserID)
+		}
+
+		missing -= len(reviewers)
+	}
+
+	// Take missing reviewers from the fallback teams
+	if missing > 0 && settings.UnderstaffedPolicy == models.UnderstaffedFallback {
+
+		extra, err := s.selectFromFallbacks(ctx, settings, pr, missing)
+
+		if err != nil {
+			return err
+		}
+
+		for _, reviewer := range extra {
+			pr.AddFallbackReviewer(reviewer.UserID)
+		}
+
+		missing -= len(extra)
+	}
+
+	unfilled, err := s.assignLabelled(ctx, pr, settings)
+
+	if err != nil {
+		return err
+	}
+
+	// Required tags may have gone beyond the count, that does not cover label rules
+	missing = max(missing, 0) + unfilled
+
+	// Candidates at their cap may free up later, with QUEUE the PR waits for them
+	if missing > 0 && capReached(ctx) {
+		pr.AwaitingReviewer = true
+		return nil
+	}
+
+	if missing > 0 {
+		if settings.UnderstaffedPolicy == models.UnderstaffedFail {
+			return apperrors.ErrNotEnoughReviewers
+		}
+
+		// ALLOW, or FALLBACK that could not fill every slot
+		pr.Understaffed = true
+	}
+
+	return nil
+}
+
+// gives every label rule matched by the PR a reviewer of the rule's team picked by
+// the strategy of the author's team, unless a member of that team reviews the PR already.
+// Returns the number of rules left without a reviewer
+func (s *PRService) assignLabelled(ctx context.Context, pr *models.PullRequest, settings *models.TeamSettings) (int, error) {
+
+	rules := settings.LabelRulesFor(pr)
+
+	if len(rules) == 0 {
+		return 0, nil
+	}
+
+	covered := make(map[string]bool)
+
+	for _, reviewerID := range pr.AssignedReviewers {
+
+		reviewer, err := s.userRepo.GetByID(ctx, reviewerID)
+
+		if err != nil {
+			return 0, err
+		}
+
+		covered[reviewer.TeamName] = true
+	}
+
+	selector := s.selectorFor(settings, pr.AuthorID)
+	traceFrom(ctx).enter(models.StepLabels)
+	unfilled := 0
+
+	for _, rule := range rules {
+
+		if covered[rule.Team] {
+			continue
+		}
+
+		candidates, err := s.getCandidatesExcluding(ctx, rule.Team, pr)
+
+		if err != nil {
+			return 0, err
+		}
+
+		picked, err := selector.Select(ctx, candidates, 1)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if len(picked) == 0 {
+			unfilled++
+			continue
+		}
+
+		pr.AddReviewer(picked[0].UserID)
+		covered[rule.Team] = true
+	}
+
+	return unfilled, nil
+}
+
+// selects up to count reviewers out of team candidates, skipping assigned reviewers,
+// using the team's selection strategy
+func (s *PRService) selectReviewers(ctx context.Context, settings *models.TeamSettings, pr *models.PullRequest, candidates []*models.User, count int) ([]*models.User, error) {
+
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(u *models.User) bool {
+
+		if pr.HasReviewer(u.UserID) {
+			traceFrom(ctx).exclude(u.UserID, models.ExcludedAlreadyAssigned)
+			return true
+		}
+
+		return false
+	})
+
+	return s.selectorFor(settings, pr.AuthorID).Select(ctx, candidates, count)
+}
+
+// makes sure every required tag is covered by an assigned reviewer.
+// Tags already covered (e.g. by code owners) are skipped, for every other tag
+// the strategy picks among candidates having it, preferring the ones covering
+// most of the uncovered tags so that the PR gets as few extra reviewers as possible
+func (s *PRService) assignTagged(ctx context.Context, pr *models.PullRequest, settings *models.TeamSettings, candidates []*models.User) error {
+
+	if len(pr.RequiredTags) == 0 {
+		return nil
+	}
+
+	traceFrom(ctx).enter(models.StepTags)
+	covered := make(map[string]bool)
+
+	for _, candidate := range candidates {
+		if pr.HasReviewer(candidate.UserID) {
+			markCovered(covered, candidate, pr.RequiredTags)
+		}
+	}
+
+	// Reviewers assigned from outside the team (owners) can cover tags too
+	for _, reviewerID := range pr.AssignedReviewers {
+
+		if slices.ContainsFunc(candidates, func(u *models.User) bool { return u.UserID == reviewerID }) {
+			continue
+		}
+
+		reviewer, err := s.userRepo.GetByID(ctx, reviewerID)
+
+		if err != nil {
+			return err
+		}
+
+		markCovered(covered, reviewer, pr.RequiredTags)
+	}
+
+	selector := s.selectorFor(settings, pr.AuthorID)
+
+	for _, tag := range pr.RequiredTags {
+
+		if covered[tag] {
+			continue
+		}
+
+		best := []*models.User{}
+		bestCoverage := 0
+
+		for _, candidate := range candidates {
+
+			if pr.HasReviewer(candidate.UserID) || !candidate.HasTag(tag) {
+				continue
+			}
+
+			coverage := countUncovered(covered, candidate, pr.RequiredTags)
+
+			switch {
+			case coverage > bestCoverage:
+				best = []*models.User{candidate}
+				bestCoverage = coverage
+			case coverage == bestCoverage:
+				best = append(best, candidate)
+			}
+		}
+
+		picked, err := selector.Select(ctx, best, 1)
+
+		if err != nil {
+			return err
+		}
+
+		// Everyone having the tag is at the cap, the PR waits
+		if len(picked) == 0 && len(best) > 0 && capReached(ctx) {
+			continue
+		}
+
+		if len(picked) == 0 {
+			return fmt.Errorf("%w: %s", apperrors.ErrTagNotCovered, tag)
+		}
+
+		pr.AddReviewer(picked[0].UserID)
+		markCovered(covered, picked[0], pr.RequiredTags)
+	}
+
+	return nil
+}
+
+// picks a replacement for a reviewer leaving a PR with required tags:
+// the candidates are narrowed down to the ones covering most of the tags
+// nobody else on the PR has, returns ErrTagNotCovered when no one covers any
+func (s *PRService) narrowByLostTags(ctx context.Context, pr *models.PullRequest, leavingID string, candidates []*models.User) ([]*models.User, error) {
+
+	if len(pr.RequiredTags) == 0 {
+		return candidates, nil
+	}
+
+	covered := make(map[string]bool)
+
+	for _, reviewerID := range pr.AssignedReviewers {
+
+		if reviewerID == leavingID {
+			continue
+		}
+
+		reviewer, err := s.userRepo.GetByID(ctx, reviewerID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		markCovered(covered, reviewer, pr.RequiredTags)
+	}
+
+	lost := slices.DeleteFunc(slices.Clone(pr.RequiredTags), func(tag string) bool { return covered[tag] })
+
+	if len(lost) == 0 {
+		return candidates, nil
+	}
+
+	best := []*models.User{}
+	bestCoverage := 0
+
+	for _, candidate := range candidates {
+
+		coverage := countUncovered(covered, candidate, lost)
+
+		switch {
+		case coverage == 0:
+			traceFrom(ctx).exclude(candidate.UserID, models.ExcludedMissingTag)
+			continue
+		case coverage > bestCoverage:
+			best = []*models.User{candidate}
+			bestCoverage = coverage
+		case coverage == bestCoverage:
+			best = append(best, candidate)
+		}
+	}
+
+	if len(best) == 0 {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrTagNotCovered, lost[0])
+	}
+
+	return best, nil
+}
+
+func markCovered(covered map[string]bool, user *models.User, tags []string) {
+	for _, tag := range tags {
+		if user.HasTag(tag) {
+			covered[tag] = true
+		}
+	}
+}
+
+func countUncovered(covered map[string]bool, user *models.User, tags []string) int {
+
+	count := 0
+
+	for _, tag := range tags {
+		if !covered[tag] && user.HasTag(tag) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// assigns code owners of the changed files: one owner for every matched rule
+// not covered yet, while reviewer slots remain, picked by the team's strategy
+func (s *PRService) assignOwners(ctx context.Context, pr *models.PullRequest, settings *models.TeamSettings) error {
+
+	rules, err := s.teamRepo.GetOwnershipRules(ctx, settings.TeamName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	// Distinct rules deciding ownership, in order of changed files
+	matched := []*models.OwnershipRule{}
+	seen := make(map[*models.OwnershipRule]bool)
+
+	for _, path := range pr.ChangedFiles {
+		rule := MatchOwnershipRule(rules, path)
+
+		if rule == nil || !rule.HasOwners() || seen[rule] {
+			continue
+		}
+
+		seen[rule] = true
+		matched = append(matched, rule)
+	}
+
+	selector := s.selectorFor(settings, pr.AuthorID)
+	traceFrom(ctx).enter(models.StepOwners)
+
+	for _, rule := range matched {
+
+		if len(pr.AssignedReviewers) >= settings.ReviewerCount {
+			break
+		}
+
+		owners, err := s.getOwnerCandidates(ctx, rule, pr.AuthorID)
+
+		if err != nil {
+			return err
+		}
+
+		// An owner of this rule is already assigned
+		if slices.ContainsFunc(owners, func(u *models.User) bool { return pr.HasReviewer(u.UserID) }) {
+			continue
+		}
+
+		picked, err := selector.Select(ctx, owners, 1)
+
+		if err != nil {
+			return err
+		}
+
+		for _, reviewer := range picked {
+			pr.AddReviewer(reviewer.UserID)
+		}
+	}
+
+	return nil
+}
+
+// gets active owners of the rule (users and members of owning teams), author excluded
+func (s *PRService) getOwnerCandidates(ctx context.Context, rule *models.OwnershipRule, authorID string) ([]*models.User, error) {
+
+	owners := []*models.User{}
+	seen := map[string]bool{authorID: true}
+
+	for _, userID := range rule.OwnerUsers {
+
+		if seen[userID] {
+			continue
+		}
+
+		seen[userID] = true
+
+		user, err := s.userRepo.GetByID(ctx, userID)
+
+		// Rules may outlive users, such owners are skipped
+		if errors.Is(err, apperrors.ErrUserNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if user.IsActive {
+			owners = append(owners, user)
+		}
+	}
+
+	for _, teamName := range rule.OwnerTeams {
+
+		members, err := s.userRepo.GetActiveByTeam(ctx, teamName, authorID, s.clock.Now())
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range members {
+			if !seen[member.UserID] {
+				seen[member.UserID] = true
+				owners = append(owners, member)
+			}
+		}
+	}
+
+	return owners, nil
+}
+
+// takes up to count reviewers from the team's fallback pools, in their order,
+// with the team's own selection strategy. The author and current reviewers are skipped
+func (s *PRService) selectFromFallbacks(ctx context.Context, settings *models.TeamSettings, pr *models.PullRequest, count int) ([]*models.User, error) {
+
+	selector := s.selectorFor(settings, pr.AuthorID)
+	selected := []*models.User{}
+	traceFrom(ctx).enter(models.StepFallback)
+
+	for _, fallbackTeam := range settings.FallbackTeams {
+
+		if len(selected) >= count {
+			break
+		}
+
+		candidates, err := s.getCandidatesExcluding(ctx, fallbackTeam, pr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Reviewers picked from earlier fallback teams are not on the PR yet
+		candidates = slices.DeleteFunc(candidates, func(u *models.User) bool {
+			return slices.Contains(selected, u)
+		})
+
+		picked, err := selector.Select(ctx, candidates, count-len(selected))
+
+		if err != nil {
+			return nil, err
+		}
+
+		selected = append(selected, picked...)
+	}
+
+	return selected, nil
+}
+
+// gets active users from team excluding the PR's author and current reviewers
+func (s *PRService) getCandidatesExcluding(ctx context.Context, teamName string, pr *models.PullRequest) ([]*models.User, error) {
+
+	now := s.clock.Now()
+	allCandidates, err := s.userRepo.GetActiveByTeam(ctx, teamName, "", now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.traceTeamExclusions(ctx, teamName, "", now); err != nil {
+		return nil, err
+	}
+
+	filtered := []*models.User{}
+
+	for _, candidate := range allCandidates {
+
+		switch {
+		case candidate.UserID == pr.AuthorID:
+			traceFrom(ctx).exclude(candidate.UserID, models.ExcludedAuthor)
+		case pr.HasReviewer(candidate.UserID):
+			traceFrom(ctx).exclude(candidate.UserID, models.ExcludedAlreadyAssigned)
+		default:
+			filtered = append(filtered, candidate)
+		}
+	}
+
+	return filtered, nil
+}
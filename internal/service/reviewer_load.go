@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+)
+
+/*
+
+Load snapshot of a selection.
+Caps, the decision trace and load based strategies all look at the candidates' load.
+loadSnapshotSelector reads it once per call of the pipeline, before any candidate is
+filtered out, and carries it in the context: caps compare open reviews, strategies and
+the trace see the same score. Nothing is read when no one looks at it: a ROUND_ROBIN
+team without caps and without a trace. The score is the weighted open load adjusted by the team's
+fairness window and author spread, each read in one more query when enabled.
+
+*/
+
+type loadContextKey struct{}
+
+// loadSnapshot holds the loads of the candidates of one selection
+type loadSnapshot struct {
+	open   map[string]int
+	scores map[string]int
+}
+
+func loadsFrom(ctx context.Context) *loadSnapshot {
+	snapshot, _ := ctx.Value(loadContextKey{}).(*loadSnapshot)
+	return snapshot
+}
+
+// reviewerScores returns the load strategies rank candidates by, taken from the
+// snapshot of the context or, when a strategy is used on its own, from the repository
+func reviewerScores(ctx context.Context, userRepo repository.UserRepository, candidates []*models.User) (map[string]int, error) {
+
+	if snapshot := loadsFrom(ctx); snapshot != nil {
+		return snapshot.scores, nil
+	}
+
+	return userRepo.GetReviewerLoad(ctx, userIDsOf(candidates))
+}
+
+// openReviews returns the number of open reviews of the users, from the snapshot when there is one
+func openReviews(ctx context.Context, userRepo repository.UserRepository, users []*models.User) (map[string]int, error) {
+
+	if snapshot := loadsFrom(ctx); snapshot != nil {
+		return snapshot.open, nil
+	}
+
+	loads, err := userRepo.GetReviewerLoads(ctx, userIDsOf(users))
+
+	if err != nil {
+		return nil, err
+	}
+
+	open := make(map[string]int, len(loads))
+
+	for userID, load := range loads {
+		open[userID] = load.Open
+	}
+
+	return open, nil
+}
+
+// loadSnapshotSelector reads the loads of all candidates before the pipeline it wraps runs
+type loadSnapshotSelector struct {
+	inner    ReviewerSelector
+	userRepo repository.UserRepository
+	settings *models.TeamSettings
+	authorID string
+	now      time.Time
+	usesLoad bool
+}
+
+func newLoadSnapshotSelector(inner ReviewerSelector, userRepo repository.UserRepository, settings *models.TeamSettings,
+	strategy models.ReviewerStrategy, authorID string, now time.Time) ReviewerSelector {
+
+	return &loadSnapshotSelector{
+		inner:    inner,
+		userRepo: userRepo,
+		settings: settings,
+		authorID: authorID,
+		now:      now,
+		usesLoad: strategy.UsesLoad(),
+	}
+}
+
+func (s *loadSnapshotSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	if len(candidates) == 0 || count <= 0 || !s.needed(ctx, candidates) {
+		return s.inner.Select(ctx, candidates, count)
+	}
+
+	userIDs := userIDsOf(candidates)
+
+	loads, err := s.userRepo.GetReviewerLoads(ctx, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &loadSnapshot{open: make(map[string]int, len(loads)), scores: make(map[string]int, len(loads))}
+
+	for userID, load := range loads {
+		snapshot.open[userID] = load.Open
+		snapshot.scores[userID] = load.Weighted
+	}
+
+	if s.settings.FairnessWindowEnabled() {
+
+		err := addWindowFairness(ctx, s.userRepo, s.settings, userIDs, s.now, snapshot.scores)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.settings.AuthorSpreadEnabled() {
+
+		err := addAuthorSpread(ctx, s.userRepo, s.settings, s.authorID, userIDs, s.now, snapshot.scores)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.inner.Select(context.WithValue(ctx, loadContextKey{}, snapshot), candidates, count)
+}
+
+// reports whether the strategy, a cap or the trace of the context will look at the loads
+func (s *loadSnapshotSelector) needed(ctx context.Context, candidates []*models.User) bool {
+
+	if s.usesLoad || traceFrom(ctx) != nil {
+		return true
+	}
+
+	return slices.ContainsFunc(candidates, func(u *models.User) bool { return s.settings.ReviewCap(u) > 0 })
+}
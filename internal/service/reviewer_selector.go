@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+)
+
+/*
+
+Reviewer selection strategies.
+Every team picks one strategy, PRService resolves it and delegates the choice
+among already filtered candidates (author, inactive users and current reviewers
+are removed before a selector is called). Open load is the sum of review weights
+of a member's OPEN PRs, every PR weighs 1 unless the team weighs PRs by size.
+Within the pipeline it comes from the load snapshot of the selection:
+
+- LEAST_LOADED        - lowest open load first, random tie-break
+- ROUND_ROBIN         - the member who waited longest since the last assignment goes first
+- WEIGHTED_RANDOM     - random draw, probability inversely proportional to open load
+- HISTORICAL_FAIRNESS - fewest assignments ever first, then open load, random tie-break
+
+*/
+
+// ReviewerSelector picks up to count reviewers out of the given candidates
+type ReviewerSelector interface {
+	Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error)
+}
+
+func newReviewerSelectors(userRepo repository.UserRepository, rnd *rand.Rand) map[models.ReviewerStrategy]ReviewerSelector {
+	return map[models.ReviewerStrategy]ReviewerSelector{
+		models.StrategyLeastLoaded:        NewLeastLoadedSelector(userRepo, rnd),
+		models.StrategyRoundRobin:         NewRoundRobinSelector(userRepo),
+		models.StrategyWeightedRandom:     NewWeightedRandomSelector(userRepo, rnd),
+		models.StrategyHistoricalFairness: NewHistoricalFairnessSelector(userRepo, rnd),
+	}
+}
+
+// lockedSource lets one random source serve concurrent requests,
+// rand.Rand itself is not safe for concurrent use
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func newLockedSource(seed int64) *lockedSource {
+	return &lockedSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// LeastLoadedSelector prefers candidates with the fewest open reviews
+type LeastLoadedSelector struct {
+	userRepo repository.UserRepository
+	rand     *rand.Rand
+}
+
+func NewLeastLoadedSelector(userRepo repository.UserRepository, rnd *rand.Rand) *LeastLoadedSelector {
+	return &LeastLoadedSelector{userRepo: userRepo, rand: rnd}
+}
+
+func (s *LeastLoadedSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	if len(candidates) == 0 || count <= 0 {
+		return []*models.User{}, nil
+	}
+
+	load, err := reviewerScores(ctx, s.userRepo, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Shuffle first so that users with the same load keep a random order
+	sorted := slices.Clone(candidates)
+	s.rand.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return load[sorted[i].UserID] < load[sorted[j].UserID]
+	})
+
+	return sorted[:min(len(sorted), count)], nil
+}
+
+// RoundRobinSelector rotates strictly through the team: the candidate
+// whose last assignment is the oldest (or who was never assigned) goes first
+type RoundRobinSelector struct {
+	userRepo repository.UserRepository
+}
+
+func NewRoundRobinSelector(userRepo repository.UserRepository) *RoundRobinSelector {
+	return &RoundRobinSelector{userRepo: userRepo}
+}
+
+func (s *RoundRobinSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	if len(candidates) == 0 || count <= 0 {
+		return []*models.User{}, nil
+	}
+
+	history, err := s.userRepo.GetReviewerHistory(ctx, userIDsOf(candidates))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Stable sort keeps the repository order (by username) for ties
+	sorted := slices.Clone(candidates)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		lastI := history[sorted[i].UserID].LastAssignedAt
+		lastJ := history[sorted[j].UserID].LastAssignedAt
+
+		switch {
+		case lastI == nil:
+			return lastJ != nil
+		case lastJ == nil:
+			return false
+		default:
+			return lastI.Before(*lastJ)
+		}
+	})
+
+	return sorted[:min(len(sorted), count)], nil
+}
+
+// WeightedRandomSelector draws candidates randomly without replacement,
+// a candidate with load L has weight 1/(L+1)
+type WeightedRandomSelector struct {
+	userRepo repository.UserRepository
+	rand     *rand.Rand
+}
+
+func NewWeightedRandomSelector(userRepo repository.UserRepository, rnd *rand.Rand) *WeightedRandomSelector {
+	return &WeightedRandomSelector{userRepo: userRepo, rand: rnd}
+}
+
+func (s *WeightedRandomSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	if len(candidates) == 0 || count <= 0 {
+		return []*models.User{}, nil
+	}
+
+	load, err := reviewerScores(ctx, s.userRepo, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pool := slices.Clone(candidates)
+	selected := []*models.User{}
+
+	for len(pool) > 0 && len(selected) < count {
+
+		weights := make([]float64, len(pool))
+		total := 0.0
+
+		for i, candidate := range pool {
+			weights[i] = 1 / float64(load[candidate.UserID]+1)
+			total += weights[i]
+		}
+
+		// Walk the cumulative weights until the random point is reached
+		point := s.rand.Float64() * total
+		picked := len(pool) - 1
+
+		for i, weight := range weights {
+			if point < weight {
+				picked = i
+				break
+			}
+			point -= weight
+		}
+
+		selected = append(selected, pool[picked])
+		pool = slices.Delete(pool, picked, picked+1)
+	}
+
+	return selected, nil
+}
+
+// HistoricalFairnessSelector prefers candidates with the fewest assignments
+// over all time, open load is used as a secondary key
+type HistoricalFairnessSelector struct {
+	userRepo repository.UserRepository
+	rand     *rand.Rand
+}
+
+func NewHistoricalFairnessSelector(userRepo repository.UserRepository, rnd *rand.Rand) *HistoricalFairnessSelector {
+	return &HistoricalFairnessSelector{userRepo: userRepo, rand: rnd}
+}
+
+func (s *HistoricalFairnessSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	if len(candidates) == 0 || count <= 0 {
+		return []*models.User{}, nil
+	}
+
+	userIDs := userIDsOf(candidates)
+
+	history, err := s.userRepo.GetReviewerHistory(ctx, userIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	load, err := reviewerScores(ctx, s.userRepo, candidates)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := slices.Clone(candidates)
+	s.rand.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		totalI := history[sorted[i].UserID].TotalAssigned
+		totalJ := history[sorted[j].UserID].TotalAssigned
+		if totalI != totalJ {
+			return totalI < totalJ
+		}
+		return load[sorted[i].UserID] < load[sorted[j].UserID]
+	})
+
+	return sorted[:min(len(sorted), count)], nil
+}
+
+func userIDsOf(users []*models.User) []string {
+
+	userIDs := make([]string, len(users))
+
+	for i, u := range users {
+		userIDs[i] = u.UserID
+	}
+
+	return userIDs
+}
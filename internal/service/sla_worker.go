@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Background worker checking reviews against the teams' review SLA.
+Every interval it records and escalates the reviews that became late.
+Runs until the context is cancelled.
+
+*/
+
+type SLAWorker struct {
+	slaService *SLAService
+	interval   time.Duration
+}
+
+func NewSLAWorker(slaService *SLAService, interval time.Duration) *SLAWorker {
+	return &SLAWorker{
+		slaService: slaService,
+		interval:   interval,
+	}
+}
+
+func (w *SLAWorker) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *SLAWorker) check(ctx context.Context) {
+
+	breached, err := w.slaService.CheckSLA(ctx)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to check review SLA")
+		}
+		return
+	}
+
+	if breached > 0 {
+		log.Info().Int("breached", breached).Msg("Review SLA breaches escalated")
+	}
+}
@@ -2,34 +2,49 @@ package service
 
 import (
 	"context"
+	"sort"
 
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
 )
 
 /*
 
 Statistics service for tracking PR assignment metrics.
-Provides reviewer workload data including total assignments and active reviews.
+Provides reviewer workload data including total assignments, active reviews both
+as a raw count and weighted by PR size, and the fairness score load based strategies
+compare the reviewer by: weighted load plus the weighted assignments within the
+fairness window of the reviewer's team.
 
 */
 
 type StatsService struct {
 	prRepo   repository.PRRepository
 	userRepo repository.UserRepository
+	teamRepo repository.TeamRepository
+	clock    Clock
 }
 
-func NewStatsService(prRepo repository.PRRepository, userRepo repository.UserRepository) *StatsService {
+func NewStatsService(prRepo repository.PRRepository, userRepo repository.UserRepository, teamRepo repository.TeamRepository, clock Clock) *StatsService {
 	return &StatsService{
 		prRepo:   prRepo,
 		userRepo: userRepo,
+		teamRepo: teamRepo,
+		clock:    clock,
 	}
 }
 
+// AssignmentStats of a reviewer. WindowAssigned counts assignments within
+// FairnessWindowDays of the reviewer's team, both are zero when the window is off
 type AssignmentStats struct {
-	UserID        string `json:"user_id"`
-	Username      string `json:"username"`
-	TotalAssigned int    `json:"total_assigned"`
-	ActiveReviews int    `json:"active_reviews"`
+	UserID             string `json:"user_id"`
+	Username           string `json:"username"`
+	TotalAssigned      int    `json:"total_assigned"`
+	ActiveReviews      int    `json:"active_reviews"`
+	WeightedLoad       int    `json:"weighted_load"`
+	FairnessWindowDays int    `json:"fairness_window_days"`
+	WindowAssigned     int    `json:"window_assigned"`
+	FairnessScore      int    `json:"fairness_score"`
 }
 
 func (s *StatsService) GetAssignmentStats(ctx context.Context) ([]AssignmentStats, error) {
@@ -41,15 +56,17 @@ func (s *StatsService) GetAssignmentStats(ctx context.Context) ([]AssignmentStat
 		return nil, err
 	}
 
-	// Get user IDs
+	// Get user IDs, sorted to keep the response order stable
 	userIDs := make([]string, 0, len(totalStats))
 
 	for userID := range totalStats {
 		userIDs = append(userIDs, userID)
 	}
 
-	// Get active review counts
-	activeStats, err := s.userRepo.GetReviewerLoad(ctx, userIDs)
+	sort.Strings(userIDs)
+
+	// Get active review counts and weights in one query
+	loads, err := s.userRepo.GetReviewerLoads(ctx, userIDs)
 
 	if err != nil {
 		return nil, err
@@ -57,8 +74,9 @@ func (s *StatsService) GetAssignmentStats(ctx context.Context) ([]AssignmentStat
 
 	// Build response
 	stats := []AssignmentStats{}
+	teamSettings := make(map[string]*models.TeamSettings)
 
-	for userID, total := range totalStats {
+	for _, userID := range userIDs {
 
 		user, err := s.userRepo.GetByID(ctx, userID)
 
@@ -66,12 +84,41 @@ func (s *StatsService) GetAssignmentStats(ctx context.Context) ([]AssignmentStat
 			continue
 		}
 
-		stats = append(stats, AssignmentStats{
+		settings, ok := teamSettings[user.TeamName]
+
+		if !ok {
+			settings, err = s.teamRepo.GetSettings(ctx, user.TeamName)
+
+			if err != nil {
+				return nil, err
+			}
+
+			teamSettings[user.TeamName] = settings
+		}
+
+		stat := AssignmentStats{
 			UserID:        userID,
 			Username:      user.Username,
-			TotalAssigned: total,
-			ActiveReviews: activeStats[userID],
-		})
+			TotalAssigned: totalStats[userID],
+			ActiveReviews: loads[userID].Open,
+			WeightedLoad:  loads[userID].Weighted,
+		}
+
+		if settings.FairnessWindowEnabled() {
+
+			since := s.clock.Now().AddDate(0, 0, -settings.FairnessWindowDays)
+			recent, err := s.userRepo.GetAssignmentCounts(ctx, []string{userID}, since)
+
+			if err != nil {
+				return nil, err
+			}
+
+			stat.FairnessWindowDays = settings.FairnessWindowDays
+			stat.WindowAssigned = recent[userID]
+		}
+
+		stat.FairnessScore = settings.FairnessScore(stat.WeightedLoad, stat.WindowAssigned)
+		stats = append(stats, stat)
 	}
 
 	return stats, nil
@@ -2,6 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
@@ -11,23 +15,71 @@ import (
 /*
 
 Team service for team management operations.
-Handles team creation with member synchronization and team data retrieval.
+Handles team creation with member synchronization, team data retrieval,
+the choice of the team's reviewer selection strategy and team settings
+(reviewer count, understaffed policy, ordered fallback teams, working hours policy,
+never pair rules and the author spread penalty)
+and CODEOWNERS-style ownership rules used to prefer code owners as reviewers.
+Team creation is recorded in the audit log, as is every existing member whose
+is_active flag the creation switches; the entries are saved in the transaction
+writing the team or the member.
 
 */
 
+// TeamSettingsUpdate holds settings to change, nil fields keep their current value.
+// FallbackTeams, NeverPair and LabelRules replace the whole list
+type TeamSettingsUpdate struct {
+	ReviewerStrategy    *models.ReviewerStrategy   `json:"reviewer_strategy"`
+	ReviewerCount       *int                       `json:"reviewer_count"`
+	UnderstaffedPolicy  *models.UnderstaffedPolicy `json:"understaffed_policy"`
+	FallbackTeams       *[]string                  `json:"fallback_teams"`
+	WorkingHoursPolicy  *models.WorkingHoursPolicy `json:"working_hours_policy"`
+	WorkingHoursWindow  *int                       `json:"working_hours_window"`
+	NeverPair           *[]models.ReviewerPair     `json:"never_pair"`
+	AuthorSpreadDays    *int                       `json:"author_spread_days"`
+	AuthorSpreadPenalty *int                       `json:"author_spread_penalty"`
+	FairnessWindowDays  *int                       `json:"fairness_window_days"`
+	FairnessWeight      *int                       `json:"fairness_weight"`
+	LoadWeighting       *models.LoadWeighting      `json:"load_weighting"`
+	WeightLinesStep     *int                       `json:"weight_lines_step"`
+	WeightFilesStep     *int                       `json:"weight_files_step"`
+	MaxOpenReviews      *int                       `json:"max_open_reviews"`
+	CapPolicy           *models.CapPolicy          `json:"cap_policy"`
+	MergeApprovals      *models.MergeApprovals     `json:"merge_approvals"`
+	ApprovalQuorum      *int                       `json:"approval_quorum"`
+	ReviewSLAHours      *int                       `json:"review_sla_hours"`
+	SLABusinessHours    *bool                      `json:"sla_business_hours"`
+	SLAEscalation       *models.SLAEscalation      `json:"sla_escalation"`
+	LabelRules          *[]models.LabelRule        `json:"label_rules"`
+}
+
 type TeamService struct {
 	teamRepo repository.TeamRepository
 	userRepo repository.UserRepository
+	staffing StaffingNotifier
+	clock    Clock
 }
 
-func NewTeamService(teamRepo repository.TeamRepository, userRepo repository.UserRepository) *TeamService {
+// NewTeamService creates the service, staffing may be nil when nobody waits for new members
+func NewTeamService(teamRepo repository.TeamRepository, userRepo repository.UserRepository, staffing StaffingNotifier, clock Clock) *TeamService {
 	return &TeamService{
 		teamRepo: teamRepo,
 		userRepo: userRepo,
+		staffing: staffing,
+		clock:    clock,
 	}
 }
 
-func (s *TeamService) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember) (*models.Team, error) {
+func (s *TeamService) CreateTeam(ctx context.Context, teamName string, members []models.TeamMember, strategy models.ReviewerStrategy) (*models.Team, error) {
+
+	// Empty strategy means the default one
+	if strategy == "" {
+		strategy = models.DefaultReviewerStrategy
+	}
+
+	if !strategy.IsValid() {
+		return nil, apperrors.ErrInvalidStrategy
+	}
 
 	// Check if team exists
 	exists, err := s.teamRepo.Exists(ctx, teamName)
@@ -42,6 +94,10 @@ func (s *TeamService) CreateTeam(ctx context.Context, teamName string, members [
 
 	// Create team
 	team := models.NewTeam(teamName, members)
+	team.ReviewerStrategy = strategy
+
+	now := s.clock.Now()
+	team.RecordAudit(newAuditEntry(ctx, models.AuditTeamCreated, models.AuditEntityTeam, teamName, nil, team, now))
 
 	err = s.teamRepo.Create(ctx, team)
 
@@ -54,16 +110,451 @@ func (s *TeamService) CreateTeam(ctx context.Context, teamName string, members [
 
 		user := models.NewUser(member.UserID, member.Username, teamName, member.IsActive)
 
-		err := s.userRepo.Create(ctx, user)
+		existing, err := s.userRepo.GetByID(ctx, member.UserID)
+
+		if err != nil && !errors.Is(err, apperrors.ErrUserNotFound) {
+			return nil, err
+		}
+
+		if existing != nil && existing.IsActive != member.IsActive {
+			user.RecordAudit(memberFlipEntry(ctx, existing, user, now))
+		}
+
+		err = s.userRepo.Create(ctx, user)
 
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// Members may have joined from other teams or come back active
+	notifyStaffing(s.staffing)
+
 	return team, nil
 }
 
+// memberFlipEntry is the audit entry of an existing user whose is_active flag a member upsert switched,
+// the upsert keeps the fields it does not write
+func memberFlipEntry(ctx context.Context, existing, upserted *models.User, at time.Time) models.AuditEntry {
+
+	after := *existing
+	after.Username, after.TeamName = upserted.Username, upserted.TeamName
+	after.IsActive, after.UpdatedAt = upserted.IsActive, upserted.UpdatedAt
+
+	action := models.AuditUserDeactivated
+
+	if after.IsActive {
+		action = models.AuditUserActivated
+	}
+
+	return newAuditEntry(ctx, action, models.AuditEntityUser, existing.UserID, existing, &after, at)
+}
+
 func (s *TeamService) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
 	return s.teamRepo.GetByName(ctx, teamName)
 }
+
+func (s *TeamService) SetStrategy(ctx context.Context, teamName string, strategy models.ReviewerStrategy) (*models.Team, error) {
+
+	if !strategy.IsValid() {
+		return nil, apperrors.ErrInvalidStrategy
+	}
+
+	err := s.teamRepo.UpdateStrategy(ctx, teamName, strategy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.teamRepo.GetByName(ctx, teamName)
+}
+
+func (s *TeamService) GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	return s.teamRepo.GetSettings(ctx, teamName)
+}
+
+func (s *TeamService) UpdateSettings(ctx context.Context, teamName string, update TeamSettingsUpdate) (*models.TeamSettings, error) {
+
+	settings, err := s.teamRepo.GetSettings(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply changed fields
+	if update.ReviewerStrategy != nil {
+		settings.ReviewerStrategy = *update.ReviewerStrategy
+	}
+
+	if update.ReviewerCount != nil {
+		settings.ReviewerCount = *update.ReviewerCount
+	}
+
+	if update.UnderstaffedPolicy != nil {
+		settings.UnderstaffedPolicy = *update.UnderstaffedPolicy
+	}
+
+	if update.FallbackTeams != nil {
+		settings.FallbackTeams = *update.FallbackTeams
+	}
+
+	if update.WorkingHoursPolicy != nil {
+		settings.WorkingHoursPolicy = *update.WorkingHoursPolicy
+	}
+
+	if update.WorkingHoursWindow != nil {
+		settings.WorkingHoursWindow = *update.WorkingHoursWindow
+	}
+
+	if update.NeverPair != nil {
+		settings.NeverPair = *update.NeverPair
+	}
+
+	if update.AuthorSpreadDays != nil {
+		settings.AuthorSpreadDays = *update.AuthorSpreadDays
+	}
+
+	if update.AuthorSpreadPenalty != nil {
+		settings.AuthorSpreadPenalty = *update.AuthorSpreadPenalty
+	}
+
+	if update.FairnessWindowDays != nil {
+		settings.FairnessWindowDays = *update.FairnessWindowDays
+	}
+
+	if update.FairnessWeight != nil {
+		settings.FairnessWeight = *update.FairnessWeight
+	}
+
+	if update.LoadWeighting != nil {
+		settings.LoadWeighting = *update.LoadWeighting
+	}
+
+	if update.WeightLinesStep != nil {
+		settings.WeightLinesStep = *update.WeightLinesStep
+	}
+
+	if update.WeightFilesStep != nil {
+		settings.WeightFilesStep = *update.WeightFilesStep
+	}
+
+	if update.MaxOpenReviews != nil {
+		settings.MaxOpenReviews = *update.MaxOpenReviews
+	}
+
+	if update.CapPolicy != nil {
+		settings.CapPolicy = *update.CapPolicy
+	}
+
+	if update.MergeApprovals != nil {
+		settings.MergeApprovals = *update.MergeApprovals
+	}
+
+	if update.ApprovalQuorum != nil {
+		settings.ApprovalQuorum = *update.ApprovalQuorum
+	}
+
+	if update.ReviewSLAHours != nil {
+		settings.ReviewSLAHours = *update.ReviewSLAHours
+	}
+
+	if update.SLABusinessHours != nil {
+		settings.SLABusinessHours = *update.SLABusinessHours
+	}
+
+	if update.SLAEscalation != nil {
+		settings.SLAEscalation = *update.SLAEscalation
+	}
+
+	if update.LabelRules != nil {
+		settings.LabelRules = *update.LabelRules
+	}
+
+	// Validate the result as a whole
+	if !settings.ReviewerStrategy.IsValid() {
+		return nil, apperrors.ErrInvalidStrategy
+	}
+
+	if settings.ReviewerCount < models.MinReviewerCount || settings.ReviewerCount > models.MaxReviewerCount {
+		return nil, fmt.Errorf("%w: reviewer_count must be between %d and %d",
+			apperrors.ErrInvalidSettings, models.MinReviewerCount, models.MaxReviewerCount)
+	}
+
+	if !settings.UnderstaffedPolicy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown understaffed_policy", apperrors.ErrInvalidSettings)
+	}
+
+	if !settings.WorkingHoursPolicy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown working_hours_policy", apperrors.ErrInvalidSettings)
+	}
+
+	if settings.WorkingHoursWindow < 0 || settings.WorkingHoursWindow > models.MaxWorkingHoursWindow {
+		return nil, fmt.Errorf("%w: working_hours_window must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxWorkingHoursWindow)
+	}
+
+	if settings.UnderstaffedPolicy == models.UnderstaffedFallback && len(settings.FallbackTeams) == 0 {
+		return nil, fmt.Errorf("%w: FALLBACK policy requires fallback_teams", apperrors.ErrInvalidSettings)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, fallbackTeam := range settings.FallbackTeams {
+
+		if fallbackTeam == teamName {
+			return nil, fmt.Errorf("%w: team cannot fall back to itself", apperrors.ErrInvalidSettings)
+		}
+
+		if seen[fallbackTeam] {
+			return nil, fmt.Errorf("%w: duplicate fallback team %s", apperrors.ErrInvalidSettings, fallbackTeam)
+		}
+
+		seen[fallbackTeam] = true
+
+		exists, err := s.teamRepo.Exists(ctx, fallbackTeam)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, apperrors.ErrTeamNotFound
+		}
+	}
+
+	if settings.AuthorSpreadDays < 0 || settings.AuthorSpreadDays > models.MaxAuthorSpreadDays {
+		return nil, fmt.Errorf("%w: author_spread_days must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxAuthorSpreadDays)
+	}
+
+	if settings.AuthorSpreadPenalty < 0 || settings.AuthorSpreadPenalty > models.MaxAuthorSpreadPenalty {
+		return nil, fmt.Errorf("%w: author_spread_penalty must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxAuthorSpreadPenalty)
+	}
+
+	if settings.FairnessWindowDays < 0 || settings.FairnessWindowDays > models.MaxFairnessWindowDays {
+		return nil, fmt.Errorf("%w: fairness_window_days must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxFairnessWindowDays)
+	}
+
+	if settings.FairnessWeight < 0 || settings.FairnessWeight > models.MaxFairnessWeight {
+		return nil, fmt.Errorf("%w: fairness_weight must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxFairnessWeight)
+	}
+
+	if !settings.LoadWeighting.IsValid() {
+		return nil, fmt.Errorf("%w: unknown load_weighting", apperrors.ErrInvalidSettings)
+	}
+
+	if settings.WeightLinesStep < 0 || settings.WeightLinesStep > models.MaxWeightStep ||
+		settings.WeightFilesStep < 0 || settings.WeightFilesStep > models.MaxWeightStep {
+		return nil, fmt.Errorf("%w: weight_lines_step and weight_files_step must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxWeightStep)
+	}
+
+	if settings.MaxOpenReviews < 0 || settings.MaxOpenReviews > models.MaxReviewCap {
+		return nil, fmt.Errorf("%w: max_open_reviews must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxReviewCap)
+	}
+
+	if !settings.CapPolicy.IsValid() {
+		return nil, fmt.Errorf("%w: unknown cap_policy", apperrors.ErrInvalidSettings)
+	}
+
+	if !settings.MergeApprovals.IsValid() {
+		return nil, fmt.Errorf("%w: unknown merge_approvals", apperrors.ErrInvalidSettings)
+	}
+
+	if settings.ApprovalQuorum < 1 || settings.ApprovalQuorum > models.MaxReviewerCount {
+		return nil, fmt.Errorf("%w: approval_quorum must be between 1 and %d",
+			apperrors.ErrInvalidSettings, models.MaxReviewerCount)
+	}
+
+	// A quorum the team never assigns could only be reached by extra reviewers
+	if settings.MergeApprovals == models.MergeApprovalsQuorum && settings.ApprovalQuorum > settings.ReviewerCount {
+		return nil, fmt.Errorf("%w: approval_quorum must not exceed reviewer_count", apperrors.ErrInvalidSettings)
+	}
+
+	if settings.ReviewSLAHours < 0 || settings.ReviewSLAHours > models.MaxReviewSLAHours {
+		return nil, fmt.Errorf("%w: review_sla_hours must be between 0 and %d",
+			apperrors.ErrInvalidSettings, models.MaxReviewSLAHours)
+	}
+
+	if !settings.SLAEscalation.IsValid() {
+		return nil, fmt.Errorf("%w: unknown sla_escalation", apperrors.ErrInvalidSettings)
+	}
+
+	if update.NeverPair != nil {
+
+		pairs, err := s.checkNeverPairs(ctx, settings.NeverPair)
+
+		if err != nil {
+			return nil, err
+		}
+
+		settings.NeverPair = pairs
+	}
+
+	if update.LabelRules != nil {
+
+		rules, err := s.checkLabelRules(ctx, settings.LabelRules)
+
+		if err != nil {
+			return nil, err
+		}
+
+		settings.LabelRules = rules
+	}
+
+	settings.UpdatedAt = time.Now()
+
+	if err := s.teamRepo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	notifyStaffing(s.staffing)
+
+	return settings, nil
+}
+
+// normalizes never pair rules: two different existing users per pair, duplicates are dropped
+func (s *TeamService) checkNeverPairs(ctx context.Context, pairs []models.ReviewerPair) ([]models.ReviewerPair, error) {
+
+	normalized := []models.ReviewerPair{}
+
+	for _, pair := range pairs {
+
+		if pair.UserA == "" || pair.UserB == "" || pair.UserA == pair.UserB {
+			return nil, fmt.Errorf("%w: never_pair needs two different users", apperrors.ErrInvalidSettings)
+		}
+
+		pair = pair.Normalized()
+
+		if slices.Contains(normalized, pair) {
+			continue
+		}
+
+		for _, userID := range []string{pair.UserA, pair.UserB} {
+			if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+				return nil, err
+			}
+		}
+
+		normalized = append(normalized, pair)
+	}
+
+	return normalized, nil
+}
+
+// normalizes label rules: a label and an existing team per rule, duplicates are dropped
+func (s *TeamService) checkLabelRules(ctx context.Context, rules []models.LabelRule) ([]models.LabelRule, error) {
+
+	normalized := []models.LabelRule{}
+
+	for _, rule := range rules {
+
+		rule.Label = models.NormalizeLabel(rule.Label)
+
+		if rule.Label == "" || len(rule.Label) > models.MaxLabelLength || rule.Team == "" {
+			return nil, fmt.Errorf("%w: label_rules need a label and a team", apperrors.ErrInvalidSettings)
+		}
+
+		if slices.Contains(normalized, rule) {
+			continue
+		}
+
+		exists, err := s.teamRepo.Exists(ctx, rule.Team)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, apperrors.ErrTeamNotFound
+		}
+
+		normalized = append(normalized, rule)
+	}
+
+	return normalized, nil
+}
+
+func (s *TeamService) GetOwnershipRules(ctx context.Context, teamName string) ([]models.OwnershipRule, error) {
+
+	exists, err := s.teamRepo.Exists(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, apperrors.ErrTeamNotFound
+	}
+
+	return s.teamRepo.GetOwnershipRules(ctx, teamName)
+}
+
+// SetOwnershipRules replaces team's ownership rules with the ones from a CODEOWNERS file
+func (s *TeamService) SetOwnershipRules(ctx context.Context, teamName, codeowners string) ([]models.OwnershipRule, error) {
+
+	exists, err := s.teamRepo.Exists(ctx, teamName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, apperrors.ErrTeamNotFound
+	}
+
+	rules, err := ParseCodeowners(codeowners)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Every referenced owner must exist
+	checkedUsers := make(map[string]bool)
+	checkedTeams := make(map[string]bool)
+
+	for _, rule := range rules {
+
+		for _, userID := range rule.OwnerUsers {
+
+			if checkedUsers[userID] {
+				continue
+			}
+
+			if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+				return nil, err
+			}
+
+			checkedUsers[userID] = true
+		}
+
+		for _, ownerTeam := range rule.OwnerTeams {
+
+			if checkedTeams[ownerTeam] {
+				continue
+			}
+
+			exists, err := s.teamRepo.Exists(ctx, ownerTeam)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !exists {
+				return nil, apperrors.ErrTeamNotFound
+			}
+
+			checkedTeams[ownerTeam] = true
+		}
+	}
+
+	if err := s.teamRepo.ReplaceOwnershipRules(ctx, teamName, rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
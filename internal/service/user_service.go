@@ -2,7 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
 )
@@ -10,19 +16,39 @@ import (
 /*
 
 User service for user management and review tracking.
-Handles user activation status and review history retrieval.
+Handles user activation status (optionally handing open reviews over on deactivation,
+changes of the status are recorded in the audit log),
+review history retrieval and expertise tags.
+Tags are normalized to lowercase, e.g. "SQL " and "sql" are the same tag.
+Working hours are a weekly schedule in the user's IANA timezone.
 
 */
 
+// OpenReviewReassigner hands the open reviews of a user over to other reviewers
+type OpenReviewReassigner interface {
+	ReassignOpenReviews(ctx context.Context, userID string) (*ReassignmentReport, error)
+}
+
 type UserService struct {
-	userRepo repository.UserRepository
-	prRepo   repository.PRRepository
+	userRepo   repository.UserRepository
+	prRepo     repository.PRRepository
+	reassigner OpenReviewReassigner
+	staffing   StaffingNotifier
+	publisher  EventPublisher
+	clock      Clock
 }
 
-func NewUserService(userRepo repository.UserRepository, prRepo repository.PRRepository) *UserService {
+// NewUserService creates the service, staffing may be nil when nobody waits for freed reviewers
+// and publisher may be nil when nobody listens for user events
+func NewUserService(userRepo repository.UserRepository, prRepo repository.PRRepository, reassigner OpenReviewReassigner,
+	staffing StaffingNotifier, publisher EventPublisher, clock Clock) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		prRepo:   prRepo,
+		userRepo:   userRepo,
+		prRepo:     prRepo,
+		reassigner: reassigner,
+		staffing:   staffing,
+		publisher:  publisher,
+		clock:      clock,
 	}
 }
 
@@ -34,18 +60,149 @@ func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive b
 		return nil, err
 	}
 
+	now := s.clock.Now()
+	before := *user
 	user.SetActive(isActive)
 
+	// Saved in the transaction of the update
+	if before.IsActive != isActive {
+
+		action := models.AuditUserDeactivated
+
+		if isActive {
+			action = models.AuditUserActivated
+		}
+
+		user.RecordAudit(newAuditEntry(ctx, action, models.AuditEntityUser, userID, &before, user, now))
+	}
+
 	err = s.userRepo.Update(ctx, user)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if isActive {
+		notifyStaffing(s.staffing)
+	} else if before.IsActive {
+		publishEvent(ctx, s.publisher, models.EventUserDeactivated, now, models.UserEventData{User: user})
+	}
+
+	return user, nil
+}
+
+// Deactivate switches the user off and reassigns all their OPEN reviews.
+// The user stays inactive even when some PRs could not be reassigned,
+// the report tells which ones still need attention. A reassignment stopped
+// by an error returns the user and the partial report together with the error
+func (s *UserService) Deactivate(ctx context.Context, userID string) (*models.User, *ReassignmentReport, error) {
+
+	user, err := s.SetIsActive(ctx, userID, false)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := s.reassigner.ReassignOpenReviews(ctx, userID)
+
+	if err != nil {
+		return user, report, err
+	}
+
+	return user, report, nil
+}
+
+// GetUserReviews lists PRs the user reviews, only the ones in the given review state unless it is empty
+func (s *UserService) GetUserReviews(ctx context.Context, userID string, state models.ReviewState) ([]*models.PullRequest, error) {
+
+	if state != "" && !state.IsValid() {
+		return nil, fmt.Errorf("%w: unknown review state %q", apperrors.ErrInvalidReviewState, state)
+	}
+
+	// Verify user exists
+	_, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prs, err := s.prRepo.GetByReviewer(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if state == "" {
+		return prs, nil
+	}
+
+	return slices.DeleteFunc(prs, func(pr *models.PullRequest) bool {
+		return pr.ReviewState(userID) != state
+	}), nil
+}
+
+func (s *UserService) SetWorkingHours(ctx context.Context, userID, timezone string, hours models.WorkingHours) (*models.User, error) {
+
+	if timezone == "" {
+		timezone = models.DefaultTimezone
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", apperrors.ErrInvalidWorkingHours, timezone)
+	}
+
+	if hours == nil {
+		hours = models.WorkingHours{}
+	}
+
+	if err := hours.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrInvalidWorkingHours, err)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.Timezone = timezone
+	user.WorkingHours = hours
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SetReviewCap sets the cap on open reviews of the user, nil leaves the cap to team settings
+func (s *UserService) SetReviewCap(ctx context.Context, userID string, maxOpenReviews *int) (*models.User, error) {
+
+	if maxOpenReviews != nil && (*maxOpenReviews < 1 || *maxOpenReviews > models.MaxReviewCap) {
+		return nil, fmt.Errorf("%w: max_open_reviews must be between 1 and %d",
+			apperrors.ErrInvalidReviewCap, models.MaxReviewCap)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.MaxOpenReviews = maxOpenReviews
+	user.UpdatedAt = time.Now()
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	notifyStaffing(s.staffing)
+
 	return user, nil
 }
 
-func (s *UserService) GetUserReviews(ctx context.Context, userID string) ([]*models.PullRequest, error) {
+func (s *UserService) GetTags(ctx context.Context, userID string) ([]string, error) {
 
 	// Verify user exists
 	_, err := s.userRepo.GetByID(ctx, userID)
@@ -54,5 +211,61 @@ func (s *UserService) GetUserReviews(ctx context.Context, userID string) ([]*mod
 		return nil, err
 	}
 
-	return s.prRepo.GetByReviewer(ctx, userID)
+	return s.userRepo.GetTags(ctx, userID)
+}
+
+func (s *UserService) AddTags(ctx context.Context, userID string, tags []string) ([]string, error) {
+	return s.changeTags(ctx, userID, tags, s.userRepo.AddTags)
+}
+
+func (s *UserService) RemoveTags(ctx context.Context, userID string, tags []string) ([]string, error) {
+	return s.changeTags(ctx, userID, tags, s.userRepo.RemoveTags)
+}
+
+func (s *UserService) SetTags(ctx context.Context, userID string, tags []string) ([]string, error) {
+	return s.changeTags(ctx, userID, tags, s.userRepo.ReplaceTags)
+}
+
+// validates tags, applies the change and returns the resulting tags of the user
+func (s *UserService) changeTags(ctx context.Context, userID string, tags []string, change func(context.Context, string, []string) error) ([]string, error) {
+
+	normalized, err := normalizeTags(tags)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := change(ctx, userID, normalized); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetTags(ctx, userID)
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9.+#][a-z0-9.+#_-]{0,63}$`)
+
+// lowercases and trims tags, drops duplicates and returns them sorted
+func normalizeTags(tags []string) ([]string, error) {
+
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidTag, tag)
+		}
+
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized), nil
 }
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+/*
+
+Background worker sending outbound webhook deliveries.
+It sends the due deliveries whenever new ones are queued and every interval
+in between, which is when retries come due. Runs until the context is cancelled.
+
+*/
+
+type WebhookDeliveryWorker struct {
+	outboundService *OutboundWebhookService
+	interval        time.Duration
+}
+
+func NewWebhookDeliveryWorker(outboundService *OutboundWebhookService, interval time.Duration) *WebhookDeliveryWorker {
+	return &WebhookDeliveryWorker{
+		outboundService: outboundService,
+		interval:        interval,
+	}
+}
+
+func (w *WebhookDeliveryWorker) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.deliver(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.outboundService.DeliveriesQueued():
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *WebhookDeliveryWorker) deliver(ctx context.Context) {
+
+	delivered, err := w.outboundService.DeliverDue(ctx)
+
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to send webhook deliveries")
+		}
+		return
+	}
+
+	if delivered > 0 {
+		log.Info().Int("delivered", delivered).Msg("Webhook deliveries sent")
+	}
+}
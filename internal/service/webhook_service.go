@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/codehost"
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	repository "github.com/SashaMalcev/pr-reviewer-service/internal/repository/interfaces"
+)
+
+/*
+
+Webhook service: code hosts report PR changes and the service follows them,
+so PRs no longer have to be registered and merged by hand.
+A code host adapter (see the codehost package) verifies a delivery and turns it
+into a change, which maps onto PRService:
+
+- opened            - CreatePR (as a DRAFT for draft PRs)
+- ready for review  - MarkReady
+- edited            - UpdateMetadata with name, base branch, labels and description
+- merged            - RecordExternalMerge
+- closed            - ClosePR
+- reopened          - ReopenPR
+
+Authors are resolved through logins of the code host mapped to users;
+a PR of an unmapped author is rejected with LOGIN_NOT_MAPPED, so the delivery
+shows up as failed on the code host and may be redelivered once the login is mapped.
+Redeliveries are harmless: a PR that exists already, a change of a PR the service
+does not know and an illegal transition are acknowledged as IGNORED, as are other
+events and actions, so the code host does not report them as failed.
+A merge is a fact on the code host: it is recorded even without the approvals
+merge_approvals of the team require, only /pullRequest/merge enforces them.
+
+*/
+
+// PRAutomation is the part of PRService driven by webhooks
+type PRAutomation interface {
+	CreatePR(ctx context.Context, req CreatePRRequest) (*models.PullRequest, error)
+	UpdateMetadata(ctx context.Context, prID string, update PRMetadataUpdate) (*models.PullRequest, error)
+	MarkReady(ctx context.Context, prID string) (*models.PullRequest, error)
+	RecordExternalMerge(ctx context.Context, prID string) (*models.PullRequest, error)
+	ClosePR(ctx context.Context, prID string) (*models.PullRequest, error)
+	ReopenPR(ctx context.Context, prID string) (*models.PullRequest, error)
+}
+
+type WebhookService struct {
+	loginRepo repository.LoginRepository
+	userRepo  repository.UserRepository
+	prs       PRAutomation
+	clock     Clock
+	adapters  map[models.CodeHost]codehost.Adapter
+}
+
+// NewWebhookService creates the service accepting deliveries of the code hosts of the adapters
+func NewWebhookService(loginRepo repository.LoginRepository, userRepo repository.UserRepository, prs PRAutomation,
+	clock Clock, adapters ...codehost.Adapter) *WebhookService {
+
+	byHost := make(map[models.CodeHost]codehost.Adapter, len(adapters))
+
+	for _, adapter := range adapters {
+		byHost[adapter.Host()] = adapter
+	}
+
+	return &WebhookService{
+		loginRepo: loginRepo,
+		userRepo:  userRepo,
+		prs:       prs,
+		clock:     clock,
+		adapters:  byHost,
+	}
+}
+
+// HandleDelivery verifies a webhook delivery of the code host and applies it
+func (s *WebhookService) HandleDelivery(ctx context.Context, host models.CodeHost, header http.Header, body []byte) (*models.WebhookResult, error) {
+
+	adapter, ok := s.adapters[host]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrCodeHostNotFound, host)
+	}
+
+	if err := adapter.Verify(header, body); err != nil {
+		return nil, err
+	}
+
+	change, err := adapter.Parse(header, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.WebhookResult{
+		Event:         change.Event,
+		Action:        change.Action,
+		PullRequestID: change.PullRequestID,
+		Outcome:       models.WebhookIgnored,
+	}
+
+	if change.Kind == codehost.ChangeIgnored {
+		result.Reason = "event or action is not handled"
+		return result, nil
+	}
+
+	outcome, err := s.applyChange(ctx, host, change)
+
+	switch {
+	case errors.Is(err, apperrors.ErrPRExists):
+		result.Reason = "PR is registered already"
+	case errors.Is(err, apperrors.ErrPRNotFound):
+		result.Reason = "PR is not registered"
+	case errors.Is(err, apperrors.ErrInvalidTransition):
+		result.Reason = err.Error()
+	case err != nil:
+		return nil, err
+	default:
+		result.Outcome = outcome
+	}
+
+	return result, nil
+}
+
+func (s *WebhookService) applyChange(ctx context.Context, host models.CodeHost, change *codehost.Change) (models.WebhookOutcome, error) {
+
+	var err error
+	prID := change.PullRequestID
+
+	switch change.Kind {
+	case codehost.ChangeOpened:
+
+		authorID, err := s.resolveLogin(ctx, host, change.AuthorLogin)
+
+		if err != nil {
+			return "", err
+		}
+
+		_, err = s.prs.CreatePR(ctx, CreatePRRequest{
+			PullRequestID:   prID,
+			PullRequestName: change.Title,
+			AuthorID:        authorID,
+			Draft:           change.Draft,
+			PRMetadata:      change.Metadata,
+		})
+
+		return models.WebhookCreated, err
+
+	case codehost.ChangeReady:
+		_, err = s.prs.MarkReady(ctx, prID)
+		return models.WebhookReady, err
+
+	case codehost.ChangeEdited:
+		_, err = s.prs.UpdateMetadata(ctx, prID, PRMetadataUpdate{
+			PullRequestName: &change.Title,
+			BaseBranch:      &change.Metadata.BaseBranch,
+			Labels:          &change.Metadata.Labels,
+			Description:     &change.Metadata.Description,
+		})
+
+		return models.WebhookUpdated, err
+
+	case codehost.ChangeMerged:
+		_, err = s.prs.RecordExternalMerge(ctx, prID)
+		return models.WebhookMerged, err
+
+	case codehost.ChangeClosed:
+		_, err = s.prs.ClosePR(ctx, prID)
+		return models.WebhookClosed, err
+
+	case codehost.ChangeReopened:
+		_, err = s.prs.ReopenPR(ctx, prID)
+		return models.WebhookReopened, err
+	}
+
+	return models.WebhookIgnored, nil
+}
+
+// SaveLogin maps a login of the code host to an existing user, a login mapped before moves to the new user
+func (s *WebhookService) SaveLogin(ctx context.Context, host models.CodeHost, login, userID string) (*models.CodeHostLogin, error) {
+
+	login = models.NormalizeLogin(login)
+
+	if !host.IsValid() {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrCodeHostNotFound, host)
+	}
+
+	if login == "" || len(login) > models.MaxLoginLength {
+		return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidLogin, login)
+	}
+
+	// Verify user exists
+	_, err := s.userRepo.GetByID(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := &models.CodeHostLogin{
+		CodeHost:  host,
+		Login:     login,
+		UserID:    userID,
+		CreatedAt: s.clock.Now(),
+	}
+
+	if err := s.loginRepo.Save(ctx, mapping); err != nil {
+		return nil, err
+	}
+
+	return mapping, nil
+}
+
+func (s *WebhookService) RemoveLogin(ctx context.Context, host models.CodeHost, login string) error {
+
+	if !host.IsValid() {
+		return fmt.Errorf("%w: %s", apperrors.ErrCodeHostNotFound, host)
+	}
+
+	return s.loginRepo.Delete(ctx, host, models.NormalizeLogin(login))
+}
+
+func (s *WebhookService) ListLogins(ctx context.Context, host models.CodeHost) ([]*models.CodeHostLogin, error) {
+
+	if !host.IsValid() {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrCodeHostNotFound, host)
+	}
+
+	return s.loginRepo.List(ctx, host)
+}
+
+// resolves the author of a PR on the code host to a user
+func (s *WebhookService) resolveLogin(ctx context.Context, host models.CodeHost, login string) (string, error) {
+
+	userID, err := s.loginRepo.FindUserID(ctx, host, models.NormalizeLogin(login))
+
+	if errors.Is(err, apperrors.ErrLoginNotMapped) {
+		return "", fmt.Errorf("%w: %s", err, login)
+	}
+
+	return userID, err
+}
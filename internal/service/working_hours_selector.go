@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+)
+
+/*
+
+Working hours aware selection.
+Wraps a team's strategy: candidates who work now (in their own timezone) or start
+within the team's window are available, the strategy picks among them first.
+With PREFER the others fill the remaining slots, with REQUIRE they are never picked.
+Users without a schedule are always available.
+
+*/
+
+type workingHoursSelector struct {
+	inner  ReviewerSelector
+	clock  Clock
+	policy models.WorkingHoursPolicy
+	window time.Duration
+}
+
+func newWorkingHoursSelector(inner ReviewerSelector, clock Clock, settings *models.TeamSettings) ReviewerSelector {
+
+	if settings.WorkingHoursPolicy != models.WorkingHoursPrefer && settings.WorkingHoursPolicy != models.WorkingHoursRequire {
+		return inner
+	}
+
+	return &workingHoursSelector{
+		inner:  inner,
+		clock:  clock,
+		policy: settings.WorkingHoursPolicy,
+		window: time.Duration(settings.WorkingHoursWindow) * time.Hour,
+	}
+}
+
+func (s *workingHoursSelector) Select(ctx context.Context, candidates []*models.User, count int) ([]*models.User, error) {
+
+	now := s.clock.Now()
+	available := []*models.User{}
+	unavailable := []*models.User{}
+
+	for _, candidate := range candidates {
+		if candidate.IsAvailableWithin(now, s.window) {
+			available = append(available, candidate)
+		} else {
+			unavailable = append(unavailable, candidate)
+		}
+	}
+
+	selected, err := s.inner.Select(ctx, available, count)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.policy == models.WorkingHoursRequire {
+
+		for _, candidate := range unavailable {
+			traceFrom(ctx).exclude(candidate.UserID, models.ExcludedWorkingHours)
+		}
+
+		return selected, nil
+	}
+
+	if len(selected) >= count {
+		return selected, nil
+	}
+
+	rest, err := s.inner.Select(ctx, unavailable, count-len(selected))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(selected, rest...), nil
+}
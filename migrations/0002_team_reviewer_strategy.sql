@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Reviewer selection strategy per team
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS reviewer_strategy VARCHAR(32) NOT NULL DEFAULT 'LEAST_LOADED'
+    CHECK (reviewer_strategy IN ('LEAST_LOADED', 'ROUND_ROBIN', 'WEIGHTED_RANDOM', 'HISTORICAL_FAIRNESS'));
+
+COMMENT ON COLUMN teams.reviewer_strategy IS 'Strategy used to pick reviewers among team members';
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_user_assigned ON pr_reviewers(user_id, assigned_at);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_pr_reviewers_user_assigned;
+ALTER TABLE teams DROP COLUMN IF EXISTS reviewer_strategy;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Team settings table
+CREATE TABLE IF NOT EXISTS team_settings (
+    team_name VARCHAR(255) PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+    reviewer_count INTEGER NOT NULL DEFAULT 2 CHECK (reviewer_count BETWEEN 1 AND 10),
+    understaffed_policy VARCHAR(20) NOT NULL DEFAULT 'ALLOW' CHECK (understaffed_policy IN ('FAIL', 'FALLBACK', 'ALLOW')),
+    fallback_team VARCHAR(255) REFERENCES teams(team_name) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (fallback_team IS NULL OR fallback_team <> team_name)
+);
+
+COMMENT ON TABLE team_settings IS 'Stores per-team reviewer assignment settings';
+COMMENT ON COLUMN team_settings.team_name IS 'Team the settings belong to';
+COMMENT ON COLUMN team_settings.reviewer_count IS 'Number of reviewers required for every PR';
+COMMENT ON COLUMN team_settings.understaffed_policy IS 'What to do when there are too few candidates: FAIL, FALLBACK or ALLOW';
+COMMENT ON COLUMN team_settings.fallback_team IS 'Team to take missing reviewers from (FALLBACK policy)';
+COMMENT ON COLUMN team_settings.updated_at IS 'Timestamp when settings were last updated';
+
+
+-- Understaffed flag for pull requests
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS understaffed BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN pull_requests.understaffed IS 'Whether PR got fewer reviewers than its team requires';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS understaffed;
+DROP TABLE IF EXISTS team_settings;
+-- +goose StatementEnd
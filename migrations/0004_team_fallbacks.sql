@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Ordered fallback reviewer pools per team
+CREATE TABLE IF NOT EXISTS team_fallbacks (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    fallback_team VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (team_name, fallback_team),
+    UNIQUE (team_name, position),
+    CHECK (fallback_team <> team_name)
+);
+
+COMMENT ON TABLE team_fallbacks IS 'Ordered list of teams to take missing reviewers from';
+COMMENT ON COLUMN team_fallbacks.team_name IS 'Team that falls back';
+COMMENT ON COLUMN team_fallbacks.fallback_team IS 'Team providing extra reviewers';
+COMMENT ON COLUMN team_fallbacks.position IS 'Order in which fallback teams are tried (ascending)';
+
+INSERT INTO team_fallbacks (team_name, fallback_team, position)
+SELECT team_name, fallback_team, 1 FROM team_settings WHERE fallback_team IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE team_settings DROP COLUMN IF EXISTS fallback_team;
+
+
+-- Reviewers taken from a fallback pool
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS from_fallback BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN pr_reviewers.from_fallback IS 'Whether reviewer was taken from a fallback team';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS from_fallback;
+
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS fallback_team VARCHAR(255) REFERENCES teams(team_name) ON DELETE SET NULL;
+
+UPDATE team_settings s SET fallback_team = f.fallback_team
+FROM team_fallbacks f
+WHERE f.team_name = s.team_name AND f.position = 1;
+
+DROP TABLE IF EXISTS team_fallbacks;
+-- +goose StatementEnd
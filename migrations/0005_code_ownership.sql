@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- CODEOWNERS-style ownership rules per team
+CREATE TABLE IF NOT EXISTS ownership_rules (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    pattern TEXT NOT NULL,
+    owner_users TEXT[] NOT NULL DEFAULT '{}',
+    owner_teams TEXT[] NOT NULL DEFAULT '{}',
+    PRIMARY KEY (team_name, position)
+);
+
+COMMENT ON TABLE ownership_rules IS 'Path patterns mapped to owning users and teams, the last matching rule wins';
+COMMENT ON COLUMN ownership_rules.team_name IS 'Team whose PRs the rules apply to';
+COMMENT ON COLUMN ownership_rules.position IS 'Order of the rule in the uploaded CODEOWNERS file';
+COMMENT ON COLUMN ownership_rules.pattern IS 'CODEOWNERS-style path glob';
+COMMENT ON COLUMN ownership_rules.owner_users IS 'Users owning matching paths';
+COMMENT ON COLUMN ownership_rules.owner_teams IS 'Teams owning matching paths';
+
+
+-- Changed file paths of pull requests
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS changed_files TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN pull_requests.changed_files IS 'Paths changed by the PR, used to find code owners';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS changed_files;
+DROP TABLE IF EXISTS ownership_rules;
+-- +goose StatementEnd
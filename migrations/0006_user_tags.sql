@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Expertise tags of users
+CREATE TABLE IF NOT EXISTS user_tags (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tags_tag ON user_tags(tag);
+
+COMMENT ON TABLE user_tags IS 'Expertise tags (sql, frontend, k8s, ...) used to match reviewers to PRs';
+COMMENT ON COLUMN user_tags.user_id IS 'User having the expertise';
+COMMENT ON COLUMN user_tags.tag IS 'Normalized (lowercase) tag';
+
+
+-- Tags a PR needs reviewed
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS required_tags TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN pull_requests.required_tags IS 'Tags each of which must be covered by at least one assigned reviewer';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS required_tags;
+DROP TABLE IF EXISTS user_tags;
+-- +goose StatementEnd
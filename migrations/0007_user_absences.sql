@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Out-of-office schedules
+CREATE TABLE IF NOT EXISTS absences (
+    absence_id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    applied BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_absences_user_period ON absences(user_id, starts_at, ends_at) WHERE cancelled_at IS NULL;
+
+COMMENT ON TABLE absences IS 'Date ranges during which users are away and must not get reviews';
+COMMENT ON COLUMN absences.absence_id IS 'Unique identifier of the absence';
+COMMENT ON COLUMN absences.user_id IS 'Absent user';
+COMMENT ON COLUMN absences.starts_at IS 'Start of the absence, inclusive';
+COMMENT ON COLUMN absences.ends_at IS 'End of the absence, exclusive';
+COMMENT ON COLUMN absences.reason IS 'Free-form reason (vacation, sick leave, ...)';
+COMMENT ON COLUMN absences.cancelled_at IS 'When the absence was cancelled, NULL if it was not';
+COMMENT ON COLUMN absences.applied IS 'is_active of the user was switched off for this absence and has to be switched back on';
+COMMENT ON COLUMN absences.created_at IS 'Absence creation timestamp';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS absences;
+-- +goose StatementEnd
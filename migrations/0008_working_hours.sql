@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Timezone and weekly working hours of users
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS working_hours JSONB NOT NULL DEFAULT '[]';
+
+COMMENT ON COLUMN users.timezone IS 'IANA timezone working hours are given in';
+COMMENT ON COLUMN users.working_hours IS 'Weekly schedule: [{day, start, end}], empty means always available';
+
+
+-- Working hours policy of teams
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS working_hours_policy VARCHAR(16) NOT NULL DEFAULT 'IGNORE';
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS working_hours_window INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN team_settings.working_hours_policy IS 'IGNORE, PREFER or REQUIRE reviewers available by working hours';
+COMMENT ON COLUMN team_settings.working_hours_window IS 'Hours ahead in which a reviewer starting work still counts as available';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE team_settings DROP COLUMN IF EXISTS working_hours_window;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS working_hours_policy;
+ALTER TABLE users DROP COLUMN IF EXISTS working_hours;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Decision traces explaining reviewer assignment
+CREATE TABLE IF NOT EXISTS assignment_traces (
+    trace_id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    action VARCHAR(32) NOT NULL,
+    replaced_user_id VARCHAR(255),
+    strategy VARCHAR(32) NOT NULL DEFAULT '',
+    selected TEXT[] NOT NULL DEFAULT '{}',
+    excluded JSONB NOT NULL DEFAULT '[]',
+    rounds JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_traces_pr ON assignment_traces(pull_request_id, trace_id);
+
+COMMENT ON TABLE assignment_traces IS 'Why reviewers of a PR were chosen, one row per change of reviewers';
+COMMENT ON COLUMN assignment_traces.trace_id IS 'Unique identifier of the trace';
+COMMENT ON COLUMN assignment_traces.pull_request_id IS 'PR whose reviewers changed';
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN or MANUAL_ADD';
+COMMENT ON COLUMN assignment_traces.replaced_user_id IS 'Reviewer taken off the PR by a reassignment';
+COMMENT ON COLUMN assignment_traces.strategy IS 'Selection strategy used, empty for manual changes';
+COMMENT ON COLUMN assignment_traces.selected IS 'Reviewers put on the PR';
+COMMENT ON COLUMN assignment_traces.excluded IS 'Team members left out: [{user_id, step, reason}]';
+COMMENT ON COLUMN assignment_traces.rounds IS 'Strategy calls: [{step, wanted, candidates: [{user_id, load, selected, tie_break}]}]';
+COMMENT ON COLUMN assignment_traces.created_at IS 'When the decision was made';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS assignment_traces;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Pairs of users that must never review each other's PRs
+CREATE TABLE IF NOT EXISTS team_never_pairs (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    user_a VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    user_b VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (team_name, user_a, user_b),
+    CHECK (user_a < user_b)
+);
+
+COMMENT ON TABLE team_never_pairs IS 'Hard constraints: neither user of a pair reviews PRs of the other';
+COMMENT ON COLUMN team_never_pairs.team_name IS 'Team whose reviewer selection applies the rule';
+COMMENT ON COLUMN team_never_pairs.user_a IS 'First user of the pair, the smaller id';
+COMMENT ON COLUMN team_never_pairs.user_b IS 'Second user of the pair, the greater id';
+
+
+-- Soft penalty for reviewing the same author again and again
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS author_spread_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS author_spread_penalty INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN team_settings.author_spread_days IS 'Days of history in which reviews of the same author are penalized, 0 disables';
+COMMENT ON COLUMN team_settings.author_spread_penalty IS 'Load points added per recent review of the same author, 0 disables';
+
+
+-- Recent reviews of an author are looked up by assignment time
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_user_assigned ON pr_reviewers(user_id, assigned_at);
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_pr_reviewers_user_assigned;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS author_spread_penalty;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS author_spread_days;
+DROP TABLE IF EXISTS team_never_pairs;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Assignments within a sliding window count towards the load of a candidate
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS fairness_window_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS fairness_weight INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN team_settings.fairness_window_days IS 'Days of assignment history added to open load, 0 disables';
+COMMENT ON COLUMN team_settings.fairness_weight IS 'Load points added per assignment within the window, 0 disables';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE team_settings DROP COLUMN IF EXISTS fairness_weight;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS fairness_window_days;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Optional size metadata of PRs and the weight each PR adds to its reviewers' load
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS lines_added INTEGER;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS lines_removed INTEGER;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS files_changed INTEGER;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS review_weight INTEGER NOT NULL DEFAULT 1 CHECK (review_weight >= 1);
+
+COMMENT ON COLUMN pull_requests.lines_added IS 'Lines added by the PR, NULL when the size is unknown';
+COMMENT ON COLUMN pull_requests.lines_removed IS 'Lines removed by the PR, NULL when the size is unknown';
+COMMENT ON COLUMN pull_requests.files_changed IS 'Files changed by the PR, NULL when the size is unknown';
+COMMENT ON COLUMN pull_requests.review_weight IS 'Load the PR adds to each reviewer while open, fixed at creation';
+
+
+-- How a team weighs its new PRs
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS load_weighting VARCHAR(16) NOT NULL DEFAULT 'COUNT';
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS weight_lines_step INTEGER NOT NULL DEFAULT 500;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS weight_files_step INTEGER NOT NULL DEFAULT 20;
+
+COMMENT ON COLUMN team_settings.load_weighting IS 'COUNT counts every PR as 1, SIZE weighs PRs by explicit weight or size';
+COMMENT ON COLUMN team_settings.weight_lines_step IS 'Changed lines per extra weight point under SIZE weighting, 0 ignores lines';
+COMMENT ON COLUMN team_settings.weight_files_step IS 'Changed files per extra weight point under SIZE weighting, 0 ignores files';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE team_settings DROP COLUMN IF EXISTS weight_files_step;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS weight_lines_step;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS load_weighting;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS review_weight;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS files_changed;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS lines_removed;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS lines_added;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Caps on open reviews of users and teams
+ALTER TABLE users ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER CHECK (max_open_reviews >= 1);
+
+COMMENT ON COLUMN users.max_open_reviews IS 'Cap on open reviews of the user, NULL leaves it to the team settings';
+
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS cap_policy VARCHAR(16) NOT NULL DEFAULT 'EXCEED';
+
+COMMENT ON COLUMN team_settings.max_open_reviews IS 'Cap on open reviews of members without their own cap, 0 disables';
+COMMENT ON COLUMN team_settings.cap_policy IS 'QUEUE, EXCEED or FAIL when every candidate is at its cap';
+
+
+-- PRs waiting for a reviewer slot to free up
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS awaiting_reviewer BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN pull_requests.awaiting_reviewer IS 'Reviewer slots were left empty because every candidate was at its cap';
+
+
+-- Open load of many users is read in one query
+CREATE INDEX IF NOT EXISTS idx_pull_requests_open ON pull_requests(pull_request_id) WHERE status = 'OPEN';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_pull_requests_open;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS awaiting_reviewer;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS cap_policy;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS max_open_reviews;
+ALTER TABLE users DROP COLUMN IF EXISTS max_open_reviews;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- OPEN PRs waiting for reviewers, kept in line with the PR flags on every write
+CREATE TABLE IF NOT EXISTS pending_assignments (
+    pull_request_id VARCHAR(255) PRIMARY KEY REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    reason VARCHAR(32) NOT NULL CHECK (reason IN ('UNDERSTAFFED', 'AWAITING_REVIEWER')),
+    enqueued_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_attempt_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_assignments_enqueued ON pending_assignments(enqueued_at);
+
+COMMENT ON TABLE pending_assignments IS 'Queue of OPEN PRs with reviewer slots left empty, retried by the staffing worker';
+COMMENT ON COLUMN pending_assignments.pull_request_id IS 'PR waiting for reviewers';
+COMMENT ON COLUMN pending_assignments.reason IS 'UNDERSTAFFED or AWAITING_REVIEWER (every candidate at its cap)';
+COMMENT ON COLUMN pending_assignments.enqueued_at IS 'When the PR started waiting';
+COMMENT ON COLUMN pending_assignments.attempts IS 'Retries that could not fill every slot';
+COMMENT ON COLUMN pending_assignments.last_attempt_at IS 'Time of the last retry, NULL before the first one';
+
+
+-- PRs left short before the queue existed
+INSERT INTO pending_assignments (pull_request_id, reason, enqueued_at)
+SELECT pull_request_id, CASE WHEN awaiting_reviewer THEN 'AWAITING_REVIEWER' ELSE 'UNDERSTAFFED' END, created_at
+FROM pull_requests
+WHERE status = 'OPEN' AND (understaffed OR awaiting_reviewer)
+ON CONFLICT (pull_request_id) DO NOTHING;
+
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD or RETRY (staffed from the pending queue)';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN or MANUAL_ADD';
+DROP TABLE IF EXISTS pending_assignments;
+-- +goose StatementEnd
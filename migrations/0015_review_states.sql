@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Latest review state of every assigned reviewer
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS review_state VARCHAR(32) NOT NULL DEFAULT 'PENDING'
+    CHECK (review_state IN ('PENDING', 'APPROVED', 'CHANGES_REQUESTED', 'COMMENTED'));
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+COMMENT ON COLUMN pr_reviewers.review_state IS 'PENDING until the reviewer submits APPROVED, CHANGES_REQUESTED or COMMENTED';
+COMMENT ON COLUMN pr_reviewers.reviewed_at IS 'When the latest review was submitted, NULL while PENDING';
+
+
+-- Approvals required before merging
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS merge_approvals VARCHAR(16) NOT NULL DEFAULT 'NONE';
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS approval_quorum INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN team_settings.merge_approvals IS 'NONE, ALL (every assigned reviewer) or QUORUM (approval_quorum reviewers) must approve before merge';
+COMMENT ON COLUMN team_settings.approval_quorum IS 'Approvals needed with the QUORUM merge requirement';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE team_settings DROP COLUMN IF EXISTS approval_quorum;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS merge_approvals;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS review_state;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- DRAFT PRs wait to be marked ready, CLOSED ones were abandoned without merging
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check CHECK (status IN ('DRAFT', 'OPEN', 'MERGED', 'CLOSED'));
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+
+COMMENT ON COLUMN pull_requests.status IS 'Current state: DRAFT, OPEN, MERGED or CLOSED, only OPEN PRs count as reviewer load';
+COMMENT ON COLUMN pull_requests.closed_at IS 'Timestamp when PR was closed without merging (null unless CLOSED)';
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD, RETRY (staffed from the pending queue), READY or REOPEN';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+-- Without the new states drafts and abandoned PRs are open again
+UPDATE pull_requests SET status = 'OPEN' WHERE status IN ('DRAFT', 'CLOSED');
+
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD or RETRY (staffed from the pending queue)';
+COMMENT ON COLUMN pull_requests.status IS 'Current state: OPEN or MERGED';
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check CHECK (status IN ('OPEN', 'MERGED'));
+-- +goose StatementEnd
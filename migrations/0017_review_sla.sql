@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Review SLA of a team
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS review_sla_hours INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS sla_business_hours BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE team_settings ADD COLUMN IF NOT EXISTS sla_escalation VARCHAR(16) NOT NULL DEFAULT 'NOTIFY';
+
+COMMENT ON COLUMN team_settings.review_sla_hours IS 'Hours a reviewer has to submit a review, 0 disables the SLA';
+COMMENT ON COLUMN team_settings.sla_business_hours IS 'Count the SLA in the reviewer''s working hours instead of calendar hours';
+COMMENT ON COLUMN team_settings.sla_escalation IS 'NOTIFY (event only), ADD_REVIEWER or REASSIGN on a breach';
+
+
+-- Reviews that missed the SLA, one row per assignment of a reviewer
+CREATE TABLE IF NOT EXISTS review_sla_breaches (
+    breach_id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    team_name VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMP NOT NULL,
+    deadline TIMESTAMP NOT NULL,
+    detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    escalation VARCHAR(16) NOT NULL CHECK (escalation IN ('NOTIFY', 'ADD_REVIEWER', 'REASSIGN')),
+    escalated_to VARCHAR(255),
+    escalation_error TEXT,
+    UNIQUE (pull_request_id, user_id, assigned_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_sla_breaches_team_detected ON review_sla_breaches(team_name, detected_at);
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_state_assigned ON pr_reviewers(review_state, assigned_at);
+
+COMMENT ON TABLE review_sla_breaches IS 'Reviewers who did not review within the team''s SLA, written by the SLA worker';
+COMMENT ON COLUMN review_sla_breaches.pull_request_id IS 'PR the late review belongs to';
+COMMENT ON COLUMN review_sla_breaches.user_id IS 'Late reviewer';
+COMMENT ON COLUMN review_sla_breaches.team_name IS 'Team of the PR author whose SLA was breached';
+COMMENT ON COLUMN review_sla_breaches.assigned_at IS 'When the late reviewer was assigned, a later assignment is checked again';
+COMMENT ON COLUMN review_sla_breaches.deadline IS 'When the review was due';
+COMMENT ON COLUMN review_sla_breaches.detected_at IS 'When the worker noticed the breach';
+COMMENT ON COLUMN review_sla_breaches.escalation IS 'Escalation applied, taken from the team settings at detection';
+COMMENT ON COLUMN review_sla_breaches.escalated_to IS 'Reviewer added or put in place of the late one';
+COMMENT ON COLUMN review_sla_breaches.escalation_error IS 'Why the escalation failed, NULL when it succeeded';
+
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD, RETRY (staffed from the pending queue), READY, REOPEN or ESCALATE (reviewer added on a SLA breach)';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD, RETRY (staffed from the pending queue), READY or REOPEN';
+DROP INDEX IF EXISTS idx_pr_reviewers_state_assigned;
+DROP TABLE IF EXISTS review_sla_breaches;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS sla_escalation;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS sla_business_hours;
+ALTER TABLE team_settings DROP COLUMN IF EXISTS review_sla_hours;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Code host metadata of PRs
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS repository VARCHAR(255);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS url TEXT;
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS base_branch VARCHAR(255);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS labels TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS description TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_repository ON pull_requests(repository, created_at);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_labels ON pull_requests USING GIN (labels);
+
+COMMENT ON COLUMN pull_requests.repository IS 'Repository of the PR on its code host ("owner/name"), NULL when unknown';
+COMMENT ON COLUMN pull_requests.url IS 'Link to the PR on its code host';
+COMMENT ON COLUMN pull_requests.base_branch IS 'Branch the PR is to be merged into';
+COMMENT ON COLUMN pull_requests.labels IS 'Normalized (lowercase) labels, matched by team label rules';
+COMMENT ON COLUMN pull_requests.description IS 'PR description';
+
+
+-- Labels asking for a reviewer of another team
+CREATE TABLE IF NOT EXISTS team_label_rules (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    label VARCHAR(100) NOT NULL,
+    reviewer_team VARCHAR(255) NOT NULL REFERENCES teams(team_name) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (team_name, label, reviewer_team)
+);
+
+COMMENT ON TABLE team_label_rules IS 'PRs of team_name having label get a reviewer of reviewer_team on top of the reviewer count';
+COMMENT ON COLUMN team_label_rules.team_name IS 'Team of the PR authors the rule applies to';
+COMMENT ON COLUMN team_label_rules.label IS 'Normalized (lowercase) label';
+COMMENT ON COLUMN team_label_rules.reviewer_team IS 'Team the extra reviewer is taken from';
+COMMENT ON COLUMN team_label_rules.position IS 'Order the rules are applied in, starting with 1';
+
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD, RETRY (staffed from the pending queue), READY, REOPEN, ESCALATE (reviewer added on a SLA breach) or LABEL (reviewer added for new labels)';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+COMMENT ON COLUMN assignment_traces.action IS 'CREATE, REASSIGN, MANUAL_REASSIGN, MANUAL_ADD, RETRY (staffed from the pending queue), READY, REOPEN or ESCALATE (reviewer added on a SLA breach)';
+DROP TABLE IF EXISTS team_label_rules;
+DROP INDEX IF EXISTS idx_pull_requests_labels;
+DROP INDEX IF EXISTS idx_pull_requests_repository;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS description;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS labels;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS base_branch;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS url;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS repository;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Accounts of code hosts whose webhooks drive PRs, mapped to users of the service
+CREATE TABLE IF NOT EXISTS code_host_logins (
+    code_host VARCHAR(20) NOT NULL CHECK (code_host IN ('GITHUB')),
+    login VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code_host, login)
+);
+
+CREATE INDEX IF NOT EXISTS idx_code_host_logins_user ON code_host_logins(user_id);
+
+COMMENT ON TABLE code_host_logins IS 'Code host accounts mapped to users, webhooks resolve PR authors through it';
+COMMENT ON COLUMN code_host_logins.code_host IS 'Code host of the account: GITHUB';
+COMMENT ON COLUMN code_host_logins.login IS 'Lowercased login on the code host';
+COMMENT ON COLUMN code_host_logins.user_id IS 'User the account belongs to';
+COMMENT ON COLUMN code_host_logins.created_at IS 'When the mapping was saved';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS code_host_logins;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE code_host_logins DROP CONSTRAINT IF EXISTS code_host_logins_code_host_check;
+ALTER TABLE code_host_logins ADD CONSTRAINT code_host_logins_code_host_check
+    CHECK (code_host IN ('GITHUB', 'GITLAB', 'GITEA'));
+
+COMMENT ON COLUMN code_host_logins.code_host IS 'Code host of the account: GITHUB, GITLAB or GITEA';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM code_host_logins WHERE code_host <> 'GITHUB';
+
+ALTER TABLE code_host_logins DROP CONSTRAINT IF EXISTS code_host_logins_code_host_check;
+ALTER TABLE code_host_logins ADD CONSTRAINT code_host_logins_code_host_check
+    CHECK (code_host IN ('GITHUB'));
+
+COMMENT ON COLUMN code_host_logins.code_host IS 'Code host of the account: GITHUB';
+-- +goose StatementEnd
@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- URLs subscribed to domain events of the service
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id BIGSERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE webhook_subscriptions IS 'Subscribers of outbound webhooks and the events they receive';
+COMMENT ON COLUMN webhook_subscriptions.url IS 'HTTP(S) URL deliveries are POSTed to';
+COMMENT ON COLUMN webhook_subscriptions.secret IS 'Key of the HMAC-SHA256 signature of every delivery';
+COMMENT ON COLUMN webhook_subscriptions.events IS 'Event types delivered to the subscriber, e.g. pr.created';
+COMMENT ON COLUMN webhook_subscriptions.created_at IS 'When the subscription was registered';
+
+
+-- Delivery log, one row per event sent to a subscription
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(subscription_id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    response_status INTEGER,
+    last_error TEXT,
+    replay_of BIGINT REFERENCES webhook_deliveries(delivery_id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, delivery_id);
+
+COMMENT ON TABLE webhook_deliveries IS 'Outbound webhook deliveries with their attempts, kept for querying and replay';
+COMMENT ON COLUMN webhook_deliveries.subscription_id IS 'Subscription the event is delivered to';
+COMMENT ON COLUMN webhook_deliveries.event_type IS 'Type of the delivered event';
+COMMENT ON COLUMN webhook_deliveries.payload IS 'Event as it is signed and sent';
+COMMENT ON COLUMN webhook_deliveries.status IS 'PENDING (waiting for an attempt), DELIVERED (2xx received) or FAILED (out of attempts)';
+COMMENT ON COLUMN webhook_deliveries.attempts IS 'Attempts made so far';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS 'When a PENDING delivery is due, pushed forward while a worker sends it';
+COMMENT ON COLUMN webhook_deliveries.last_attempt_at IS 'When the last attempt was made';
+COMMENT ON COLUMN webhook_deliveries.response_status IS 'HTTP status of the last attempt, NULL when no response came';
+COMMENT ON COLUMN webhook_deliveries.last_error IS 'Why the last attempt failed';
+COMMENT ON COLUMN webhook_deliveries.replay_of IS 'Delivery this one replays';
+COMMENT ON COLUMN webhook_deliveries.created_at IS 'When the delivery was queued';
+COMMENT ON COLUMN webhook_deliveries.delivered_at IS 'When the subscriber accepted the delivery';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Domain events written together with the change they describe, drained by the outbox dispatcher
+CREATE TABLE IF NOT EXISTS outbox_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DISPATCHED', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_error TEXT,
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(aggregate_type, aggregate_id, event_id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dead ON outbox_events(event_id) WHERE status = 'DEAD';
+
+COMMENT ON TABLE outbox_events IS 'Transactional outbox: events are dispatched to the sinks at least once, in order per aggregate';
+COMMENT ON COLUMN outbox_events.aggregate_type IS 'Kind of the entity the event is about: pull_request or user';
+COMMENT ON COLUMN outbox_events.aggregate_id IS 'Id of the entity, events of one entity are dispatched one after another';
+COMMENT ON COLUMN outbox_events.event_type IS 'Event type, e.g. reviewer.assigned';
+COMMENT ON COLUMN outbox_events.payload IS 'Data of the event';
+COMMENT ON COLUMN outbox_events.occurred_at IS 'When the change happened';
+COMMENT ON COLUMN outbox_events.status IS 'PENDING (waiting for dispatch), DISPATCHED (every sink accepted it) or DEAD (out of attempts)';
+COMMENT ON COLUMN outbox_events.attempts IS 'Failed and successful dispatch attempts made so far';
+COMMENT ON COLUMN outbox_events.next_attempt_at IS 'When a PENDING event is due, pushed forward while a dispatcher holds it';
+COMMENT ON COLUMN outbox_events.last_error IS 'Why the last dispatch failed';
+COMMENT ON COLUMN outbox_events.dispatched_at IS 'When every sink accepted the event';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Append-only log of changes: who did what to which entity, with the entity before and after
+CREATE TABLE IF NOT EXISTS audit_events (
+    audit_id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    before_state JSONB,
+    after_state JSONB,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events(entity_type, entity_id, audit_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor, audit_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+
+-- Entries are never changed or removed
+CREATE OR REPLACE FUNCTION reject_audit_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_change();
+
+COMMENT ON TABLE audit_events IS 'Append-only audit log, updates and deletes are rejected';
+COMMENT ON COLUMN audit_events.actor IS 'Who made the change: X-Actor of the request, webhook:<code host> or system';
+COMMENT ON COLUMN audit_events.action IS 'What was done, e.g. reviewer.reassigned';
+COMMENT ON COLUMN audit_events.entity_type IS 'Kind of the changed entity: team, user or pull_request';
+COMMENT ON COLUMN audit_events.entity_id IS 'Id of the changed entity';
+COMMENT ON COLUMN audit_events.before_state IS 'The entity before the change, NULL for created entities';
+COMMENT ON COLUMN audit_events.after_state IS 'The entity after the change';
+COMMENT ON COLUMN audit_events.created_at IS 'When the change happened';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS reject_audit_change();
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- When the absence switched the user off, the same value goes to users.updated_at
+ALTER TABLE absences ADD COLUMN IF NOT EXISTS applied_at TIMESTAMP;
+
+-- Users still off since their absence switched them off
+UPDATE absences a SET applied_at = u.updated_at
+FROM users u
+WHERE a.applied AND u.user_id = a.user_id AND NOT u.is_active;
+
+COMMENT ON COLUMN absences.applied_at IS 'users.updated_at written when this absence switched the user off, a later change of the user (e.g. a deactivation by hand) keeps the user off after the absence';
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE absences DROP COLUMN IF EXISTS applied_at;
+-- +goose StatementEnd
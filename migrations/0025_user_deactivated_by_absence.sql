@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+
+-- Users switched off by an absence, the availability worker switches back on only them
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_by_absence BOOLEAN NOT NULL DEFAULT false;
+
+-- Users still off since their absence switched them off
+UPDATE users u SET deactivated_by_absence = true
+FROM absences a
+WHERE a.applied AND a.user_id = u.user_id AND NOT u.is_active AND u.updated_at = a.applied_at;
+
+COMMENT ON COLUMN users.deactivated_by_absence IS 'is_active was switched off by an absence, cleared when the flag is set by hand';
+
+-- The marker replaces matching users.updated_at against the time the absence switched the user off
+ALTER TABLE absences DROP COLUMN IF EXISTS applied_at;
+-- +goose StatementEnd
+
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE absences ADD COLUMN IF NOT EXISTS applied_at TIMESTAMP;
+
+UPDATE absences a SET applied_at = u.updated_at
+FROM users u
+WHERE a.applied AND u.user_id = a.user_id AND u.deactivated_by_absence;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deactivated_by_absence;
+-- +goose StatementEnd
@@ -2,9 +2,12 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/repository/postgres"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -31,7 +34,7 @@ func getTestDB(t *testing.T) *pgxpool.Pool {
 
 func cleanDB(t *testing.T, pool *pgxpool.Pool) {
 	_, err := pool.Exec(context.Background(), `
-        TRUNCATE TABLE pr_reviewers, pull_requests, users, teams CASCADE
+        TRUNCATE TABLE audit_events, outbox_events, webhook_deliveries, webhook_subscriptions, code_host_logins, team_label_rules, review_sla_breaches, pending_assignments, team_never_pairs, assignment_traces, pr_reviewers, pull_requests, absences, user_tags, users, ownership_rules, team_fallbacks, team_settings, teams CASCADE
     `)
 	require.NoError(t, err)
 }
@@ -58,10 +61,52 @@ func TestTeamRepository_Integration(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "backend", retrieved.TeamName)
 
+	assert.Equal(t, models.StrategyLeastLoaded, retrieved.ReviewerStrategy)
+
 	// Check exists
 	exists, err := repo.Exists(ctx, "backend")
 	assert.NoError(t, err)
 	assert.True(t, exists)
+
+	// Update strategy
+	err = repo.UpdateStrategy(ctx, "backend", models.StrategyRoundRobin)
+	assert.NoError(t, err)
+
+	retrieved, err = repo.GetByName(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, models.StrategyRoundRobin, retrieved.ReviewerStrategy)
+
+	// Settings default until saved
+	settings, err := repo.GetSettings(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, models.DefaultReviewerCount, settings.ReviewerCount)
+	assert.Equal(t, models.UnderstaffedAllow, settings.UnderstaffedPolicy)
+	assert.Equal(t, models.StrategyRoundRobin, settings.ReviewerStrategy)
+
+	require.NoError(t, repo.Create(ctx, models.NewTeam("platform", []models.TeamMember{})))
+	require.NoError(t, repo.Create(ctx, models.NewTeam("frontend", []models.TeamMember{})))
+
+	settings.ReviewerCount = 3
+	settings.UnderstaffedPolicy = models.UnderstaffedFallback
+	settings.FallbackTeams = []string{"platform", "frontend"}
+	assert.NoError(t, repo.SaveSettings(ctx, settings))
+
+	settings, err = repo.GetSettings(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, settings.ReviewerCount)
+	assert.Equal(t, models.UnderstaffedFallback, settings.UnderstaffedPolicy)
+	assert.Equal(t, []string{"platform", "frontend"}, settings.FallbackTeams)
+
+	// Ownership rules keep their order
+	rules := []models.OwnershipRule{
+		{Pattern: "*", OwnerUsers: []string{}, OwnerTeams: []string{"backend"}},
+		{Pattern: "/docs/", OwnerUsers: []string{"u5"}, OwnerTeams: []string{}},
+	}
+	assert.NoError(t, repo.ReplaceOwnershipRules(ctx, "backend", rules))
+
+	storedRules, err := repo.GetOwnershipRules(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, rules, storedRules)
 }
 
 func TestUserRepository_Integration(t *testing.T) {
@@ -101,6 +146,29 @@ func TestUserRepository_Integration(t *testing.T) {
 	retrieved, err = userRepo.GetByID(ctx, "u1")
 	assert.NoError(t, err)
 	assert.False(t, retrieved.IsActive)
+
+	// Tags
+	require.NoError(t, userRepo.ReplaceTags(ctx, "u1", []string{"sql", "k8s"}))
+	require.NoError(t, userRepo.AddTags(ctx, "u1", []string{"frontend", "sql"}))
+	require.NoError(t, userRepo.RemoveTags(ctx, "u1", []string{"k8s"}))
+
+	tags, err := userRepo.GetTags(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"frontend", "sql"}, tags)
+
+	retrieved, err = userRepo.GetByID(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"frontend", "sql"}, retrieved.Tags)
+
+	// Working hours
+	retrieved.Timezone = "Europe/Berlin"
+	retrieved.WorkingHours = models.WorkingHours{{Day: "MON", Start: "09:00", End: "18:00"}}
+	require.NoError(t, userRepo.Update(ctx, retrieved))
+
+	updated, err := userRepo.GetByID(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", updated.Timezone)
+	assert.Equal(t, retrieved.WorkingHours, updated.WorkingHours)
 }
 
 func TestPRRepository_Integration(t *testing.T) {
@@ -127,9 +195,14 @@ func TestPRRepository_Integration(t *testing.T) {
 	reviewer := models.NewUser("u2", "Sasha", "backend", true)
 	require.NoError(t, userRepo.Create(ctx, reviewer))
 
+	fallbackReviewer := models.NewUser("u3", "Eve", "backend", true)
+	require.NoError(t, userRepo.Create(ctx, fallbackReviewer))
+
 	// Create PR
 	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
 	pr.AddReviewer("u2")
+	pr.AddFallbackReviewer("u3")
+	pr.ChangedFiles = []string{"internal/service/pr_service.go"}
 	err := prRepo.Create(ctx, pr)
 	assert.NoError(t, err)
 
@@ -137,11 +210,888 @@ func TestPRRepository_Integration(t *testing.T) {
 	retrieved, err := prRepo.GetByID(ctx, "pr-1")
 	assert.NoError(t, err)
 	assert.Equal(t, "Test PR", retrieved.PullRequestName)
-	assert.Equal(t, 1, len(retrieved.AssignedReviewers))
+	assert.Equal(t, 2, len(retrieved.AssignedReviewers))
 	assert.Contains(t, retrieved.AssignedReviewers, "u2")
+	assert.Equal(t, []string{"u3"}, retrieved.FallbackReviewers)
+	assert.Equal(t, pr.ChangedFiles, retrieved.ChangedFiles)
 
 	// Get by reviewer
 	prs, err := prRepo.GetByReviewer(ctx, "u2")
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(prs))
+	assert.Equal(t, models.ReviewPending, prs[0].ReviewState("u2"))
+
+	// Review states survive updates of the reviewer list
+	reviewedAt := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, prRepo.SubmitReview(ctx, "pr-1", "u2", models.ReviewApproved, reviewedAt))
+	assert.ErrorIs(t, prRepo.SubmitReview(ctx, "pr-1", "u1", models.ReviewApproved, reviewedAt), apperrors.ErrNotAssigned)
+	require.NoError(t, prRepo.Update(ctx, retrieved))
+
+	retrieved, err = prRepo.GetByID(ctx, "pr-1")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReviewApproved, retrieved.ReviewState("u2"))
+	assert.True(t, reviewedAt.Equal(*retrieved.ReviewOf("u2").SubmittedAt))
+	assert.Equal(t, models.ReviewPending, retrieved.ReviewState("u3"))
+	assert.Equal(t, 1, retrieved.Approvals())
+
+	// Recent reviews of the author
+	counts, err := userRepo.GetAuthorReviewCounts(ctx, "u1", []string{"u2", "u3", "u1"}, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"u2": 1, "u3": 1, "u1": 0}, counts)
+
+	counts, err = userRepo.GetAuthorReviewCounts(ctx, "u1", []string{"u2"}, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, counts["u2"])
+
+	// Open load is weighted, the raw count is not
+	bigPR := models.NewPullRequest("pr-2", "Big PR", "u1")
+	bigPR.AddReviewer("u2")
+	bigPR.Size = &models.PRSize{LinesAdded: 1200, LinesRemoved: 300, FilesChanged: 12}
+	bigPR.ReviewWeight = 4
+	require.NoError(t, prRepo.Create(ctx, bigPR))
+
+	retrieved, err = prRepo.GetByID(ctx, "pr-2")
+	assert.NoError(t, err)
+	assert.Equal(t, bigPR.Size, retrieved.Size)
+	assert.Equal(t, 4, retrieved.ReviewWeight)
+	assert.False(t, retrieved.AwaitingReviewer)
+
+	retrieved.AwaitingReviewer = true
+	require.NoError(t, prRepo.Update(ctx, retrieved))
+
+	retrieved, err = prRepo.GetByID(ctx, "pr-2")
+	assert.NoError(t, err)
+	assert.True(t, retrieved.AwaitingReviewer)
+
+	load, err := userRepo.GetReviewerLoad(ctx, []string{"u2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, load["u2"])
+
+	loads, err := userRepo.GetReviewerLoads(ctx, []string{"u2", "u3"})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReviewerLoad{Open: 2, Weighted: 5}, loads["u2"])
+	assert.Equal(t, models.ReviewerLoad{Open: 1, Weighted: 1}, loads["u3"])
+
+	// Assignments within a fairness window
+	counts, err = userRepo.GetAssignmentCounts(ctx, []string{"u2", "u1"}, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"u2": 2, "u1": 0}, counts)
+
+	// Never pair rules are stored with the team settings
+	settings, err := teamRepo.GetSettings(ctx, "backend")
+	require.NoError(t, err)
+
+	settings.NeverPair = []models.ReviewerPair{{UserA: "u2", UserB: "u1"}}
+	settings.AuthorSpreadDays = 30
+	settings.AuthorSpreadPenalty = 2
+	settings.FairnessWindowDays = 14
+	settings.FairnessWeight = 3
+	settings.LoadWeighting = models.LoadWeightingSize
+	settings.WeightLinesStep = 250
+	settings.MaxOpenReviews = 4
+	settings.CapPolicy = models.CapQueue
+	settings.MergeApprovals = models.MergeApprovalsQuorum
+	settings.ApprovalQuorum = 2
+	require.NoError(t, teamRepo.SaveSettings(ctx, settings))
+
+	settings, err = teamRepo.GetSettings(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ReviewerPair{{UserA: "u1", UserB: "u2"}}, settings.NeverPair)
+	assert.Equal(t, 30, settings.AuthorSpreadDays)
+	assert.Equal(t, 2, settings.AuthorSpreadPenalty)
+	assert.Equal(t, 14, settings.FairnessWindowDays)
+	assert.Equal(t, 3, settings.FairnessWeight)
+	assert.Equal(t, models.LoadWeightingSize, settings.LoadWeighting)
+	assert.Equal(t, 250, settings.WeightLinesStep)
+	assert.Equal(t, models.DefaultWeightFilesStep, settings.WeightFilesStep)
+	assert.Equal(t, 4, settings.MaxOpenReviews)
+	assert.Equal(t, models.CapQueue, settings.CapPolicy)
+	assert.Equal(t, models.MergeApprovalsQuorum, settings.MergeApprovals)
+	assert.Equal(t, 2, settings.ApprovalQuorum)
+
+	// A user's own cap
+	limit := 2
+	user, err := userRepo.GetByID(ctx, "u2")
+	require.NoError(t, err)
+	assert.Nil(t, user.MaxOpenReviews)
+
+	user.MaxOpenReviews = &limit
+	require.NoError(t, userRepo.Update(ctx, user))
+
+	user, err = userRepo.GetByID(ctx, "u2")
+	assert.NoError(t, err)
+	assert.Equal(t, &limit, user.MaxOpenReviews)
+}
+
+func TestAbsenceRepository_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	absenceRepo := postgres.NewAbsenceRepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+
+	now := time.Now()
+	absence := models.NewAbsence("u1", now.Add(-time.Hour), now.Add(time.Hour), "vacation")
+	require.NoError(t, absenceRepo.Create(ctx, absence))
+	assert.NotZero(t, absence.AbsenceID)
+
+	// Absent users are not candidates even before the flag is synced
+	candidates, err := userRepo.GetActiveByTeam(ctx, "backend", "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(candidates))
+	assert.Equal(t, "u2", candidates[0].UserID)
+
+	// The absence is over by the given time
+	candidates, err = userRepo.GetActiveByTeam(ctx, "backend", "", now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(candidates))
+
+	// Traces learn which filter left each member out
+	exclusions, err := userRepo.GetTeamExclusions(ctx, "backend", "u2", now)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TraceExclusion{
+		{UserID: "u1", Reason: models.ExcludedAbsent},
+		{UserID: "u2", Reason: models.ExcludedAuthor},
+	}, exclusions)
+
+	// Absence start switches the flag off
+	deactivated, restored, err := absenceRepo.SyncActiveFlags(ctx, now)
+	assert.NoError(t, err)
+	require.Len(t, deactivated, 1)
+	assert.Empty(t, restored)
+	assert.Equal(t, "u1", deactivated[0].After.UserID)
+	assert.True(t, deactivated[0].Before.IsActive)
+	assert.False(t, deactivated[0].After.IsActive)
+
+	user, err := userRepo.GetByID(ctx, "u1")
+	assert.NoError(t, err)
+	assert.False(t, user.IsActive)
+
+	// Absence end switches it back on
+	deactivated, restored, err = absenceRepo.SyncActiveFlags(ctx, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, deactivated)
+	require.Len(t, restored, 1)
+	assert.False(t, restored[0].Before.IsActive)
+	assert.True(t, restored[0].After.IsActive)
+
+	user, err = userRepo.GetByID(ctx, "u1")
+	assert.NoError(t, err)
+	assert.True(t, user.IsActive)
+
+	// A user deactivated by hand during the absence stays off after it
+	later := models.NewAbsence("u2", now.Add(-time.Hour), now.Add(time.Hour), "vacation")
+	require.NoError(t, absenceRepo.Create(ctx, later))
+
+	deactivated, _, err = absenceRepo.SyncActiveFlags(ctx, now)
+	assert.NoError(t, err)
+	assert.Len(t, deactivated, 1)
+
+	user, err = userRepo.GetByID(ctx, "u2")
+	assert.NoError(t, err)
+	user.SetActive(false)
+	require.NoError(t, userRepo.Update(ctx, user))
+
+	_, restored, err = absenceRepo.SyncActiveFlags(ctx, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, restored)
+
+	user, err = userRepo.GetByID(ctx, "u2")
+	assert.NoError(t, err)
+	assert.False(t, user.IsActive)
+
+	// Other changes of the user during the absence do not keep the user off after it
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u3", "Carol", "backend", true)))
+	require.NoError(t, absenceRepo.Create(ctx, models.NewAbsence("u3", now.Add(-time.Hour), now.Add(time.Hour), "vacation")))
+
+	deactivated, _, err = absenceRepo.SyncActiveFlags(ctx, now)
+	assert.NoError(t, err)
+	assert.Len(t, deactivated, 1)
+
+	user, err = userRepo.GetByID(ctx, "u3")
+	require.NoError(t, err)
+	assert.True(t, user.DeactivatedByAbsence)
+	user.WorkingHours = models.WorkingHours{{Day: "MON", Start: "09:00", End: "18:00"}}
+	user.UpdatedAt = time.Now()
+	require.NoError(t, userRepo.Update(ctx, user))
+
+	// A team upsert keeping the flag off keeps the marker too
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u3", "Carol", "backend", false)))
+
+	_, restored, err = absenceRepo.SyncActiveFlags(ctx, now.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, restored, 1)
+
+	user, err = userRepo.GetByID(ctx, "u3")
+	assert.NoError(t, err)
+	assert.True(t, user.IsActive)
+	assert.False(t, user.DeactivatedByAbsence)
+
+	// Cancel
+	require.NoError(t, absenceRepo.Cancel(ctx, absence.AbsenceID, now))
+	assert.ErrorIs(t, absenceRepo.Cancel(ctx, absence.AbsenceID, now), apperrors.ErrAbsenceNotFound)
+
+	absences, err := absenceRepo.ListByUser(ctx, "u1", time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(absences))
+	assert.NotNil(t, absences[0].CancelledAt)
+}
+
+func TestAssignmentTraceRepository_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+	traceRepo := postgres.NewAssignmentTraceRepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+	require.NoError(t, prRepo.Create(ctx, models.NewPullRequest("pr-1", "Test PR", "u1")))
+
+	trace := models.NewAssignmentTrace("pr-1", models.TraceActionCreate)
+	trace.Strategy = models.StrategyLeastLoaded
+	trace.Selected = []string{"u2"}
+	trace.Excluded = []models.TraceExclusion{{UserID: "u1", Step: models.StepTeam, Reason: models.ExcludedAuthor}}
+	trace.Rounds = []models.SelectionRound{{
+		Step:       models.StepTeam,
+		Wanted:     2,
+		Candidates: []models.TracedCandidate{{UserID: "u2", Load: 3, Selected: true}},
+	}}
+
+	require.NoError(t, traceRepo.Create(ctx, trace))
+	assert.NotZero(t, trace.TraceID)
+
+	traces, err := traceRepo.ListByPR(ctx, "pr-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(traces))
+	assert.Equal(t, models.TraceActionCreate, traces[0].Action)
+	assert.Empty(t, traces[0].ReplacedUserID)
+	assert.Equal(t, trace.Selected, traces[0].Selected)
+	assert.Equal(t, trace.Excluded, traces[0].Excluded)
+	assert.Equal(t, trace.Rounds, traces[0].Rounds)
+}
+
+func TestPendingAssignments_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("frontend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u3", "Carol", "frontend", true)))
+
+	// A short PR enters the queue on create, a staffed one does not
+	short := models.NewPullRequest("pr-1", "Short", "u1")
+	short.AddReviewer("u2")
+	short.Understaffed = true
+	require.NoError(t, prRepo.Create(ctx, short))
+	require.NoError(t, prRepo.Create(ctx, models.NewPullRequest("pr-2", "Staffed", "u3")))
+
+	pending, err := prRepo.ListPending(ctx, "")
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(pending))
+	assert.Equal(t, "pr-1", pending[0].PullRequestID)
+	assert.Equal(t, "backend", pending[0].TeamName)
+	assert.Equal(t, models.PendingUnderstaffed, pending[0].Reason)
+	assert.Equal(t, []string{"u2"}, pending[0].AssignedReviewers)
+	assert.Nil(t, pending[0].LastAttemptAt)
+
+	pending, err = prRepo.ListPending(ctx, "frontend")
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// Attempts are counted, a change of reason keeps them
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, prRepo.RecordPendingAttempt(ctx, "pr-1", now))
+
+	short.Understaffed = false
+	short.AwaitingReviewer = true
+	require.NoError(t, prRepo.Update(ctx, short))
+
+	pending, err = prRepo.ListPending(ctx, "backend")
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(pending))
+	assert.Equal(t, models.PendingAwaitingReviewer, pending[0].Reason)
+	assert.Equal(t, 1, pending[0].Attempts)
+	require.NotNil(t, pending[0].LastAttemptAt)
+	assert.True(t, now.Equal(*pending[0].LastAttemptAt))
+
+	// Merged PRs leave the queue
+	require.NoError(t, short.Merge())
+	require.NoError(t, prRepo.Update(ctx, short))
+
+	pending, err = prRepo.ListPending(ctx, "")
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestPRLifecycle_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+
+	draft := models.NewPullRequest("pr-1", "Draft", "u1")
+	draft.Status = models.PRStatusDraft
+	require.NoError(t, prRepo.Create(ctx, draft))
+
+	retrieved, err := prRepo.GetByID(ctx, "pr-1")
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusDraft, retrieved.Status)
+
+	// An abandoned PR no longer counts as load
+	require.NoError(t, retrieved.MarkReady())
+	retrieved.AddReviewer("u2")
+	require.NoError(t, prRepo.Update(ctx, retrieved))
+
+	load, err := userRepo.GetReviewerLoad(ctx, []string{"u2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, load["u2"])
+
+	require.NoError(t, retrieved.Close())
+	require.NoError(t, prRepo.Update(ctx, retrieved))
+
+	retrieved, err = prRepo.GetByID(ctx, "pr-1")
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusClosed, retrieved.Status)
+	assert.NotNil(t, retrieved.ClosedAt)
+	assert.Equal(t, []string{"u2"}, retrieved.AssignedReviewers)
+
+	load, err = userRepo.GetReviewerLoad(ctx, []string{"u2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, load["u2"])
+}
+
+func TestSLARepository_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+	slaRepo := postgres.NewSLARepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+
+	pr := models.NewPullRequest("pr-1", "Feature", "u1")
+	pr.AddReviewer("u2")
+	require.NoError(t, prRepo.Create(ctx, pr))
+
+	later := time.Now().Add(48 * time.Hour)
+
+	// Teams without a SLA are never checked
+	awaited, err := slaRepo.ListAwaitedReviews(ctx, later)
+	assert.NoError(t, err)
+	assert.Empty(t, awaited)
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewSLAHours = 24
+	settings.SLAEscalation = models.SLAEscalationReassign
+	settings.UpdatedAt = time.Now()
+	require.NoError(t, teamRepo.SaveSettings(ctx, settings))
+
+	saved, err := teamRepo.GetSettings(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, 24, saved.ReviewSLAHours)
+	assert.Equal(t, models.SLAEscalationReassign, saved.SLAEscalation)
+
+	awaited, err = slaRepo.ListAwaitedReviews(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, awaited)
+
+	awaited, err = slaRepo.ListAwaitedReviews(ctx, later)
+	assert.NoError(t, err)
+	require.Len(t, awaited, 1)
+	assert.Equal(t, "u2", awaited[0].UserID)
+	assert.Equal(t, "backend", awaited[0].TeamName)
+
+	// A breach is recorded once per assignment
+	breach := &models.SLABreach{
+		PullRequestID: "pr-1",
+		UserID:        "u2",
+		TeamName:      "backend",
+		AssignedAt:    awaited[0].AssignedAt,
+		Deadline:      awaited[0].AssignedAt.Add(24 * time.Hour),
+		DetectedAt:    later,
+		Escalation:    models.SLAEscalationReassign,
+	}
+
+	created, err := slaRepo.CreateBreach(ctx, breach)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = slaRepo.CreateBreach(ctx, &models.SLABreach{
+		PullRequestID: "pr-1", UserID: "u2", TeamName: "backend", AssignedAt: awaited[0].AssignedAt,
+		Deadline: breach.Deadline, DetectedAt: later, Escalation: models.SLAEscalationReassign,
+	})
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	require.NoError(t, slaRepo.ResolveBreach(ctx, breach.BreachID, "", "no candidate"))
+
+	awaited, err = slaRepo.ListAwaitedReviews(ctx, later)
+	assert.NoError(t, err)
+	assert.Empty(t, awaited)
+
+	breaches, err := slaRepo.ListBreaches(ctx, models.SLABreachFilter{TeamName: "backend"})
+	assert.NoError(t, err)
+	require.Len(t, breaches, 1)
+	assert.Equal(t, "no candidate", breaches[0].EscalationError)
+	assert.Empty(t, breaches[0].EscalatedTo)
+
+	breaches, err = slaRepo.ListBreaches(ctx, models.SLABreachFilter{UserID: "u1"})
+	assert.NoError(t, err)
+	assert.Empty(t, breaches)
+
+	breaches, err = slaRepo.ListBreaches(ctx, models.SLABreachFilter{PullRequestID: breach.PullRequestID})
+	assert.NoError(t, err)
+	assert.Len(t, breaches, 1)
+
+	breaches, err = slaRepo.ListBreaches(ctx, models.SLABreachFilter{PullRequestID: "pr-unknown"})
+	assert.NoError(t, err)
+	assert.Empty(t, breaches)
+}
+
+func TestPRMetadata_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("security", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+
+	// Label rules round-trip through team settings
+	settings := models.DefaultTeamSettings("backend")
+	settings.LabelRules = []models.LabelRule{{Label: "security", Team: "security"}}
+	settings.UpdatedAt = time.Now()
+	require.NoError(t, teamRepo.SaveSettings(ctx, settings))
+
+	saved, err := teamRepo.GetSettings(ctx, "backend")
+	assert.NoError(t, err)
+	assert.Equal(t, settings.LabelRules, saved.LabelRules)
+
+	// Metadata is stored with the PR
+	pr := models.NewPullRequest("pr-1", "Rotate keys", "u1")
+	pr.Repository = "acme/api"
+	pr.URL = "https://github.com/acme/api/pull/1"
+	pr.BaseBranch = "main"
+	pr.Labels = []string{"bug", "security"}
+	pr.Description = "Rotates signing keys"
+	pr.AddReviewer("u2")
+	require.NoError(t, prRepo.Create(ctx, pr))
+	require.NoError(t, prRepo.Create(ctx, models.NewPullRequest("pr-2", "Docs", "u1")))
+
+	loaded, err := prRepo.GetByID(ctx, "pr-1")
+	assert.NoError(t, err)
+	assert.Equal(t, pr.PRMetadata, loaded.PRMetadata)
+
+	plain, err := prRepo.GetByID(ctx, "pr-2")
+	assert.NoError(t, err)
+	assert.Empty(t, plain.Repository)
+	assert.Equal(t, []string{}, plain.Labels)
+
+	// Listing filters by repository and label
+	prs, err := prRepo.List(ctx, models.PRFilter{Repository: "acme/api", Limit: 10})
+	assert.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, []string{"u2"}, prs[0].AssignedReviewers)
+
+	prs, err = prRepo.List(ctx, models.PRFilter{Label: "security", Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, prs, 1)
+
+	prs, err = prRepo.List(ctx, models.PRFilter{Label: "docs", Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, prs)
+
+	prs, err = prRepo.List(ctx, models.PRFilter{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, prs, 2)
+}
+
+func TestLoginRepository_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	loginRepo := postgres.NewLoginRepository(pool)
+
+	// Setup
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+
+	_, err := loginRepo.FindUserID(ctx, models.CodeHostGitHub, "alice-dev")
+	assert.ErrorIs(t, err, apperrors.ErrLoginNotMapped)
+
+	login := &models.CodeHostLogin{CodeHost: models.CodeHostGitHub, Login: "alice-dev", UserID: "u1", CreatedAt: time.Now()}
+	require.NoError(t, loginRepo.Save(ctx, login))
+
+	userID, err := loginRepo.FindUserID(ctx, models.CodeHostGitHub, "alice-dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", userID)
+
+	// Saving the login again moves it to another user
+	login.UserID = "u2"
+	require.NoError(t, loginRepo.Save(ctx, login))
+
+	logins, err := loginRepo.List(ctx, models.CodeHostGitHub)
+	assert.NoError(t, err)
+	require.Len(t, logins, 1)
+	assert.Equal(t, "u2", logins[0].UserID)
+
+	// The same login on another code host is another account
+	require.NoError(t, loginRepo.Save(ctx, &models.CodeHostLogin{CodeHost: models.CodeHostGitLab, Login: "alice-dev", UserID: "u1", CreatedAt: time.Now()}))
+
+	userID, err = loginRepo.FindUserID(ctx, models.CodeHostGitLab, "alice-dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "u1", userID)
+
+	assert.NoError(t, loginRepo.Delete(ctx, models.CodeHostGitHub, "alice-dev"))
+	assert.ErrorIs(t, loginRepo.Delete(ctx, models.CodeHostGitHub, "alice-dev"), apperrors.ErrLoginNotMapped)
+}
+
+func TestWebhookRepository_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	webhookRepo := postgres.NewWebhookRepository(pool)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	subscription := &models.WebhookSubscription{
+		URL:       "https://hooks.example.com/reviews",
+		Secret:    "secret",
+		Events:    []models.EventType{models.EventPRCreated, models.EventReviewerAssigned},
+		CreatedAt: now,
+	}
+	require.NoError(t, webhookRepo.CreateSubscription(ctx, subscription))
+	require.NotZero(t, subscription.SubscriptionID)
+
+	subscribers, err := webhookRepo.ListSubscribers(ctx, models.EventReviewerAssigned)
+	assert.NoError(t, err)
+	require.Len(t, subscribers, 1)
+	assert.Equal(t, subscription.Events, subscribers[0].Events)
+	assert.Equal(t, "secret", subscribers[0].Secret)
+
+	subscribers, err = webhookRepo.ListSubscribers(ctx, models.EventPRMerged)
+	assert.NoError(t, err)
+	assert.Empty(t, subscribers)
+
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: subscription.SubscriptionID,
+		EventType:      models.EventPRCreated,
+		Payload:        []byte(`{"type":"pr.created"}`),
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &now,
+		CreatedAt:      now,
+	}
+	require.NoError(t, webhookRepo.CreateDelivery(ctx, delivery))
+
+	// A claimed delivery is leased, a second claim skips it
+	claimed, err := webhookRepo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, delivery.DeliveryID, claimed[0].DeliveryID)
+	assert.JSONEq(t, `{"type":"pr.created"}`, string(claimed[0].Payload))
+
+	claimed, err = webhookRepo.ClaimDueDeliveries(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// The failed attempt is recorded and retried later
+	next := now.Add(30 * time.Second)
+	delivery.Attempts = 1
+	delivery.NextAttemptAt = &next
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 503
+	delivery.LastError = "subscriber responded with status 503"
+	require.NoError(t, webhookRepo.RecordAttempt(ctx, delivery))
+
+	stored, err := webhookRepo.GetDelivery(ctx, delivery.DeliveryID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stored.Attempts)
+	assert.Equal(t, 503, stored.ResponseStatus)
+	assert.Equal(t, next, stored.NextAttemptAt.UTC())
+
+	replay := &models.WebhookDelivery{
+		SubscriptionID: subscription.SubscriptionID,
+		EventType:      models.EventPRCreated,
+		Payload:        stored.Payload,
+		Status:         models.DeliveryPending,
+		NextAttemptAt:  &now,
+		ReplayOf:       &delivery.DeliveryID,
+		CreatedAt:      now,
+	}
+	require.NoError(t, webhookRepo.CreateDelivery(ctx, replay))
+
+	deliveries, err := webhookRepo.ListDeliveries(ctx, models.WebhookDeliveryFilter{SubscriptionID: subscription.SubscriptionID, Limit: 10})
+	assert.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, replay.DeliveryID, deliveries[0].DeliveryID)
+	assert.Equal(t, delivery.DeliveryID, *deliveries[0].ReplayOf)
+
+	_, err = webhookRepo.GetDelivery(ctx, replay.DeliveryID+100)
+	assert.ErrorIs(t, err, apperrors.ErrDeliveryNotFound)
+
+	// Unsubscribing drops the delivery log of the subscription
+	require.NoError(t, webhookRepo.DeleteSubscription(ctx, subscription.SubscriptionID))
+	assert.ErrorIs(t, webhookRepo.DeleteSubscription(ctx, subscription.SubscriptionID), apperrors.ErrSubscriptionNotFound)
+
+	_, err = webhookRepo.GetDelivery(ctx, delivery.DeliveryID)
+	assert.ErrorIs(t, err, apperrors.ErrDeliveryNotFound)
+}
+
+func TestOutbox_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+	outboxRepo := postgres.NewOutboxRepository(pool)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+
+	// Events recorded on the PR are saved with it and forgotten
+	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+	pr.AddReviewer("u2")
+	pr.RecordEvent(models.Event{Type: models.EventPRCreated, OccurredAt: now, Data: models.PREventData{PullRequest: pr}})
+	pr.RecordEvent(models.Event{Type: models.EventReviewerAssigned, OccurredAt: now,
+		Data: models.ReviewerEventData{PullRequestID: "pr-1", AuthorID: "u1", ReviewerID: "u2"}})
+	require.NoError(t, prRepo.Create(ctx, pr))
+	assert.Empty(t, pr.Events())
+
+	require.NoError(t, outboxRepo.Append(ctx, models.Event{Type: models.EventUserDeactivated, OccurredAt: now,
+		Data: models.UserEventData{User: models.NewUser("u2", "Bob", "backend", false)}}))
+
+	// Only the oldest pending event of each aggregate is claimed
+	claimed, err := outboxRepo.ClaimDue(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, models.EventPRCreated, claimed[0].EventType)
+	assert.Equal(t, models.AggregatePullRequest, claimed[0].AggregateType)
+	assert.Equal(t, "pr-1", claimed[0].AggregateID)
+	assert.Equal(t, models.EventUserDeactivated, claimed[1].EventType)
+	assert.Equal(t, "u2", claimed[1].AggregateID)
+
+	// While pr.created is leased reviewer.assigned waits for it
+	leased, err := outboxRepo.ClaimDue(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, leased)
+
+	created := claimed[0]
+	created.Status = models.OutboxDispatched
+	created.Attempts = 1
+	created.NextAttemptAt = nil
+	created.DispatchedAt = &now
+	require.NoError(t, outboxRepo.RecordAttempt(ctx, created))
+
+	claimed, err = outboxRepo.ClaimDue(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, models.EventReviewerAssigned, claimed[0].EventType)
+
+	// A dead event steps out of the line and can be requeued
+	assigned := claimed[0]
+	assigned.Status = models.OutboxDead
+	assigned.Attempts = 10
+	assigned.NextAttemptAt = nil
+	assigned.LastError = "disk full"
+	require.NoError(t, outboxRepo.RecordAttempt(ctx, assigned))
+
+	dead, err := outboxRepo.ListDead(ctx, 10)
+	assert.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, assigned.EventID, dead[0].EventID)
+	assert.Equal(t, "disk full", dead[0].LastError)
+
+	require.NoError(t, outboxRepo.Requeue(ctx, assigned.EventID, now))
+	assert.ErrorIs(t, outboxRepo.Requeue(ctx, assigned.EventID, now), apperrors.ErrOutboxEventNotFound)
+
+	claimed, err = outboxRepo.ClaimDue(ctx, now, time.Minute, 10)
+	assert.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, assigned.EventID, claimed[0].EventID)
+	assert.Equal(t, 0, claimed[0].Attempts)
+}
+
+func TestAuditRepository_Integration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	pool := getTestDB(t)
+	defer pool.Close()
+	defer cleanDB(t, pool)
+
+	ctx := context.Background()
+	teamRepo := postgres.NewTeamRepository(pool)
+	userRepo := postgres.NewUserRepository(pool)
+	prRepo := postgres.NewPRRepository(pool)
+	auditRepo := postgres.NewAuditRepository(pool)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, teamRepo.Create(ctx, models.NewTeam("backend", []models.TeamMember{})))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u1", "Alice", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u2", "Bob", "backend", true)))
+	require.NoError(t, userRepo.Create(ctx, models.NewUser("u3", "Eve", "backend", true)))
+
+	require.NoError(t, auditRepo.Append(ctx, models.AuditEntry{Actor: "alice", Action: models.AuditTeamCreated,
+		EntityType: models.AuditEntityTeam, EntityID: "backend", After: map[string]string{"team_name": "backend"}, CreatedAt: now}))
+
+	// Audit entries recorded on the PR are saved with it
+	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+	pr.AddReviewer("u2")
+	require.NoError(t, prRepo.Create(ctx, pr))
+
+	before := pr.Snapshot()
+	pr.RemoveReviewer("u2")
+	pr.AddReviewer("u3")
+	pr.RecordAudit(models.AuditEntry{Actor: "bob", Action: models.AuditReviewerReassigned,
+		EntityType: models.AuditEntityPullRequest, EntityID: "pr-1", Before: before, After: pr.Snapshot(), CreatedAt: now})
+	require.NoError(t, prRepo.Update(ctx, pr))
+	assert.Empty(t, pr.AuditEntries())
+
+	entries, err := auditRepo.List(ctx, models.AuditFilter{EntityType: models.AuditEntityPullRequest, EntityID: "pr-1", Limit: 10})
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "bob", entries[0].Actor)
+	assert.Equal(t, now, entries[0].CreatedAt.UTC())
+
+	var reassigned struct {
+		AssignedReviewers []string `json:"assigned_reviewers"`
+	}
+
+	require.NoError(t, json.Unmarshal(entries[0].Before.(json.RawMessage), &reassigned))
+	assert.Equal(t, []string{"u2"}, reassigned.AssignedReviewers)
+
+	// Pages go back from the latest entry
+	entries, err = auditRepo.List(ctx, models.AuditFilter{Limit: 1})
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditReviewerReassigned, entries[0].Action)
+
+	entries, err = auditRepo.List(ctx, models.AuditFilter{Cursor: entries[0].AuditID, Limit: 1})
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditTeamCreated, entries[0].Action)
+	assert.Nil(t, entries[0].Before)
+
+	later := now.Add(time.Minute)
+	entries, err = auditRepo.List(ctx, models.AuditFilter{From: &later, Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Audit entries recorded on a user are saved with it, a failed update saves neither
+	user, err := userRepo.GetByID(ctx, "u3")
+	require.NoError(t, err)
+	user.SetActive(false)
+	user.RecordAudit(models.AuditEntry{Actor: "bob", Action: models.AuditUserDeactivated,
+		EntityType: models.AuditEntityUser, EntityID: "u3", After: user, CreatedAt: now})
+	require.NoError(t, userRepo.Update(ctx, user))
+	assert.Empty(t, user.AuditEntries())
+
+	ghost := models.NewUser("ghost", "Ghost", "backend", false)
+	ghost.RecordAudit(models.AuditEntry{Actor: "bob", Action: models.AuditUserDeactivated,
+		EntityType: models.AuditEntityUser, EntityID: "ghost", After: ghost, CreatedAt: now})
+	assert.ErrorIs(t, userRepo.Update(ctx, ghost), apperrors.ErrUserNotFound)
+
+	entries, err = auditRepo.List(ctx, models.AuditFilter{EntityType: models.AuditEntityUser, Limit: 10})
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "u3", entries[0].EntityID)
+
+	// The log is append-only
+	_, err = pool.Exec(ctx, "DELETE FROM audit_events")
+	assert.Error(t, err)
 }
@@ -0,0 +1,361 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) Append(ctx context.Context, entries ...models.AuditEntry) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepo) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*models.AuditEntry), args.Error(1)
+}
+
+// keeps recorded audit entries for assertions
+type recordingAudit struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAudit) Record(ctx context.Context, entry models.AuditEntry) error {
+	a.entries = append(a.entries, entry)
+	return nil
+}
+
+func TestActor_FromContext(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Equal(t, service.SystemActor, service.ActorFrom(ctx))
+	assert.Equal(t, "alice", service.ActorFrom(service.WithActor(ctx, " alice ")))
+	assert.Equal(t, service.AnonymousActor, service.ActorFrom(service.WithActor(ctx, "")))
+}
+
+func TestActor_ClaimedByCaller(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Equal(t, "claimed:alice", service.ActorFrom(service.WithClaimedActor(ctx, " alice ")))
+	assert.Equal(t, service.AnonymousActor, service.ActorFrom(service.WithClaimedActor(ctx, "  ")))
+}
+
+func TestActor_CutOnCharacterBoundary(t *testing.T) {
+
+	// Every "ж" is two bytes, a byte cut would split the last one
+	actor := service.ActorFrom(service.WithActor(context.Background(), "a"+strings.Repeat("ж", service.MaxActorLength)))
+
+	assert.True(t, utf8.ValidString(actor))
+	assert.Equal(t, service.MaxActorLength, utf8.RuneCountInString(actor))
+}
+
+func TestReassignReviewer_RecordsAuditWithPreviousReviewers(t *testing.T) {
+	ctx := service.WithActor(context.Background(), "alice")
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now: now})
+
+	openPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+	}
+
+	oldReviewer := &models.User{UserID: "u2", TeamName: "backend"}
+	newCandidate := &models.User{UserID: "u4", IsActive: true}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(oldReviewer, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{newCandidate, oldReviewer}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u4"}).Return(openLoads(map[string]int{"u4": 0}), nil)
+	mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	_, _, err := prService.ReassignReviewer(ctx, "pr-1", "u2")
+	require.NoError(t, err)
+
+	entries := openPR.AuditEntries()
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, "alice", entry.Actor)
+	assert.Equal(t, models.AuditReviewerReassigned, entry.Action)
+	assert.Equal(t, models.AuditEntityPullRequest, entry.EntityType)
+	assert.Equal(t, "pr-1", entry.EntityID)
+	assert.Equal(t, now, entry.CreatedAt)
+
+	// The previous reviewers survive the change of the PR
+	assert.Equal(t, []string{"u2", "u3"}, entry.Before.(*models.PullRequest).AssignedReviewers)
+	assert.ElementsMatch(t, []string{"u3", "u4"}, entry.After.(*models.PullRequest).AssignedReviewers)
+}
+
+func TestAddAndRemoveReviewer_RecordAudit(t *testing.T) {
+	ctx := service.WithActor(context.Background(), "alice")
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	openPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2"},
+	}
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.UnderstaffedPolicy = models.UnderstaffedAllow
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u3").Return(&models.User{UserID: "u3", TeamName: "backend", IsActive: true}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockPRRepo.On("Update", ctx, openPR).Return(nil)
+
+	_, err := prService.AddReviewer(ctx, "pr-1", "u3")
+	require.NoError(t, err)
+
+	_, err = prService.RemoveReviewer(ctx, "pr-1", "u2")
+	require.NoError(t, err)
+
+	entries := openPR.AuditEntries()
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, models.AuditReviewerAdded, entries[0].Action)
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, []string{"u2"}, entries[0].Before.(*models.PullRequest).AssignedReviewers)
+	assert.Equal(t, []string{"u2", "u3"}, entries[0].After.(*models.PullRequest).AssignedReviewers)
+
+	assert.Equal(t, models.AuditReviewerRemoved, entries[1].Action)
+	assert.Equal(t, []string{"u2", "u3"}, entries[1].Before.(*models.PullRequest).AssignedReviewers)
+	assert.Equal(t, []string{"u3"}, entries[1].After.(*models.PullRequest).AssignedReviewers)
+}
+
+func TestCreatePR_RecordsAudit(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{}, nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := prService.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+	require.NoError(t, err)
+
+	entries := pr.AuditEntries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, service.SystemActor, entries[0].Actor)
+	assert.Equal(t, models.AuditPRCreated, entries[0].Action)
+	assert.Nil(t, entries[0].Before)
+	assert.Equal(t, "pr-1", entries[0].After.(*models.PullRequest).PullRequestID)
+}
+
+// savedUserAudit collects the audit entries of users as the repository saves them
+func savedUserAudit(entries *[]models.AuditEntry) func(mock.Arguments) {
+	return func(args mock.Arguments) {
+		user := args.Get(1).(*models.User)
+		*entries = append(*entries, user.AuditEntries()...)
+		user.ClearAudit()
+	}
+}
+
+func TestUserService_SetIsActive_RecordsAudit(t *testing.T) {
+	ctx := service.WithActor(context.Background(), "bob")
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockUserRepo := new(MockUserRepo)
+	audit := &recordingAudit{}
+
+	userService := service.NewUserService(mockUserRepo, new(MockPRRepo), nil, nil, nil, fixedClock{now: now})
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", IsActive: true}, nil)
+	mockUserRepo.On("Update", ctx, mock.AnythingOfType("*models.User")).Run(savedUserAudit(&audit.entries)).Return(nil)
+
+	_, err := userService.SetIsActive(ctx, "u1", false)
+	require.NoError(t, err)
+
+	// Nothing changes the second time, nothing is recorded
+	_, err = userService.SetIsActive(ctx, "u1", false)
+	require.NoError(t, err)
+
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, "bob", audit.entries[0].Actor)
+	assert.Equal(t, models.AuditUserDeactivated, audit.entries[0].Action)
+	assert.Equal(t, now, audit.entries[0].CreatedAt)
+	assert.True(t, audit.entries[0].Before.(*models.User).IsActive)
+	assert.False(t, audit.entries[0].After.(*models.User).IsActive)
+}
+
+func TestTeamService_CreateTeam_RecordsAudit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+	audit := &recordingAudit{}
+
+	teamService := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, fixedClock{now: now})
+
+	mockTeamRepo.On("Exists", ctx, "backend").Return(false, nil)
+	mockTeamRepo.On("Create", ctx, mock.AnythingOfType("*models.Team")).Run(func(args mock.Arguments) {
+		audit.entries = append(audit.entries, args.Get(1).(*models.Team).AuditEntries()...)
+	}).Return(nil)
+
+	team, err := teamService.CreateTeam(ctx, "backend", []models.TeamMember{}, "")
+	require.NoError(t, err)
+
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, now, audit.entries[0].CreatedAt)
+	assert.Equal(t, models.AuditTeamCreated, audit.entries[0].Action)
+	assert.Equal(t, models.AuditEntityTeam, audit.entries[0].EntityType)
+	assert.Equal(t, "backend", audit.entries[0].EntityID)
+	assert.Nil(t, audit.entries[0].Before)
+	assert.Equal(t, team, audit.entries[0].After)
+}
+
+func TestTeamService_CreateTeam_RecordsMemberFlips(t *testing.T) {
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+	audit := &recordingAudit{}
+
+	teamService := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	members := []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: false},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Carol", IsActive: true},
+	}
+
+	mockTeamRepo.On("Exists", ctx, "backend").Return(false, nil)
+	mockTeamRepo.On("Create", ctx, mock.AnythingOfType("*models.Team")).Run(func(args mock.Arguments) {
+		audit.entries = append(audit.entries, args.Get(1).(*models.Team).AuditEntries()...)
+	}).Return(nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "frontend", IsActive: true, Timezone: "Europe/Berlin"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "frontend", IsActive: true}, nil)
+	mockUserRepo.On("GetByID", ctx, "u3").Return(nil, apperrors.ErrUserNotFound)
+	mockUserRepo.On("Create", ctx, mock.AnythingOfType("*models.User")).Run(savedUserAudit(&audit.entries)).Return(nil)
+
+	_, err := teamService.CreateTeam(ctx, "backend", members, "")
+	require.NoError(t, err)
+
+	// Only u1 was switched: u2 stays active and u3 is new
+	require.Len(t, audit.entries, 2)
+	assert.Equal(t, models.AuditTeamCreated, audit.entries[0].Action)
+
+	flip := audit.entries[1]
+	assert.Equal(t, models.AuditUserDeactivated, flip.Action)
+	assert.Equal(t, service.SystemActor, flip.Actor)
+	assert.Equal(t, "u1", flip.EntityID)
+	assert.True(t, flip.Before.(*models.User).IsActive)
+
+	after := flip.After.(*models.User)
+	assert.False(t, after.IsActive)
+	assert.Equal(t, "backend", after.TeamName)
+	assert.Equal(t, "Europe/Berlin", after.Timezone)
+}
+
+func TestAvailabilityService_SyncAvailability_RecordsFlips(t *testing.T) {
+	ctx := context.Background()
+
+	mockAbsenceRepo := new(MockAbsenceRepo)
+	audit := &recordingAudit{}
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	availabilityService := service.NewAvailabilityService(mockAbsenceRepo, new(MockUserRepo), fixedClock{now: now}, nil, audit)
+
+	mockAbsenceRepo.On("SyncActiveFlags", ctx, now).Return(flipped("u1", false), flipped("u2", true), nil)
+
+	deactivated, restored, err := availabilityService.SyncAvailability(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deactivated)
+	assert.Equal(t, 1, restored)
+
+	// The worker has no request, the switches are made by system
+	require.Len(t, audit.entries, 2)
+	assert.Equal(t, models.AuditUserDeactivated, audit.entries[0].Action)
+	assert.Equal(t, "u1", audit.entries[0].EntityID)
+	assert.Equal(t, models.AuditUserActivated, audit.entries[1].Action)
+	assert.Equal(t, "u2", audit.entries[1].EntityID)
+
+	for _, entry := range audit.entries {
+		assert.Equal(t, service.SystemActor, entry.Actor)
+		assert.Equal(t, models.AuditEntityUser, entry.EntityType)
+		assert.Equal(t, now, entry.CreatedAt)
+	}
+}
+
+func TestAuditService_ListPages(t *testing.T) {
+	ctx := context.Background()
+
+	mockAuditRepo := new(MockAuditRepo)
+	auditService := service.NewAuditService(mockAuditRepo)
+
+	entries := []*models.AuditEntry{{AuditID: 9}, {AuditID: 7}, {AuditID: 4}}
+
+	// One more entry than asked for means there is a next page
+	mockAuditRepo.On("List", ctx, models.AuditFilter{EntityID: "pr-1", Limit: 3}).Return(entries, nil)
+	mockAuditRepo.On("List", ctx, models.AuditFilter{EntityID: "pr-1", Cursor: 7, Limit: 3}).Return(entries[2:], nil)
+
+	page, err := auditService.List(ctx, models.AuditFilter{EntityID: "pr-1", Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, entries[:2], page.Entries)
+	require.NotNil(t, page.NextCursor)
+	assert.Equal(t, int64(7), *page.NextCursor)
+
+	page, err = auditService.List(ctx, models.AuditFilter{EntityID: "pr-1", Cursor: *page.NextCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, entries[2:], page.Entries)
+	assert.Nil(t, page.NextCursor)
+}
+
+func TestAuditService_ListInvalidFilter(t *testing.T) {
+	ctx := context.Background()
+
+	mockAuditRepo := new(MockAuditRepo)
+	auditService := service.NewAuditService(mockAuditRepo)
+
+	from := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	for _, filter := range []models.AuditFilter{
+		{Action: "pr.deleted"},
+		{EntityType: "absence"},
+		{From: &from, To: &to},
+		{Limit: 501},
+	} {
+		_, err := auditService.List(ctx, filter)
+		assert.ErrorIs(t, err, apperrors.ErrInvalidAuditFilter)
+	}
+
+	mockAuditRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
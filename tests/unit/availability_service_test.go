@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAbsenceRepo struct {
+	mock.Mock
+}
+
+func (m *MockAbsenceRepo) Create(ctx context.Context, absence *models.Absence) error {
+	args := m.Called(ctx, absence)
+	return args.Error(0)
+}
+
+func (m *MockAbsenceRepo) GetByID(ctx context.Context, absenceID int64) (*models.Absence, error) {
+	args := m.Called(ctx, absenceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Absence), args.Error(1)
+}
+
+func (m *MockAbsenceRepo) ListByUser(ctx context.Context, userID string, endedAfter time.Time) ([]*models.Absence, error) {
+	args := m.Called(ctx, userID, endedAfter)
+	return args.Get(0).([]*models.Absence), args.Error(1)
+}
+
+func (m *MockAbsenceRepo) Cancel(ctx context.Context, absenceID int64, at time.Time) error {
+	args := m.Called(ctx, absenceID, at)
+	return args.Error(0)
+}
+
+func (m *MockAbsenceRepo) SyncActiveFlags(ctx context.Context, at time.Time) ([]models.ActiveFlagChange, []models.ActiveFlagChange, error) {
+	args := m.Called(ctx, at)
+	deactivated, _ := args.Get(0).([]models.ActiveFlagChange)
+	restored, _ := args.Get(1).([]models.ActiveFlagChange)
+	return deactivated, restored, args.Error(2)
+}
+
+// flipped is the change of a user whose is_active flag was switched to isActive
+func flipped(userID string, isActive bool) []models.ActiveFlagChange {
+	return []models.ActiveFlagChange{{
+		Before: &models.User{UserID: userID, IsActive: !isActive},
+		After:  &models.User{UserID: userID, IsActive: isActive},
+	}}
+}
+
+func TestAvailabilityService_CreateAbsence_StartsNow(t *testing.T) {
+
+	ctx := context.Background()
+	mockAbsenceRepo := new(MockAbsenceRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	availabilityService := service.NewAvailabilityService(mockAbsenceRepo, mockUserRepo, fixedClock{now: now}, nil, nil)
+
+	startsAt := now.Add(-time.Hour)
+	endsAt := now.Add(7 * 24 * time.Hour)
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", IsActive: true}, nil)
+	mockAbsenceRepo.On("Create", ctx, mock.AnythingOfType("*models.Absence")).Return(nil)
+	mockAbsenceRepo.On("SyncActiveFlags", ctx, now).Return(flipped("u1", false), nil, nil)
+
+	absence, err := availabilityService.CreateAbsence(ctx, "u1", startsAt, endsAt, "vacation")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.AbsenceOngoing, absence.Status)
+	assert.Equal(t, "vacation", absence.Reason)
+	mockAbsenceRepo.AssertExpectations(t)
+}
+
+func TestAvailabilityService_CreateAbsence_Scheduled(t *testing.T) {
+
+	ctx := context.Background()
+	mockAbsenceRepo := new(MockAbsenceRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	availabilityService := service.NewAvailabilityService(mockAbsenceRepo, mockUserRepo, fixedClock{now: now}, nil, nil)
+
+	startsAt := now.Add(24 * time.Hour)
+	endsAt := startsAt.Add(48 * time.Hour)
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", IsActive: true}, nil)
+	mockAbsenceRepo.On("Create", ctx, mock.AnythingOfType("*models.Absence")).Return(nil)
+
+	absence, err := availabilityService.CreateAbsence(ctx, "u1", startsAt, endsAt, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.AbsenceScheduled, absence.Status)
+	// The worker switches the user off once the absence starts
+	mockAbsenceRepo.AssertNotCalled(t, "SyncActiveFlags")
+}
+
+func TestAvailabilityService_CreateAbsence_InvalidPeriod(t *testing.T) {
+
+	ctx := context.Background()
+	mockAbsenceRepo := new(MockAbsenceRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	availabilityService := service.NewAvailabilityService(mockAbsenceRepo, mockUserRepo, fixedClock{now: now}, nil, nil)
+
+	// ends before it starts
+	_, err := availabilityService.CreateAbsence(ctx, "u1", now.Add(time.Hour), now, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidAbsence)
+
+	// already over
+	_, err = availabilityService.CreateAbsence(ctx, "u1", now.Add(-48*time.Hour), now.Add(-time.Hour), "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidAbsence)
+
+	mockAbsenceRepo.AssertNotCalled(t, "Create")
+}
+
+func TestAvailabilityService_CancelAbsence_Ongoing(t *testing.T) {
+
+	ctx := context.Background()
+	mockAbsenceRepo := new(MockAbsenceRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	availabilityService := service.NewAvailabilityService(mockAbsenceRepo, mockUserRepo, fixedClock{now: now}, nil, nil)
+
+	absence := models.NewAbsence("u1", now.Add(-time.Hour), now.Add(time.Hour), "sick leave")
+	absence.AbsenceID = 7
+
+	mockAbsenceRepo.On("GetByID", ctx, int64(7)).Return(absence, nil)
+	mockAbsenceRepo.On("Cancel", ctx, int64(7), now).Return(nil)
+	mockAbsenceRepo.On("SyncActiveFlags", ctx, now).Return(nil, flipped("u1", true), nil)
+
+	cancelled, err := availabilityService.CancelAbsence(ctx, 7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.AbsenceCancelled, cancelled.Status)
+	assert.NotNil(t, cancelled.CancelledAt)
+	mockAbsenceRepo.AssertExpectations(t)
+}
+
+func TestAvailabilityService_CancelAbsence_Ended(t *testing.T) {
+
+	ctx := context.Background()
+	mockAbsenceRepo := new(MockAbsenceRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	availabilityService := service.NewAvailabilityService(mockAbsenceRepo, mockUserRepo, fixedClock{now: now}, nil, nil)
+
+	absence := models.NewAbsence("u1", now.Add(-48*time.Hour), now.Add(-time.Hour), "")
+	absence.AbsenceID = 7
+
+	mockAbsenceRepo.On("GetByID", ctx, int64(7)).Return(absence, nil)
+
+	cancelled, err := availabilityService.CancelAbsence(ctx, 7)
+
+	assert.Nil(t, cancelled)
+	assert.ErrorIs(t, err, apperrors.ErrAbsenceClosed)
+	mockAbsenceRepo.AssertNotCalled(t, "Cancel")
+}
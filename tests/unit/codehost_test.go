@@ -0,0 +1,250 @@
+package unit
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/codehost"
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubAdapter_Verify(t *testing.T) {
+
+	adapter := codehost.NewGitHub(webhookSecret)
+	header, body := githubDelivery(t, "pull_request", "pull_request_closed.json")
+
+	assert.NoError(t, adapter.Verify(header, body))
+
+	signature := header.Get("X-Hub-Signature-256")
+
+	for _, bad := range []string{"", "sha1=" + signature[7:], "sha256=00ff", signature[:len(signature)-2] + "00"} {
+		header.Set("X-Hub-Signature-256", bad)
+		assert.ErrorIs(t, adapter.Verify(header, body), apperrors.ErrInvalidSignature, bad)
+	}
+
+	// A tampered body no longer matches its signature
+	header.Set("X-Hub-Signature-256", signature)
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-3] = ' '
+	assert.ErrorIs(t, adapter.Verify(header, tampered), apperrors.ErrInvalidSignature)
+
+	// Without a secret nothing is trusted
+	assert.ErrorIs(t, codehost.NewGitHub("").Verify(header, body), apperrors.ErrInvalidSignature)
+}
+
+func TestGitHubAdapter_Parse(t *testing.T) {
+
+	adapter := codehost.NewGitHub(webhookSecret)
+
+	header, body := githubDelivery(t, "pull_request", "pull_request_opened.json")
+	change, err := adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, codehost.ChangeOpened, change.Kind)
+	assert.Equal(t, "acme/api#42", change.PullRequestID)
+	assert.Equal(t, "Alice-Dev", change.AuthorLogin)
+	assert.Equal(t, "https://github.com/acme/api/pull/42", change.Metadata.URL)
+
+	header, body = githubDelivery(t, "pull_request", "pull_request_closed_merged.json")
+	change, err = adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, codehost.ChangeMerged, change.Kind)
+
+	header, body = githubDelivery(t, "ping", "ping.json")
+	change, err = adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, codehost.ChangeIgnored, change.Kind)
+
+	_, err = adapter.Parse(header.Clone(), []byte("{}"))
+	assert.NoError(t, err)
+
+	header.Set("X-GitHub-Event", "pull_request")
+	_, err = adapter.Parse(header, []byte(`{"action": "opened"}`))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidWebhook)
+}
+
+// reads a recorded GitLab delivery with the secret token GitLab sends
+func gitlabDelivery(t *testing.T, name string) (http.Header, []byte) {
+
+	header := http.Header{}
+	header.Set("X-Gitlab-Event", "Merge Request Hook")
+	header.Set("X-Gitlab-Token", webhookSecret)
+
+	return header, webhookFixture(t, "gitlab", name)
+}
+
+func TestGitLabAdapter_Verify(t *testing.T) {
+
+	adapter := codehost.NewGitLab(webhookSecret)
+	header, body := gitlabDelivery(t, "merge_request_open.json")
+
+	assert.NoError(t, adapter.Verify(header, body))
+
+	header.Set("X-Gitlab-Token", webhookSecret+"!")
+	assert.ErrorIs(t, adapter.Verify(header, body), apperrors.ErrInvalidSignature)
+
+	header.Del("X-Gitlab-Token")
+	assert.ErrorIs(t, adapter.Verify(header, body), apperrors.ErrInvalidSignature)
+
+	// An empty token never matches an unconfigured adapter
+	assert.ErrorIs(t, codehost.NewGitLab("").Verify(header, body), apperrors.ErrInvalidSignature)
+}
+
+func TestGitLabAdapter_Parse(t *testing.T) {
+
+	adapter := codehost.NewGitLab(webhookSecret)
+
+	header, body := gitlabDelivery(t, "merge_request_open.json")
+	change, err := adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, &codehost.Change{
+		Kind:          codehost.ChangeOpened,
+		Event:         "Merge Request Hook",
+		Action:        "open",
+		PullRequestID: "platform/billing!17",
+		Title:         "Draft: Per-tenant invoice numbers",
+		AuthorLogin:   "carol.smith",
+		Draft:         true,
+		Metadata: models.PRMetadata{
+			Repository:  "platform/billing",
+			URL:         "https://gitlab.example.com/platform/billing/-/merge_requests/17",
+			BaseBranch:  "main",
+			Labels:      []string{"security"},
+			Description: "Switches invoice numbering to per-tenant sequences.",
+		},
+	}, change)
+
+	tests := []struct {
+		fixture string
+		kind    codehost.ChangeKind
+	}{
+		{"merge_request_update_ready.json", codehost.ChangeReady},
+		{"merge_request_update_labels.json", codehost.ChangeEdited},
+		{"merge_request_approved.json", codehost.ChangeIgnored},
+		{"merge_request_merge.json", codehost.ChangeMerged},
+		{"merge_request_close.json", codehost.ChangeClosed},
+		{"merge_request_reopen.json", codehost.ChangeReopened},
+	}
+
+	for _, tt := range tests {
+		header, body := gitlabDelivery(t, tt.fixture)
+		change, err := adapter.Parse(header, body)
+
+		require.NoError(t, err, tt.fixture)
+		assert.Equal(t, tt.kind, change.Kind, tt.fixture)
+		assert.Equal(t, "platform/billing!17", change.PullRequestID, tt.fixture)
+	}
+
+	header, body = gitlabDelivery(t, "merge_request_update_labels.json")
+	change, err = adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"security", "db"}, change.Metadata.Labels)
+
+	// Other hooks are acknowledged
+	header.Set("X-Gitlab-Event", "Push Hook")
+	change, err = adapter.Parse(header, []byte(`{"object_kind": "push"}`))
+
+	require.NoError(t, err)
+	assert.Equal(t, codehost.ChangeIgnored, change.Kind)
+}
+
+// reads a recorded Gitea delivery and signs it the way Gitea does
+func giteaDelivery(t *testing.T, event, name string) (http.Header, []byte) {
+
+	body := webhookFixture(t, "gitea", name)
+
+	header := http.Header{}
+	header.Set("X-Gitea-Event", event)
+	header.Set("X-Gitea-Signature", hmacHex(webhookSecret, body))
+
+	return header, body
+}
+
+func TestGiteaAdapter_Verify(t *testing.T) {
+
+	adapter := codehost.NewGitea(webhookSecret)
+	header, body := giteaDelivery(t, "pull_request", "pull_request_opened.json")
+
+	assert.NoError(t, adapter.Verify(header, body))
+
+	header.Set("X-Gitea-Signature", strings.ToUpper(hmacHex("another secret", body)))
+	assert.ErrorIs(t, adapter.Verify(header, body), apperrors.ErrInvalidSignature)
+
+	header.Set("X-Gitea-Signature", "sha256="+hmacHex(webhookSecret, body))
+	assert.ErrorIs(t, adapter.Verify(header, body), apperrors.ErrInvalidSignature)
+
+	assert.ErrorIs(t, codehost.NewGitea("").Verify(header, body), apperrors.ErrInvalidSignature)
+}
+
+func TestGiteaAdapter_Parse(t *testing.T) {
+
+	adapter := codehost.NewGitea(webhookSecret)
+
+	header, body := giteaDelivery(t, "pull_request", "pull_request_opened.json")
+	change, err := adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, codehost.ChangeOpened, change.Kind)
+	assert.Equal(t, "gitea:infra/deploy#9", change.PullRequestID)
+	assert.Equal(t, "Erin", change.AuthorLogin)
+	assert.Equal(t, "main", change.Metadata.BaseBranch)
+	assert.False(t, change.Draft)
+
+	tests := []struct {
+		event   string
+		fixture string
+		kind    codehost.ChangeKind
+	}{
+		{"pull_request_label", "pull_request_label_updated.json", codehost.ChangeEdited},
+		{"pull_request", "pull_request_closed_merged.json", codehost.ChangeMerged},
+		{"pull_request", "pull_request_closed.json", codehost.ChangeClosed},
+		{"pull_request", "pull_request_reopened.json", codehost.ChangeReopened},
+		{"push", "pull_request_opened.json", codehost.ChangeIgnored},
+	}
+
+	for _, tt := range tests {
+		header, body := giteaDelivery(t, tt.event, tt.fixture)
+		change, err := adapter.Parse(header, body)
+
+		require.NoError(t, err, tt.fixture)
+		assert.Equal(t, tt.kind, change.Kind, tt.fixture)
+	}
+
+	header, body = giteaDelivery(t, "pull_request_label", "pull_request_label_updated.json")
+	change, err = adapter.Parse(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops", "security"}, change.Metadata.Labels)
+}
+
+// The same owner/repo and number on GitHub and Gitea are two different PRs
+func TestGiteaAdapter_PRIDsDoNotCollideWithGitHub(t *testing.T) {
+
+	header, body := githubDelivery(t, "pull_request", "pull_request_opened.json")
+	fromGitHub, err := codehost.NewGitHub(webhookSecret).Parse(header, body)
+	require.NoError(t, err)
+
+	body = webhookFixture(t, "gitea", "pull_request_opened.json")
+	body = bytes.ReplaceAll(body, []byte(`"infra/deploy"`), []byte(`"acme/api"`))
+	body = bytes.ReplaceAll(body, []byte(`"number": 9`), []byte(`"number": 42`))
+
+	header = http.Header{}
+	header.Set("X-Gitea-Event", "pull_request")
+	header.Set("X-Gitea-Signature", hmacHex(webhookSecret, body))
+
+	fromGitea, err := codehost.NewGitea(webhookSecret).Parse(header, body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "acme/api#42", fromGitHub.PullRequestID)
+	assert.Equal(t, "gitea:acme/api#42", fromGitea.PullRequestID)
+	assert.NotEqual(t, fromGitHub.PullRequestID, fromGitea.PullRequestID)
+}
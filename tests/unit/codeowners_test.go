@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"testing"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCodeowners(t *testing.T) {
+
+	rules, err := service.ParseCodeowners(`
+# Default owners
+*                @acme/backend
+
+/docs/           @u5 @u6   # docs writers
+**/*.sql         @u7 @acme/dba
+/vendor/
+`)
+
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+
+	assert.Equal(t, "*", rules[0].Pattern)
+	assert.Equal(t, []string{"backend"}, rules[0].OwnerTeams)
+	assert.Empty(t, rules[0].OwnerUsers)
+
+	assert.Equal(t, []string{"u5", "u6"}, rules[1].OwnerUsers)
+
+	assert.Equal(t, []string{"u7"}, rules[2].OwnerUsers)
+	assert.Equal(t, []string{"dba"}, rules[2].OwnerTeams)
+
+	assert.False(t, rules[3].HasOwners())
+}
+
+func TestParseCodeowners_InvalidOwner(t *testing.T) {
+
+	_, err := service.ParseCodeowners("/docs/ docs@example.com")
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidOwnership)
+}
+
+func TestMatchOwnershipRule(t *testing.T) {
+
+	rules, err := service.ParseCodeowners(`
+*            @acme/backend
+*.md         @u2
+/docs/       @u5
+build/       @u6
+/cmd/**/main.go @u7
+/vendor/
+`)
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"internal/service/pr_service.go": "*",
+		"README.md":                      "*.md",
+		"internal/README.md":             "*.md",
+		"docs/api/index.md":              "/docs/",
+		"src/docs/readme.txt":            "*",
+		"build/out.bin":                  "build/",
+		"web/build/app.js":               "build/",
+		"cmd/main.go":                    "/cmd/**/main.go",
+		"cmd/server/main.go":             "/cmd/**/main.go",
+		"vendor/lib/x.go":                "/vendor/",
+	}
+
+	for path, pattern := range cases {
+		rule := service.MatchOwnershipRule(rules, path)
+		if assert.NotNil(t, rule, path) {
+			assert.Equal(t, pattern, rule.Pattern, path)
+		}
+	}
+}
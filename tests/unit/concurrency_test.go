@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Concurrent requests share the service's random source, run with -race
+func TestCreatePR_ConcurrentRequests(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend"}
+	teammates := []*models.User{{UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}
+
+	mockPRRepo.On("Exists", mock.Anything, mock.Anything).Return(false, nil)
+	mockPRRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+	mockUserRepo.On("GetByID", mock.Anything, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", mock.Anything, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", mock.Anything, "backend", "u1", mock.Anything).Return(teammates, nil)
+	// Equal loads make every selection break ties at random
+	mockUserRepo.On("GetReviewerLoads", mock.Anything, []string{"u2", "u3", "u4"}).
+		Return(openLoads(map[string]int{"u2": 0, "u3": 0, "u4": 0}), nil)
+
+	var wg sync.WaitGroup
+
+	for _, prID := range []string{"pr-1", "pr-2"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for range 200 {
+				pr, err := prService.CreatePR(ctx, createPRRequest(prID, "Test PR", "u1"))
+
+				if assert.NoError(t, err) {
+					assert.Len(t, pr.AssignedReviewers, 2)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
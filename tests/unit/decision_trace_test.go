@@ -0,0 +1,222 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTraceRepo struct {
+	mock.Mock
+}
+
+func (m *MockTraceRepo) Create(ctx context.Context, trace *models.AssignmentTrace) error {
+	args := m.Called(ctx, trace)
+	return args.Error(0)
+}
+
+func (m *MockTraceRepo) ListByPR(ctx context.Context, prID string) ([]*models.AssignmentTrace, error) {
+	args := m.Called(ctx, prID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.AssignmentTrace), args.Error(1)
+}
+
+func TestCreatePR_RecordsDecisionTrace(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	mockTraceRepo := new(MockTraceRepo)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, mockTraceRepo, nil, fixedClock{now})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+
+	candidates := []*models.User{
+		{UserID: "u2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", TeamName: "backend", IsActive: true},
+		{UserID: "u4", TeamName: "backend", IsActive: true},
+	}
+
+	// u5 is switched off, u6 is away
+	exclusions := []models.TraceExclusion{
+		{UserID: "u1", Reason: models.ExcludedAuthor},
+		{UserID: "u5", Reason: models.ExcludedInactive},
+		{UserID: "u6", Reason: models.ExcludedAbsent},
+	}
+
+	// u3 and u4 have the same load, only one of them fits
+	load := map[string]int{"u2": 0, "u3": 1, "u4": 1}
+
+	var saved *models.AssignmentTrace
+
+	mockPRRepo.On("Exists", mock.Anything, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", mock.Anything, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", mock.Anything, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", mock.Anything, "backend", "u1", now).Return(candidates, nil)
+	mockUserRepo.On("GetTeamExclusions", mock.Anything, "backend", "u1", now).Return(exclusions, nil)
+	mockUserRepo.On("GetReviewerLoads", mock.Anything, []string{"u2", "u3", "u4"}).Return(openLoads(load), nil)
+	mockPRRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+	mockTraceRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.AssignmentTrace")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*models.AssignmentTrace) }).
+		Return(nil)
+
+	pr, err := prService.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Contains(t, pr.AssignedReviewers, "u2")
+
+	if assert.NotNil(t, saved) {
+		assert.Equal(t, models.TraceActionCreate, saved.Action)
+		assert.Equal(t, models.StrategyLeastLoaded, saved.Strategy)
+		assert.Equal(t, pr.AssignedReviewers, saved.Selected)
+		assert.Equal(t, now, saved.CreatedAt)
+
+		assert.ElementsMatch(t, []models.TraceExclusion{
+			{UserID: "u1", Step: models.StepTeam, Reason: models.ExcludedAuthor},
+			{UserID: "u5", Step: models.StepTeam, Reason: models.ExcludedInactive},
+			{UserID: "u6", Step: models.StepTeam, Reason: models.ExcludedAbsent},
+		}, saved.Excluded)
+
+		if assert.Equal(t, 1, len(saved.Rounds)) {
+			round := saved.Rounds[0]
+			assert.Equal(t, 2, round.Wanted)
+			assert.Equal(t, models.TracedCandidate{UserID: "u2", Load: 0, Selected: true}, round.Candidates[0])
+
+			// The tie on load 1 was broken between u3 and u4
+			outcomes := []models.TieBreakOutcome{round.Candidates[1].TieBreak, round.Candidates[2].TieBreak}
+			assert.ElementsMatch(t, []models.TieBreakOutcome{models.TieBreakWon, models.TieBreakLost}, outcomes)
+		}
+	}
+}
+
+func TestCreatePR_TraceOfRoundRobinHasNoTieBreaks(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	mockTraceRepo := new(MockTraceRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, mockTraceRepo, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	candidates := []*models.User{{UserID: "u2", IsActive: true}, {UserID: "u3", IsActive: true}}
+	exclusions := []models.TraceExclusion{{UserID: "u1", Reason: models.ExcludedAuthor}}
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerStrategy = models.StrategyRoundRobin
+	settings.ReviewerCount = 1
+	settings.FairnessWindowDays = 30
+	settings.FairnessWeight = 2
+
+	var saved *models.AssignmentTrace
+
+	mockPRRepo.On("Exists", mock.Anything, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", mock.Anything, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", mock.Anything, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", mock.Anything, "backend", "u1", mock.Anything).Return(candidates, nil)
+	mockUserRepo.On("GetTeamExclusions", mock.Anything, "backend", "u1", mock.Anything).Return(exclusions, nil)
+	mockUserRepo.On("GetReviewerHistory", mock.Anything, []string{"u2", "u3"}).
+		Return(map[string]models.ReviewerHistory{}, nil)
+	mockUserRepo.On("GetReviewerLoads", mock.Anything, []string{"u2", "u3"}).
+		Return(openLoads(map[string]int{"u2": 1, "u3": 1}), nil)
+	mockUserRepo.On("GetAssignmentCounts", mock.Anything, []string{"u2", "u3"}, mock.Anything).
+		Return(map[string]int{"u2": 1, "u3": 1}, nil)
+	mockPRRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+	mockTraceRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.AssignmentTrace")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*models.AssignmentTrace) }).
+		Return(nil)
+
+	pr, err := prService.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2"}, pr.AssignedReviewers)
+
+	if assert.NotNil(t, saved) && assert.Equal(t, 1, len(saved.Rounds)) {
+		// Loads include the fairness window and are tied, yet the rotation broke no tie
+		assert.Equal(t, []models.TracedCandidate{
+			{UserID: "u2", Load: 3, Selected: true},
+			{UserID: "u3", Load: 3},
+		}, saved.Rounds[0].Candidates)
+	}
+
+	mockUserRepo.AssertNotCalled(t, "GetReviewerLoad", mock.Anything, mock.Anything)
+}
+
+func TestReassignReviewer_TraceNamesTheFilterOfEachExclusion(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	mockTraceRepo := new(MockTraceRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, mockTraceRepo, nil, fixedClock{now})
+
+	openPR := &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1", Status: models.PRStatusOpen, AssignedReviewers: []string{"u2", "u3"}}
+	leaving := &models.User{UserID: "u2", TeamName: "backend", IsActive: true}
+
+	// The author and the reviewers are candidates of the team, the service filters them out
+	members := []*models.User{{UserID: "u1", IsActive: true}, leaving, {UserID: "u3", IsActive: true}, {UserID: "u4", IsActive: true}}
+
+	var saved *models.AssignmentTrace
+
+	mockPRRepo.On("GetByID", mock.Anything, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", mock.Anything, "u1").Return(&models.User{UserID: "u1", TeamName: "backend", IsActive: true}, nil)
+	mockUserRepo.On("GetByID", mock.Anything, "u2").Return(leaving, nil)
+	mockTeamRepo.On("GetSettings", mock.Anything, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", mock.Anything, "backend", "", now).Return(members, nil)
+	mockUserRepo.On("GetTeamExclusions", mock.Anything, "backend", "", now).Return([]models.TraceExclusion{
+		{UserID: "u5", Reason: models.ExcludedInactive},
+		{UserID: "u6", Reason: models.ExcludedAbsent},
+	}, nil)
+	mockUserRepo.On("GetReviewerLoads", mock.Anything, []string{"u4"}).Return(openLoads(map[string]int{"u4": 0}), nil)
+	mockPRRepo.On("Update", mock.Anything, openPR).Return(nil)
+	mockTraceRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.AssignmentTrace")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*models.AssignmentTrace) }).
+		Return(nil)
+
+	_, replacedBy, err := prService.ReassignReviewer(ctx, "pr-1", "u2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u4", replacedBy)
+
+	if assert.NotNil(t, saved) {
+		assert.ElementsMatch(t, []models.TraceExclusion{
+			{UserID: "u5", Step: models.StepTeam, Reason: models.ExcludedInactive},
+			{UserID: "u6", Step: models.StepTeam, Reason: models.ExcludedAbsent},
+			{UserID: "u1", Step: models.StepTeam, Reason: models.ExcludedAuthor},
+			{UserID: "u2", Step: models.StepTeam, Reason: models.ExcludedAlreadyAssigned},
+			{UserID: "u3", Step: models.StepTeam, Reason: models.ExcludedAlreadyAssigned},
+		}, saved.Excluded)
+	}
+}
+
+func TestExplainAssignment_PRNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockTraceRepo := new(MockTraceRepo)
+
+	prService := service.NewPRService(mockPRRepo, new(MockUserRepo), new(MockTeamRepo), mockTraceRepo, nil, service.SystemClock{})
+
+	mockPRRepo.On("Exists", ctx, "pr-404").Return(false, nil)
+
+	traces, err := prService.ExplainAssignment(ctx, "pr-404")
+
+	assert.ErrorIs(t, err, apperrors.ErrPRNotFound)
+	assert.Nil(t, traces)
+	mockTraceRepo.AssertNotCalled(t, "ListByPR", mock.Anything, mock.Anything)
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,13 +27,60 @@ func TestPullRequest_Merge(t *testing.T) {
 
 	assert.False(t, pr.IsMerged())
 
-	pr.Merge()
+	assert.NoError(t, pr.Merge())
 
 	assert.True(t, pr.IsMerged())
 	assert.Equal(t, models.PRStatusMerged, pr.Status)
 	assert.NotNil(t, pr.MergedAt)
 }
 
+func TestPullRequest_Lifecycle(t *testing.T) {
+
+	actions := map[string]func(*models.PullRequest) error{
+		"ready":  (*models.PullRequest).MarkReady,
+		"merge":  (*models.PullRequest).Merge,
+		"close":  (*models.PullRequest).Close,
+		"reopen": (*models.PullRequest).Reopen,
+	}
+
+	tests := []struct {
+		from   models.PRStatus
+		action string
+		to     models.PRStatus
+	}{
+		{from: models.PRStatusDraft, action: "ready", to: models.PRStatusOpen},
+		{from: models.PRStatusDraft, action: "close", to: models.PRStatusClosed},
+		{from: models.PRStatusDraft, action: "merge"},
+		{from: models.PRStatusOpen, action: "close", to: models.PRStatusClosed},
+		{from: models.PRStatusOpen, action: "ready"},
+		{from: models.PRStatusOpen, action: "reopen"},
+		{from: models.PRStatusClosed, action: "reopen", to: models.PRStatusOpen},
+		{from: models.PRStatusClosed, action: "merge"},
+		{from: models.PRStatusMerged, action: "close"},
+		{from: models.PRStatusMerged, action: "reopen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" "+tt.action, func(t *testing.T) {
+
+			pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+			pr.Status = tt.from
+
+			err := actions[tt.action](pr)
+
+			if tt.to == "" {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidTransition)
+				assert.Equal(t, tt.from, pr.Status)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.to, pr.Status)
+			assert.Equal(t, tt.to == models.PRStatusClosed, pr.ClosedAt != nil)
+		})
+	}
+}
+
 func TestPullRequest_AddRemoveReviewer(t *testing.T) {
 
 	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
@@ -54,6 +102,22 @@ func TestPullRequest_AddRemoveReviewer(t *testing.T) {
 	assert.False(t, removed)
 }
 
+func TestPullRequest_FallbackReviewer(t *testing.T) {
+
+	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+
+	pr.AddReviewer("u2")
+	pr.AddFallbackReviewer("u7")
+
+	assert.Equal(t, []string{"u2", "u7"}, pr.AssignedReviewers)
+	assert.False(t, pr.IsFallbackReviewer("u2"))
+	assert.True(t, pr.IsFallbackReviewer("u7"))
+
+	assert.True(t, pr.RemoveReviewer("u7"))
+	assert.False(t, pr.IsFallbackReviewer("u7"))
+	assert.Empty(t, pr.FallbackReviewers)
+}
+
 func TestUser_SetActive(t *testing.T) {
 
 	user := models.NewUser("u1", "Alice", "backend", true)
@@ -64,4 +128,156 @@ func TestUser_SetActive(t *testing.T) {
 
 	assert.False(t, user.IsActive)
 	assert.True(t, user.UpdatedAt.After(initialTime))
+
+	// Set by hand, an absence no longer switches the user back on
+	user.DeactivatedByAbsence = true
+	user.SetActive(false)
+	assert.False(t, user.DeactivatedByAbsence)
+}
+
+func TestReviewerStrategy_IsValid(t *testing.T) {
+
+	assert.True(t, models.StrategyLeastLoaded.IsValid())
+	assert.True(t, models.StrategyRoundRobin.IsValid())
+	assert.True(t, models.StrategyWeightedRandom.IsValid())
+	assert.True(t, models.StrategyHistoricalFairness.IsValid())
+	assert.False(t, models.ReviewerStrategy("FASTEST").IsValid())
+	assert.False(t, models.ReviewerStrategy("").IsValid())
+}
+
+func TestNewTeam_DefaultStrategy(t *testing.T) {
+
+	team := models.NewTeam("backend", nil)
+
+	assert.Equal(t, models.DefaultReviewerStrategy, team.ReviewerStrategy)
+}
+
+func TestAbsence_StatusAt(t *testing.T) {
+
+	startsAt := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC)
+	absence := models.NewAbsence("u1", startsAt, endsAt, "vacation")
+
+	assert.Equal(t, models.AbsenceScheduled, absence.StatusAt(startsAt.Add(-time.Second)))
+	assert.Equal(t, models.AbsenceOngoing, absence.StatusAt(startsAt))
+	assert.Equal(t, models.AbsenceEnded, absence.StatusAt(endsAt))
+	assert.False(t, absence.IsClosed(startsAt))
+
+	cancelledAt := startsAt.Add(time.Hour)
+	absence.CancelledAt = &cancelledAt
+
+	assert.Equal(t, models.AbsenceCancelled, absence.StatusAt(startsAt.Add(2*time.Hour)))
+	assert.True(t, absence.IsClosed(startsAt))
+}
+
+func TestUser_UntilWorking(t *testing.T) {
+
+	user := models.NewUser("u1", "Alice", "backend", true)
+	user.Timezone = "Europe/Berlin"
+	user.WorkingHours = models.WorkingHours{
+		{Day: "MON", Start: "09:00", End: "18:00"},
+		{Day: "TUE", Start: "09:00", End: "18:00"},
+	}
+
+	// Monday 2025-07-07 12:00 in Berlin (UTC+2)
+	monday := time.Date(2025, 7, 7, 10, 0, 0, 0, time.UTC)
+
+	until, ok := user.UntilWorking(monday)
+	assert.True(t, ok)
+	assert.Zero(t, until)
+
+	// Monday 18:30 in Berlin, next start is Tuesday 09:00
+	until, ok = user.UntilWorking(time.Date(2025, 7, 7, 16, 30, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, 14*time.Hour+30*time.Minute, until)
+	assert.False(t, user.IsAvailableWithin(time.Date(2025, 7, 7, 16, 30, 0, 0, time.UTC), 12*time.Hour))
+
+	// No schedule means always available
+	user.WorkingHours = models.WorkingHours{}
+	assert.True(t, user.IsAvailableWithin(monday, 0))
+}
+
+func TestUser_WorkingDeadline(t *testing.T) {
+
+	user := models.NewUser("u1", "Alice", "backend", true)
+	user.Timezone = "Europe/Berlin"
+	user.WorkingHours = models.WorkingHours{
+		{Day: "MON", Start: "09:00", End: "18:00"},
+		{Day: "TUE", Start: "09:00", End: "12:00"},
+		{Day: "TUE", Start: "13:00", End: "18:00"},
+	}
+
+	// Monday 17:00 in Berlin: one hour on Monday, the rest from Tuesday 09:00
+	monday := time.Date(2025, 7, 7, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 7, 8, 9, 0, 0, 0, time.UTC), user.WorkingDeadline(monday, 3*time.Hour))
+
+	// The lunch break does not count
+	assert.Equal(t, time.Date(2025, 7, 8, 12, 30, 0, 0, time.UTC), user.WorkingDeadline(monday, 5*time.Hour+30*time.Minute))
+
+	// Saturday counts from Monday, the week after
+	saturday := time.Date(2025, 7, 12, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 7, 14, 9, 0, 0, 0, time.UTC), user.WorkingDeadline(saturday, 2*time.Hour))
+
+	// No schedule counts every hour
+	user.WorkingHours = models.WorkingHours{}
+	assert.Equal(t, monday.Add(3*time.Hour), user.WorkingDeadline(monday, 3*time.Hour))
+}
+
+func TestWorkingHours_Validate(t *testing.T) {
+
+	assert.NoError(t, models.WorkingHours{{Day: "FRI", Start: "10:00", End: "24:00"}}.Validate())
+	assert.Error(t, models.WorkingHours{{Day: "FRIDAY", Start: "10:00", End: "18:00"}}.Validate())
+	assert.Error(t, models.WorkingHours{{Day: "FRI", Start: "18:00", End: "10:00"}}.Validate())
+	assert.Error(t, models.WorkingHours{{Day: "FRI", Start: "9:00", End: "18:00"}}.Validate())
+	assert.Error(t, models.WorkingHours{{Day: "FRI", Start: "09:60", End: "18:00"}}.Validate())
+}
+
+func TestTeamSettings_ReviewWeight(t *testing.T) {
+
+	settings := models.DefaultTeamSettings("backend")
+	size := &models.PRSize{LinesAdded: 1500, LinesRemoved: 500, FilesChanged: 45}
+	explicit := 7
+
+	// COUNT ignores size and explicit weight
+	assert.Equal(t, 1, settings.ReviewWeight(size, &explicit))
+
+	settings.LoadWeighting = models.LoadWeightingSize
+
+	// 1 + 2000/500 + 45/20
+	assert.Equal(t, 7, settings.ReviewWeight(size, nil))
+	assert.Equal(t, 7, settings.ReviewWeight(nil, &explicit))
+	assert.Equal(t, 1, settings.ReviewWeight(nil, nil))
+
+	settings.WeightFilesStep = 0
+	assert.Equal(t, 5, settings.ReviewWeight(size, nil))
+
+	huge := &models.PRSize{LinesAdded: 100000}
+	assert.Equal(t, models.MaxReviewWeight, settings.ReviewWeight(huge, nil))
+}
+
+func TestPendingAssignment_RetryDue(t *testing.T) {
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name     string
+		pending  models.PendingAssignment
+		expected bool
+	}{
+		{"never tried", models.PendingAssignment{}, true},
+		{"first pause over", models.PendingAssignment{Attempts: 1, LastAttemptAt: ago(time.Minute)}, true},
+		{"pause doubles", models.PendingAssignment{Attempts: 2, LastAttemptAt: ago(time.Minute)}, false},
+		{"capped at an hour", models.PendingAssignment{Attempts: 40, LastAttemptAt: ago(time.Hour)}, true},
+		{"within the cap", models.PendingAssignment{Attempts: 40, LastAttemptAt: ago(59 * time.Minute)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.pending.RetryDue(now))
+		})
+	}
 }
@@ -0,0 +1,476 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	args := m.Called(ctx, subscription)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) GetSubscription(ctx context.Context, subscriptionID int64) (*models.WebhookSubscription, error) {
+	args := m.Called(ctx, subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) DeleteSubscription(ctx context.Context, subscriptionID int64) error {
+	args := m.Called(ctx, subscriptionID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListSubscribers(ctx context.Context, eventType models.EventType) ([]*models.WebhookSubscription, error) {
+	args := m.Called(ctx, eventType)
+	return args.Get(0).([]*models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepo) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) GetDelivery(ctx context.Context, deliveryID int64) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ClaimDueDeliveries(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(ctx, at, lease, limit)
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+const subscriptionSecret = "subscriber-secret"
+
+// receiver of outbound webhooks answering with the given status, it keeps the requests it got
+type webhookReceiver struct {
+	server   *httptest.Server
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+
+	receiver := &webhookReceiver{}
+
+	receiver.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		receiver.requests = append(receiver.requests, r)
+		receiver.bodies = append(receiver.bodies, body)
+		w.WriteHeader(status)
+	}))
+
+	t.Cleanup(receiver.server.Close)
+
+	return receiver
+}
+
+// a queued delivery of pr.created to subscription 1 after the given failed attempts
+func pendingDelivery(t *testing.T, attempts int) *models.WebhookDelivery {
+
+	payload, err := json.Marshal(models.Event{
+		Type:       models.EventPRCreated,
+		OccurredAt: time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC),
+		Data:       models.PREventData{PullRequest: &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1"}},
+	})
+	require.NoError(t, err)
+
+	return &models.WebhookDelivery{
+		DeliveryID:     7,
+		SubscriptionID: 1,
+		EventType:      models.EventPRCreated,
+		Payload:        payload,
+		Status:         models.DeliveryPending,
+		Attempts:       attempts,
+	}
+}
+
+// delivers the delivery once to the receiver and returns what was recorded
+func deliverOnce(t *testing.T, receiver *webhookReceiver, delivery *models.WebhookDelivery, now time.Time) *models.WebhookDelivery {
+
+	ctx := context.Background()
+	mockWebhookRepo := new(MockWebhookRepo)
+
+	subscription := &models.WebhookSubscription{
+		SubscriptionID: 1,
+		URL:            receiver.server.URL + "/hooks",
+		Secret:         subscriptionSecret,
+		Events:         []models.EventType{models.EventPRCreated},
+	}
+
+	mockWebhookRepo.On("ClaimDueDeliveries", ctx, now, mock.Anything, mock.Anything).
+		Return([]*models.WebhookDelivery{delivery}, nil)
+	mockWebhookRepo.On("GetSubscription", ctx, int64(1)).Return(subscription, nil)
+	mockWebhookRepo.On("RecordAttempt", ctx, delivery).Return(nil)
+
+	outbound := service.NewOutboundWebhookService(mockWebhookRepo, receiver.server.Client(), fixedClock{now: now})
+
+	_, err := outbound.DeliverDue(ctx)
+	require.NoError(t, err)
+
+	mockWebhookRepo.AssertExpectations(t)
+
+	return delivery
+}
+
+func TestOutboundWebhooks_PublishQueuesForSubscribers(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockWebhookRepo := new(MockWebhookRepo)
+
+	subscriptions := []*models.WebhookSubscription{{SubscriptionID: 1}, {SubscriptionID: 2}}
+
+	mockWebhookRepo.On("ListSubscribers", ctx, models.EventReviewerAssigned).Return(subscriptions, nil)
+	mockWebhookRepo.On("CreateDelivery", ctx, mock.AnythingOfType("*models.WebhookDelivery")).Return(nil).Twice()
+
+	outbound := service.NewOutboundWebhookService(mockWebhookRepo, nil, fixedClock{now: now})
+
+	err := outbound.Publish(ctx, models.Event{
+		Type:       models.EventReviewerAssigned,
+		OccurredAt: now,
+		Data:       models.ReviewerEventData{PullRequestID: "pr-1", AuthorID: "u1", ReviewerID: "u2", Action: models.TraceActionCreate},
+	})
+
+	require.NoError(t, err)
+
+	for i, call := range mockWebhookRepo.Calls[1:] {
+		delivery := call.Arguments.Get(1).(*models.WebhookDelivery)
+
+		assert.Equal(t, subscriptions[i].SubscriptionID, delivery.SubscriptionID)
+		assert.Equal(t, models.DeliveryPending, delivery.Status)
+		assert.Equal(t, now, *delivery.NextAttemptAt)
+		assert.JSONEq(t, `{"type":"reviewer.assigned","occurred_at":"2025-07-08T12:00:00Z",
+			"data":{"pull_request_id":"pr-1","author_id":"u1","reviewer_id":"u2","action":"CREATE"}}`, string(delivery.Payload))
+	}
+
+	// The delivery worker is woken up
+	select {
+	case <-outbound.DeliveriesQueued():
+	default:
+		t.Fatal("deliveries were queued without a signal")
+	}
+}
+
+func TestOutboundWebhooks_PublishWithoutSubscribers(t *testing.T) {
+	ctx := context.Background()
+
+	mockWebhookRepo := new(MockWebhookRepo)
+
+	mockWebhookRepo.On("ListSubscribers", ctx, models.EventPRMerged).Return([]*models.WebhookSubscription{}, nil)
+
+	outbound := service.NewOutboundWebhookService(mockWebhookRepo, nil, service.SystemClock{})
+
+	err := outbound.Publish(ctx, models.Event{Type: models.EventPRMerged})
+
+	assert.NoError(t, err)
+	mockWebhookRepo.AssertNotCalled(t, "CreateDelivery", mock.Anything, mock.Anything)
+}
+
+func TestOutboundWebhooks_DeliverSignsPayload(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+	receiver := newWebhookReceiver(t, http.StatusNoContent)
+
+	delivery := deliverOnce(t, receiver, pendingDelivery(t, 0), now)
+
+	require.Len(t, receiver.requests, 1)
+	request := receiver.requests[0]
+
+	assert.Equal(t, http.MethodPost, request.Method)
+	assert.Equal(t, "/hooks", request.URL.Path)
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	assert.Equal(t, "pr.created", request.Header.Get("X-Webhook-Event"))
+	assert.Equal(t, "7", request.Header.Get("X-Webhook-Delivery"))
+	assert.Equal(t, "sha256="+hmacHex(subscriptionSecret, receiver.bodies[0]), request.Header.Get("X-Webhook-Signature-256"))
+	assert.JSONEq(t, string(delivery.Payload), string(receiver.bodies[0]))
+
+	assert.Equal(t, models.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+	assert.Equal(t, now, *delivery.DeliveredAt)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Empty(t, delivery.LastError)
+}
+
+func TestOutboundWebhooks_RetryWithExponentialBackoff(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+	receiver := newWebhookReceiver(t, http.StatusInternalServerError)
+
+	// 30s after the first failed attempt, doubled after every next one
+	for attempts, delay := range []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute} {
+
+		delivery := deliverOnce(t, receiver, pendingDelivery(t, attempts), now)
+
+		assert.Equal(t, models.DeliveryPending, delivery.Status)
+		assert.Equal(t, attempts+1, delivery.Attempts)
+		assert.Equal(t, now.Add(delay), *delivery.NextAttemptAt)
+		assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+		assert.Contains(t, delivery.LastError, "500")
+		assert.Nil(t, delivery.DeliveredAt)
+	}
+}
+
+func TestOutboundWebhooks_FailsAfterMaxAttempts(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+	receiver := newWebhookReceiver(t, http.StatusBadGateway)
+
+	delivery := deliverOnce(t, receiver, pendingDelivery(t, service.MaxDeliveryAttempts-1), now)
+
+	assert.Equal(t, models.DeliveryFailed, delivery.Status)
+	assert.Equal(t, service.MaxDeliveryAttempts, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Equal(t, now, *delivery.LastAttemptAt)
+}
+
+func TestOutboundWebhooks_UnreachableSubscriber(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+	receiver := newWebhookReceiver(t, http.StatusOK)
+	receiver.server.Close()
+
+	delivery := deliverOnce(t, receiver, pendingDelivery(t, 0), now)
+
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, 0, delivery.ResponseStatus)
+	assert.NotEmpty(t, delivery.LastError)
+	assert.Equal(t, now.Add(30*time.Second), *delivery.NextAttemptAt)
+}
+
+func TestOutboundWebhooks_ReplayQueuesCopy(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 9, 9, 0, 0, 0, time.UTC)
+
+	mockWebhookRepo := new(MockWebhookRepo)
+
+	failed := pendingDelivery(t, service.MaxDeliveryAttempts)
+	failed.Status = models.DeliveryFailed
+
+	mockWebhookRepo.On("GetDelivery", ctx, int64(7)).Return(failed, nil)
+	mockWebhookRepo.On("GetDelivery", ctx, int64(8)).Return(nil, apperrors.ErrDeliveryNotFound)
+	mockWebhookRepo.On("CreateDelivery", ctx, mock.AnythingOfType("*models.WebhookDelivery")).Return(nil)
+
+	outbound := service.NewOutboundWebhookService(mockWebhookRepo, nil, fixedClock{now: now})
+
+	replay, err := outbound.Replay(ctx, 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), *replay.ReplayOf)
+	assert.Equal(t, failed.SubscriptionID, replay.SubscriptionID)
+	assert.Equal(t, failed.Payload, replay.Payload)
+	assert.Equal(t, models.DeliveryPending, replay.Status)
+	assert.Equal(t, 0, replay.Attempts)
+	assert.Equal(t, now, *replay.NextAttemptAt)
+
+	// The original stays in the log as it was
+	assert.Equal(t, models.DeliveryFailed, failed.Status)
+
+	_, err = outbound.Replay(ctx, 8)
+	assert.ErrorIs(t, err, apperrors.ErrDeliveryNotFound)
+}
+
+func TestOutboundWebhooks_Subscribe(t *testing.T) {
+	ctx := context.Background()
+
+	mockWebhookRepo := new(MockWebhookRepo)
+	mockWebhookRepo.On("CreateSubscription", ctx, mock.AnythingOfType("*models.WebhookSubscription")).Return(nil)
+
+	outbound := service.NewOutboundWebhookService(mockWebhookRepo, nil, service.SystemClock{})
+
+	events := []models.EventType{models.EventReviewerAssigned, models.EventPRCreated, models.EventReviewerAssigned}
+
+	subscription, err := outbound.Subscribe(ctx, " https://hooks.example.com/reviews ", events, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/reviews", subscription.URL)
+	assert.Equal(t, []models.EventType{models.EventReviewerAssigned, models.EventPRCreated}, subscription.Events)
+	assert.Len(t, subscription.Secret, 64, "a secret is generated when none is given")
+
+	subscription, err = outbound.Subscribe(ctx, "http://localhost:9000/", events[:1], subscriptionSecret)
+
+	require.NoError(t, err)
+	assert.Equal(t, subscriptionSecret, subscription.Secret)
+}
+
+func TestOutboundWebhooks_SubscribeInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	mockWebhookRepo := new(MockWebhookRepo)
+
+	outbound := service.NewOutboundWebhookService(mockWebhookRepo, nil, service.SystemClock{})
+
+	created := []models.EventType{models.EventPRCreated}
+
+	for _, rawURL := range []string{"", "ftp://example.com/hook", "/relative/hook", "https://"} {
+		_, err := outbound.Subscribe(ctx, rawURL, created, "")
+		assert.ErrorIs(t, err, apperrors.ErrInvalidSubscription, rawURL)
+	}
+
+	_, err := outbound.Subscribe(ctx, "https://example.com/hook", nil, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSubscription)
+
+	_, err = outbound.Subscribe(ctx, "https://example.com/hook", []models.EventType{"pr.deleted"}, "")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSubscription)
+
+	_, err = outbound.ListDeliveries(ctx, models.WebhookDeliveryFilter{Status: "LOST"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSubscription)
+
+	mockWebhookRepo.AssertNotCalled(t, "CreateSubscription", mock.Anything, mock.Anything)
+}
+
+func TestCreatePR_RecordsCreatedAndAssignedEvents(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	outbox := &countingNotifier{}
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, outbox, fixedClock{now: now})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	reviewers := []*models.User{
+		{UserID: "u2", TeamName: "backend", IsActive: true},
+		{UserID: "u3", TeamName: "backend", IsActive: true},
+	}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(reviewers, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 0, "u3": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := prService.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, outbox.notified)
+
+	events := pr.Events()
+	require.Len(t, events, 3)
+
+	assert.Equal(t, models.EventPRCreated, events[0].Type)
+	assert.Equal(t, now, events[0].OccurredAt)
+	assert.Equal(t, pr, events[0].Data.(models.PREventData).PullRequest)
+
+	for i, reviewerID := range pr.AssignedReviewers {
+		assert.Equal(t, models.EventReviewerAssigned, events[i+1].Type)
+		assert.Equal(t, models.ReviewerEventData{
+			PullRequestID: "pr-1",
+			AuthorID:      "u1",
+			ReviewerID:    reviewerID,
+			Action:        models.TraceActionCreate,
+		}, events[i+1].Data)
+	}
+}
+
+func TestReassignReviewer_RecordsReassignedEvent(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	outbox := &countingNotifier{}
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, outbox, service.SystemClock{})
+
+	openPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+	}
+
+	oldReviewer := &models.User{UserID: "u2", TeamName: "backend"}
+	newCandidate := &models.User{UserID: "u4", IsActive: true}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(oldReviewer, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{newCandidate, oldReviewer}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u4"}).Return(openLoads(map[string]int{"u4": 0}), nil)
+	mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	_, _, err := prService.ReassignReviewer(ctx, "pr-1", "u2")
+
+	require.NoError(t, err)
+
+	events := openPR.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventReviewerReassigned, events[0].Type)
+	assert.Equal(t, models.ReviewerEventData{
+		PullRequestID:      "pr-1",
+		AuthorID:           "u1",
+		ReviewerID:         "u4",
+		ReplacedReviewerID: "u2",
+		Action:             models.TraceActionReassign,
+	}, events[0].Data)
+}
+
+func TestMergePR_RecordsMergedEventOnce(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	outbox := &countingNotifier{}
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, outbox, service.SystemClock{})
+
+	openPR := &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1", Status: models.PRStatusOpen, AssignedReviewers: []string{}}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockPRRepo.On("Update", ctx, openPR).Return(nil)
+
+	_, err := prService.MergePR(ctx, "pr-1")
+	require.NoError(t, err)
+
+	// Merging again changes nothing and records nothing
+	_, err = prService.MergePR(ctx, "pr-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, outbox.notified)
+
+	events := openPR.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventPRMerged, events[0].Type)
+	assert.Equal(t, openPR, events[0].Data.(models.PREventData).PullRequest)
+}
@@ -0,0 +1,239 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) Append(ctx context.Context, events ...models.Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) ClaimDue(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]*models.OutboxEvent, error) {
+	args := m.Called(ctx, at, lease, limit)
+	return args.Get(0).([]*models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepo) RecordAttempt(ctx context.Context, event *models.OutboxEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) ListDead(ctx context.Context, limit int) ([]*models.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]*models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepo) Requeue(ctx context.Context, eventID int64, at time.Time) error {
+	args := m.Called(ctx, eventID, at)
+	return args.Error(0)
+}
+
+// counts the notifications about saved events
+type countingNotifier struct {
+	notified int
+}
+
+func (n *countingNotifier) NotifyOutbox() {
+	n.notified++
+}
+
+// sink failing every event with the error, it keeps the events it got
+type failingSink struct {
+	err    error
+	events []models.Event
+}
+
+func (s *failingSink) Publish(ctx context.Context, event models.Event) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+// a saved pr.merged event of pr-1 after the given failed attempts
+func pendingOutboxEvent(t *testing.T, attempts int) *models.OutboxEvent {
+
+	event, err := models.NewOutboxEvent(models.Event{
+		Type:       models.EventPRMerged,
+		OccurredAt: time.Date(2025, 7, 8, 11, 0, 0, 0, time.UTC),
+		Data:       models.PREventData{PullRequest: &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1"}},
+	})
+	require.NoError(t, err)
+
+	event.EventID = 42
+	event.Attempts = attempts
+
+	return event
+}
+
+// dispatches the event once, the next claim finds nothing due
+func dispatchOnce(t *testing.T, sink service.EventPublisher, event *models.OutboxEvent, maxAttempts int, now time.Time) (*MockOutboxRepo, int) {
+
+	ctx := context.Background()
+	mockOutboxRepo := new(MockOutboxRepo)
+
+	mockOutboxRepo.On("ClaimDue", ctx, now, mock.Anything, mock.Anything).Return([]*models.OutboxEvent{event}, nil).Once()
+	mockOutboxRepo.On("ClaimDue", ctx, now, mock.Anything, mock.Anything).Return([]*models.OutboxEvent{}, nil).Once()
+	mockOutboxRepo.On("RecordAttempt", ctx, event).Return(nil)
+
+	outbox := service.NewOutboxService(mockOutboxRepo, sink, fixedClock{now: now}, maxAttempts)
+
+	dispatched, err := outbox.Dispatch(ctx)
+	require.NoError(t, err)
+
+	return mockOutboxRepo, dispatched
+}
+
+func TestOutbox_DispatchToSinks(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	first := &recordingPublisher{}
+	second := &recordingPublisher{}
+	event := pendingOutboxEvent(t, 0)
+
+	mockOutboxRepo, dispatched := dispatchOnce(t, service.EventPublishers{first, second}, event, 10, now)
+
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, models.OutboxDispatched, event.Status)
+	assert.Equal(t, 1, event.Attempts)
+	assert.Nil(t, event.NextAttemptAt)
+	assert.Equal(t, &now, event.DispatchedAt)
+
+	for _, sink := range []*recordingPublisher{first, second} {
+		require.Len(t, sink.events, 1)
+		assert.Equal(t, int64(42), sink.events[0].ID)
+		assert.Equal(t, models.EventPRMerged, sink.events[0].Type)
+		assert.JSONEq(t, string(event.Payload), string(sink.events[0].Data.(json.RawMessage)))
+	}
+
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func TestOutbox_RetryWithExponentialBackoff(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	sink := &failingSink{err: errors.New("disk full")}
+	event := pendingOutboxEvent(t, 2)
+
+	_, dispatched := dispatchOnce(t, sink, event, 10, now)
+
+	assert.Equal(t, 0, dispatched)
+	assert.Len(t, sink.events, 1)
+	assert.Equal(t, models.OutboxPending, event.Status)
+	assert.Equal(t, 3, event.Attempts)
+	assert.Equal(t, "disk full", event.LastError)
+
+	// 5s after the first failure, doubled after each next one
+	require.NotNil(t, event.NextAttemptAt)
+	assert.Equal(t, now.Add(20*time.Second), *event.NextAttemptAt)
+}
+
+func TestOutbox_DeadLetterAfterMaxAttempts(t *testing.T) {
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	sink := &failingSink{err: errors.New("disk full")}
+	event := pendingOutboxEvent(t, 2)
+
+	_, dispatched := dispatchOnce(t, sink, event, 3, now)
+
+	assert.Equal(t, 0, dispatched)
+	assert.Equal(t, models.OutboxDead, event.Status)
+	assert.Equal(t, 3, event.Attempts)
+	assert.Nil(t, event.NextAttemptAt)
+	assert.Nil(t, event.DispatchedAt)
+}
+
+func TestOutbox_PublishAppendsAndNotifies(t *testing.T) {
+	ctx := context.Background()
+
+	mockOutboxRepo := new(MockOutboxRepo)
+	outbox := service.NewOutboxService(mockOutboxRepo, &recordingPublisher{}, service.SystemClock{}, 10)
+
+	event := models.Event{
+		Type:       models.EventUserDeactivated,
+		OccurredAt: time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC),
+		Data:       models.UserEventData{User: &models.User{UserID: "u1"}},
+	}
+
+	mockOutboxRepo.On("Append", ctx, []models.Event{event}).Return(nil)
+
+	require.NoError(t, outbox.Publish(ctx, event))
+
+	select {
+	case <-outbox.EventsSaved():
+	default:
+		t.Fatal("dispatcher was not notified")
+	}
+
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func TestOutbox_Requeue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockOutboxRepo := new(MockOutboxRepo)
+	outbox := service.NewOutboxService(mockOutboxRepo, &recordingPublisher{}, fixedClock{now: now}, 10)
+
+	mockOutboxRepo.On("Requeue", ctx, int64(42), now).Return(nil)
+	mockOutboxRepo.On("Requeue", ctx, int64(43), now).Return(apperrors.ErrOutboxEventNotFound)
+
+	require.NoError(t, outbox.Requeue(ctx, 42))
+	<-outbox.EventsSaved()
+
+	err := outbox.Requeue(ctx, 43)
+	assert.ErrorIs(t, err, apperrors.ErrOutboxEventNotFound)
+	assert.Empty(t, outbox.EventsSaved())
+}
+
+func TestFileEventSink_AppendsJSONLines(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := service.NewFileEventSink(path)
+	require.NoError(t, err)
+
+	for _, eventID := range []int64{1, 2} {
+		err := sink.Publish(ctx, models.Event{
+			ID:         eventID,
+			Type:       models.EventReviewerAssigned,
+			OccurredAt: time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC),
+			Data:       models.ReviewerEventData{PullRequestID: "pr-1", ReviewerID: "u2"},
+		})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var event struct {
+		ID   int64            `json:"event_id"`
+		Type models.EventType `json:"type"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, int64(2), event.ID)
+	assert.Equal(t, models.EventReviewerAssigned, event.Type)
+}
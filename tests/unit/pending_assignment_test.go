@@ -0,0 +1,198 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStaffPending_FillsEmptySlots(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now: now})
+
+	// pr-1 gets a reviewer who has come back, nobody is free for pr-2 yet
+	staffable := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2"},
+		Understaffed:      true,
+	}
+
+	stuck := &models.PullRequest{
+		PullRequestID:     "pr-2",
+		AuthorID:          "u4",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{},
+		Understaffed:      true,
+	}
+
+	mockPRRepo.On("ListPending", ctx, "").Return([]*models.PendingAssignment{
+		{PullRequestID: "pr-1", Reason: models.PendingUnderstaffed},
+		{PullRequestID: "pr-2", Reason: models.PendingUnderstaffed},
+	}, nil)
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(staffable, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	// Absences are checked at the time of the service clock
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", now).Return([]*models.User{{UserID: "u2"}, {UserID: "u3"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 0}), nil)
+	mockPRRepo.On("Update", ctx, staffable).Return(nil)
+
+	mockPRRepo.On("GetByID", ctx, "pr-2").Return(stuck, nil)
+	mockUserRepo.On("GetByID", ctx, "u4").Return(&models.User{UserID: "u4", TeamName: "frontend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "frontend").Return(models.DefaultTeamSettings("frontend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "frontend", "u4", now).Return([]*models.User{}, nil)
+	mockPRRepo.On("RecordPendingAttempt", ctx, "pr-2", now).Return(nil)
+
+	staffed, err := prService.StaffPending(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, staffed)
+	assert.Equal(t, []string{"u2", "u3"}, staffable.AssignedReviewers)
+	assert.False(t, staffable.Understaffed)
+	assert.True(t, stuck.Understaffed)
+
+	mockPRRepo.AssertExpectations(t)
+	mockPRRepo.AssertNotCalled(t, "Update", ctx, stuck)
+}
+
+func TestStaffPending_NotifiedByActivation(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+	userService := service.NewUserService(mockUserRepo, mockPRRepo, prService, prService, nil, service.SystemClock{})
+
+	user := &models.User{UserID: "u2", TeamName: "backend"}
+
+	mockUserRepo.On("GetByID", ctx, "u2").Return(user, nil)
+	mockUserRepo.On("Update", ctx, user).Return(nil)
+
+	notified := func() bool {
+		select {
+		case <-prService.StaffingChanged():
+			return true
+		default:
+			return false
+		}
+	}
+
+	_, err := userService.SetIsActive(ctx, "u2", false)
+	assert.NoError(t, err)
+	assert.False(t, notified())
+
+	// Notifications before the worker wakes up are merged into one
+	_, err = userService.SetIsActive(ctx, "u2", true)
+	assert.NoError(t, err)
+	prService.NotifyStaffing()
+
+	assert.True(t, notified())
+	assert.False(t, notified())
+}
+
+func TestStaffPending_FailPolicyKeepsPartialFill(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now: now})
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.UnderstaffedPolicy = models.UnderstaffedFail
+
+	// Two slots are empty, only u3 has come back
+	pr := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{},
+		Understaffed:      true,
+	}
+
+	mockPRRepo.On("ListPending", ctx, "").Return([]*models.PendingAssignment{
+		{PullRequestID: "pr-1", Reason: models.PendingUnderstaffed},
+	}, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{{UserID: "u3"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 0}), nil)
+	mockPRRepo.On("Update", ctx, pr).Return(nil)
+	mockPRRepo.On("RecordPendingAttempt", ctx, "pr-1", now).Return(nil)
+
+	staffed, err := prService.StaffPending(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, staffed)
+	assert.Equal(t, []string{"u3"}, pr.AssignedReviewers)
+	assert.True(t, pr.Understaffed)
+	assert.Equal(t, models.UnderstaffedFail, settings.UnderstaffedPolicy)
+	mockPRRepo.AssertExpectations(t)
+}
+
+func TestStaffPending_BacksOffFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+
+	prService := service.NewPRService(mockPRRepo, new(MockUserRepo), new(MockTeamRepo), nil, nil, fixedClock{now: now})
+
+	// Three failed attempts wait 4 minutes, the last one was 3 minutes ago
+	lastAttempt := now.Add(-3 * time.Minute)
+
+	mockPRRepo.On("ListPending", ctx, "").Return([]*models.PendingAssignment{
+		{PullRequestID: "pr-1", Reason: models.PendingUnderstaffed, Attempts: 3, LastAttemptAt: &lastAttempt},
+	}, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(nil, apperrors.ErrPRNotFound)
+
+	staffed, err := prService.StaffPending(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, staffed)
+	mockPRRepo.AssertNotCalled(t, "GetByID", ctx, "pr-1")
+
+	// A staffing change retries the PR right away
+	_, err = prService.StaffAllPending(ctx)
+
+	assert.NoError(t, err)
+	mockPRRepo.AssertCalled(t, "GetByID", ctx, "pr-1")
+}
+
+func TestListPending_UnknownTeam(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	mockTeamRepo.On("Exists", ctx, "ghosts").Return(false, nil)
+
+	pending, err := prService.ListPending(ctx, "ghosts")
+
+	assert.ErrorIs(t, err, apperrors.ErrTeamNotFound)
+	assert.Nil(t, pending)
+	mockPRRepo.AssertNotCalled(t, "ListPending")
+}
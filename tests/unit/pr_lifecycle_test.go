@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePR_DraftThenReady(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	req := createPRRequest("pr-1", "Test PR", "u1")
+	req.Draft = true
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	draft, err := prService.CreatePR(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusDraft, draft.Status)
+	assert.Empty(t, draft.AssignedReviewers)
+	assert.False(t, draft.Understaffed)
+	mockUserRepo.AssertNotCalled(t, "GetActiveByTeam", ctx, "backend", "u1", mock.Anything)
+
+	// Reviewers of a draft do not change by hand either
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(draft, nil)
+
+	_, err = prService.AddReviewer(ctx, "pr-1", "u2")
+	assert.ErrorIs(t, err, apperrors.ErrPRNotOpen)
+
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{{UserID: "u2"}, {UserID: "u3"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 0, "u3": 1}), nil)
+	mockPRRepo.On("Update", ctx, draft).Return(nil)
+
+	pr, err := prService.MarkReady(ctx, "pr-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusOpen, pr.Status)
+	assert.ElementsMatch(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+
+	_, err = prService.MarkReady(ctx, "pr-1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidTransition)
+}
+
+func TestClosePR_ReopenKeepsReviewers(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+	pr.AddReviewer("u2")
+	pr.Understaffed = true
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr, nil)
+	mockPRRepo.On("Update", ctx, pr).Return(nil)
+
+	closed, err := prService.ClosePR(ctx, "pr-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusClosed, closed.Status)
+	assert.NotNil(t, closed.ClosedAt)
+
+	// Closing again changes nothing, merging an abandoned PR is illegal
+	_, err = prService.ClosePR(ctx, "pr-1")
+	assert.NoError(t, err)
+	mockPRRepo.AssertNumberOfCalls(t, "Update", 1)
+
+	_, err = prService.MergePR(ctx, "pr-1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidTransition)
+
+	_, _, err = prService.ReassignReviewer(ctx, "pr-1", "u2")
+	assert.ErrorIs(t, err, apperrors.ErrPRNotOpen)
+
+	// u2 keeps the slot, the missing one is filled
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{{UserID: "u2"}, {UserID: "u3"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 0}), nil)
+
+	reopened, err := prService.ReopenPR(ctx, "pr-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusOpen, reopened.Status)
+	assert.Nil(t, reopened.ClosedAt)
+	assert.Equal(t, []string{"u2", "u3"}, reopened.AssignedReviewers)
+	assert.False(t, reopened.Understaffed)
+}
+
+func TestClosePR_RecordsClosedEventAndAudit(t *testing.T) {
+	ctx := service.WithActor(context.Background(), "alice")
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	outbox := &countingNotifier{}
+
+	prService := service.NewPRService(mockPRRepo, new(MockUserRepo), new(MockTeamRepo), nil, outbox, fixedClock{now: now})
+
+	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+	pr.AddReviewer("u2")
+
+	var events []models.Event
+	var entries []models.AuditEntry
+
+	// Both go to the repository with the change
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr, nil)
+	mockPRRepo.On("Update", ctx, pr).Run(func(mock.Arguments) {
+		events, entries = pr.Events(), pr.AuditEntries()
+		pr.ClearRecorded()
+	}).Return(nil)
+
+	_, err := prService.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+
+	// Closing again records nothing
+	_, err = prService.ClosePR(ctx, "pr-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, outbox.notified)
+
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventPRClosed, events[0].Type)
+	assert.Equal(t, now, events[0].OccurredAt)
+	assert.Equal(t, pr, events[0].Data.(models.PREventData).PullRequest)
+
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditPRClosed, entries[0].Action)
+	assert.Equal(t, "alice", entries[0].Actor)
+	assert.Equal(t, models.PRStatusOpen, entries[0].Before.(*models.PullRequest).Status)
+	assert.Equal(t, models.PRStatusClosed, entries[0].After.(*models.PullRequest).Status)
+}
@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func labelTeamSettings() *models.TeamSettings {
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerCount = 1
+	settings.LabelRules = []models.LabelRule{{Label: "security", Team: "security"}}
+	return settings
+}
+
+func TestPRMetadata_Normalize(t *testing.T) {
+
+	metadata := models.PRMetadata{
+		Repository: " acme/api ",
+		URL:        "https://github.com/acme/api/pull/42",
+		Labels:     []string{"Security", " bug", "security"},
+	}
+
+	assert.NoError(t, metadata.Normalize())
+	assert.Equal(t, "acme/api", metadata.Repository)
+	assert.Equal(t, []string{"bug", "security"}, metadata.Labels)
+
+	metadata.URL = "github.com/acme/api/pull/42"
+	assert.Error(t, metadata.Normalize())
+
+	metadata.URL = ""
+	metadata.Labels = []string{" "}
+	assert.Error(t, metadata.Normalize())
+}
+
+func TestCreatePR_LabelRuleAddsReviewer(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(labelTeamSettings(), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{{UserID: "u2", TeamName: "backend"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2"}).Return(openLoads(map[string]int{"u2": 0}), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "security", "", mock.Anything).Return([]*models.User{{UserID: "s1", TeamName: "security"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"s1"}).Return(openLoads(map[string]int{"s1": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	req := createPRRequest("pr-1", "Rotate keys", "u1")
+	req.Repository = "acme/api"
+	req.Labels = []string{"Security"}
+
+	pr, err := prService.CreatePR(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "s1"}, pr.AssignedReviewers)
+	assert.Equal(t, []string{"security"}, pr.Labels)
+	assert.Equal(t, "acme/api", pr.Repository)
+	assert.False(t, pr.Understaffed)
+}
+
+func TestCreatePR_LabelRuleWithoutCandidates(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	settings := labelTeamSettings()
+	settings.UnderstaffedPolicy = models.UnderstaffedFail
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{{UserID: "u2", TeamName: "backend"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2"}).Return(openLoads(map[string]int{"u2": 0}), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "security", "", mock.Anything).Return([]*models.User{}, nil)
+
+	req := createPRRequest("pr-1", "Rotate keys", "u1")
+	req.Labels = []string{"security"}
+
+	_, err := prService.CreatePR(ctx, req)
+
+	assert.ErrorIs(t, err, apperrors.ErrNotEnoughReviewers)
+	mockPRRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestUpdateMetadata_NewLabelAddsReviewer(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	pr := models.NewPullRequest("pr-1", "Rotate keys", "u1")
+	pr.AddReviewer("u2")
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(labelTeamSettings(), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "security", "", mock.Anything).Return([]*models.User{{UserID: "s1", TeamName: "security"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"s1"}).Return(openLoads(map[string]int{"s1": 0}), nil)
+	mockPRRepo.On("Update", ctx, pr).Return(nil)
+
+	labels := []string{"bug", "SECURITY"}
+	url := "https://github.com/acme/api/pull/42"
+
+	updated, err := prService.UpdateMetadata(ctx, "pr-1", service.PRMetadataUpdate{Labels: &labels, URL: &url})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "s1"}, updated.AssignedReviewers)
+	assert.Equal(t, []string{"bug", "security"}, updated.Labels)
+	assert.Equal(t, url, updated.URL)
+
+	// Labels of a merged PR change without touching reviewers
+	pr.Status = models.PRStatusMerged
+	labels = []string{"security", "urgent"}
+
+	_, err = prService.UpdateMetadata(ctx, "pr-1", service.PRMetadataUpdate{Labels: &labels})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "s1"}, pr.AssignedReviewers)
+	mockTeamRepo.AssertNumberOfCalls(t, "GetSettings", 1)
+
+	bad := "ftp://example.com"
+	_, err = prService.UpdateMetadata(ctx, "pr-1", service.PRMetadataUpdate{URL: &bad})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidMetadata)
+}
+
+func TestListPRs_DefaultsAndNormalizesFilter(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	prService := service.NewPRService(mockPRRepo, new(MockUserRepo), new(MockTeamRepo), nil, nil, service.SystemClock{})
+
+	expected := models.PRFilter{Repository: "acme/api", Label: "security", Limit: models.DefaultPRListLimit}
+	mockPRRepo.On("List", ctx, expected).Return([]*models.PullRequest{}, nil)
+
+	prs, err := prService.ListPRs(ctx, models.PRFilter{Repository: "acme/api", Label: " Security"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, prs)
+	mockPRRepo.AssertExpectations(t)
+}
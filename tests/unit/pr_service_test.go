@@ -3,12 +3,14 @@ package unit
 import (
 	"context"
 	"testing"
+	"time"
 
 	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock repositories
@@ -49,11 +51,34 @@ func (m *MockPRRepo) GetByReviewer(ctx context.Context, userID string) ([]*model
 	return args.Get(0).([]*models.PullRequest), args.Error(1)
 }
 
+func (m *MockPRRepo) List(ctx context.Context, filter models.PRFilter) ([]*models.PullRequest, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*models.PullRequest), args.Error(1)
+}
+
 func (m *MockPRRepo) GetAssignmentStats(ctx context.Context) (map[string]int, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(map[string]int), args.Error(1)
 }
 
+func (m *MockPRRepo) SubmitReview(ctx context.Context, prID, userID string, state models.ReviewState, at time.Time) error {
+	args := m.Called(ctx, prID, userID, state, at)
+	return args.Error(0)
+}
+
+func (m *MockPRRepo) ListPending(ctx context.Context, teamName string) ([]*models.PendingAssignment, error) {
+	args := m.Called(ctx, teamName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PendingAssignment), args.Error(1)
+}
+
+func (m *MockPRRepo) RecordPendingAttempt(ctx context.Context, prID string, at time.Time) error {
+	args := m.Called(ctx, prID, at)
+	return args.Error(0)
+}
+
 type MockUserRepo struct {
 	mock.Mock
 }
@@ -76,19 +101,79 @@ func (m *MockUserRepo) GetByID(ctx context.Context, userID string) (*models.User
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepo) GetActiveByTeam(ctx context.Context, teamName string, excludeUserID string) ([]*models.User, error) {
-	args := m.Called(ctx, teamName, excludeUserID)
+func (m *MockUserRepo) GetActiveByTeam(ctx context.Context, teamName string, excludeUserID string, at time.Time) ([]*models.User, error) {
+	args := m.Called(ctx, teamName, excludeUserID, at)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
+func (m *MockUserRepo) GetTeamExclusions(ctx context.Context, teamName string, excludeUserID string, at time.Time) ([]models.TraceExclusion, error) {
+	args := m.Called(ctx, teamName, excludeUserID, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TraceExclusion), args.Error(1)
+}
+
 func (m *MockUserRepo) GetReviewerLoad(ctx context.Context, userIDs []string) (map[string]int, error) {
 	args := m.Called(ctx, userIDs)
 	return args.Get(0).(map[string]int), args.Error(1)
 }
 
+func (m *MockUserRepo) GetReviewerHistory(ctx context.Context, userIDs []string) (map[string]models.ReviewerHistory, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[string]models.ReviewerHistory), args.Error(1)
+}
+
+func (m *MockUserRepo) GetAuthorReviewCounts(ctx context.Context, authorID string, userIDs []string, since time.Time) (map[string]int, error) {
+	args := m.Called(ctx, authorID, userIDs, since)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockUserRepo) GetReviewerLoads(ctx context.Context, userIDs []string) (map[string]models.ReviewerLoad, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).(map[string]models.ReviewerLoad), args.Error(1)
+}
+
+// openLoads builds reviewer loads where every open PR weighs 1
+func openLoads(open map[string]int) map[string]models.ReviewerLoad {
+
+	loads := make(map[string]models.ReviewerLoad, len(open))
+
+	for userID, count := range open {
+		loads[userID] = models.ReviewerLoad{Open: count, Weighted: count}
+	}
+
+	return loads
+}
+
+func (m *MockUserRepo) GetAssignmentCounts(ctx context.Context, userIDs []string, since time.Time) (map[string]int, error) {
+	args := m.Called(ctx, userIDs, since)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockUserRepo) GetTags(ctx context.Context, userID string) ([]string, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepo) AddTags(ctx context.Context, userID string, tags []string) error {
+	args := m.Called(ctx, userID, tags)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) RemoveTags(ctx context.Context, userID string, tags []string) error {
+	args := m.Called(ctx, userID, tags)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) ReplaceTags(ctx context.Context, userID string, tags []string) error {
+	args := m.Called(ctx, userID, tags)
+	return args.Error(0)
+}
+
 type MockTeamRepo struct {
 	mock.Mock
 }
@@ -111,6 +196,51 @@ func (m *MockTeamRepo) Exists(ctx context.Context, teamName string) (bool, error
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockTeamRepo) UpdateStrategy(ctx context.Context, teamName string, strategy models.ReviewerStrategy) error {
+	args := m.Called(ctx, teamName, strategy)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepo) GetSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	args := m.Called(ctx, teamName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TeamSettings), args.Error(1)
+}
+
+func (m *MockTeamRepo) SaveSettings(ctx context.Context, settings *models.TeamSettings) error {
+	args := m.Called(ctx, settings)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepo) GetOwnershipRules(ctx context.Context, teamName string) ([]models.OwnershipRule, error) {
+	args := m.Called(ctx, teamName)
+	return args.Get(0).([]models.OwnershipRule), args.Error(1)
+}
+
+func (m *MockTeamRepo) ReplaceOwnershipRules(ctx context.Context, teamName string, rules []models.OwnershipRule) error {
+	args := m.Called(ctx, teamName, rules)
+	return args.Error(0)
+}
+
+// fixedClock stops the time for working hours and availability checks
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func createPRRequest(prID, prName, authorID string) service.CreatePRRequest {
+	return service.CreatePRRequest{
+		PullRequestID:   prID,
+		PullRequestName: prName,
+		AuthorID:        authorID,
+	}
+}
+
 func TestCreatePR_AssignsTwoReviewers(t *testing.T) {
 	ctx := context.Background()
 
@@ -118,7 +248,7 @@ func TestCreatePR_AssignsTwoReviewers(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	// Setup mocks
 	author := &models.User{
@@ -135,12 +265,13 @@ func TestCreatePR_AssignsTwoReviewers(t *testing.T) {
 
 	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
 	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
-	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1").Return(reviewers, nil)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{"u2", "u3"}).Return(map[string]int{"u2": 0, "u3": 0}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(reviewers, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 0, "u3": 0}), nil)
 	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
 
 	// Execute
-	pr, err := service.CreatePR(ctx, "pr-1", "Test PR", "u1")
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
 
 	// Assert
 	assert.NoError(t, err)
@@ -159,7 +290,7 @@ func TestMergePR_Idempotent(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	// Already merged PR
 	mergedPR := &models.PullRequest{
@@ -185,7 +316,7 @@ func TestCreatePR_NoActiveCandidates(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	author := &models.User{
 		UserID:   "u1",
@@ -196,14 +327,16 @@ func TestCreatePR_NoActiveCandidates(t *testing.T) {
 
 	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
 	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
-	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1").Return([]*models.User{}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{}, nil)
 	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
 
-	pr, err := service.CreatePR(ctx, "pr-1", "Test PR", "u1")
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
 
 	assert.NoError(t, err)
 	assert.NotNil(t, pr)
 	assert.Equal(t, 0, len(pr.AssignedReviewers))
+	assert.True(t, pr.Understaffed)
 }
 
 func TestCreatePR_OnlyOneCandidate(t *testing.T) {
@@ -213,7 +346,7 @@ func TestCreatePR_OnlyOneCandidate(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	author := &models.User{UserID: "u1", TeamName: "backend"}
 	reviewers := []*models.User{
@@ -222,14 +355,16 @@ func TestCreatePR_OnlyOneCandidate(t *testing.T) {
 
 	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
 	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
-	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1").Return(reviewers, nil)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{"u2"}).Return(map[string]int{"u2": 0}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(reviewers, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2"}).Return(openLoads(map[string]int{"u2": 0}), nil)
 	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
 
-	pr, err := service.CreatePR(ctx, "pr-1", "Test PR", "u1")
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(pr.AssignedReviewers))
+	assert.True(t, pr.Understaffed)
 }
 
 func TestCreatePR_LoadBalancing(t *testing.T) {
@@ -239,7 +374,7 @@ func TestCreatePR_LoadBalancing(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	author := &models.User{UserID: "u1", TeamName: "backend"}
 	reviewers := []*models.User{
@@ -250,13 +385,12 @@ func TestCreatePR_LoadBalancing(t *testing.T) {
 
 	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
 	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
-	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1").Return(reviewers, nil)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{"u2", "u3", "u4"}).Return(
-		map[string]int{"u2": 5, "u3": 2, "u4": 2}, nil,
-	)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(reviewers, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3", "u4"}).Return(openLoads(map[string]int{"u2": 5, "u3": 2, "u4": 2}), nil)
 	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
 
-	pr, err := service.CreatePR(ctx, "pr-1", "Test PR", "u1")
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
 
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(pr.AssignedReviewers))
@@ -271,7 +405,7 @@ func TestReassignReviewer_Success(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	openPR := &models.PullRequest{
 		PullRequestID:     "pr-1",
@@ -285,10 +419,11 @@ func TestReassignReviewer_Success(t *testing.T) {
 
 	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
 	mockUserRepo.On("GetByID", ctx, "u2").Return(oldReviewer, nil)
-	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "").Return(
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return(
 		[]*models.User{newCandidate, oldReviewer}, nil,
 	)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{"u4"}).Return(map[string]int{"u4": 0}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u4"}).Return(openLoads(map[string]int{"u4": 0}), nil)
 	mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
 
 	pr, replacedBy, err := service.ReassignReviewer(ctx, "pr-1", "u2")
@@ -307,7 +442,7 @@ func TestReassignReviewer_PRMerged(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	mergedPR := &models.PullRequest{
 		PullRequestID: "pr-1",
@@ -331,7 +466,7 @@ func TestReassignReviewer_NotAssigned(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	openPR := &models.PullRequest{
 		PullRequestID:     "pr-1",
@@ -356,7 +491,7 @@ func TestReassignReviewer_NoCandidate(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockTeamRepo := new(MockTeamRepo)
 
-	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
 
 	openPR := &models.PullRequest{
 		PullRequestID:     "pr-1",
@@ -369,7 +504,8 @@ func TestReassignReviewer_NoCandidate(t *testing.T) {
 
 	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
 	mockUserRepo.On("GetByID", ctx, "u2").Return(oldReviewer, nil)
-	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "").Return([]*models.User{oldReviewer}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{oldReviewer}, nil)
 
 	pr, replacedBy, err := service.ReassignReviewer(ctx, "pr-1", "u2")
 
@@ -378,3 +514,799 @@ func TestReassignReviewer_NoCandidate(t *testing.T) {
 	assert.Empty(t, replacedBy)
 	assert.Equal(t, apperrors.ErrNoCandidate, err)
 }
+
+func TestCreatePR_UnderstaffedPolicyFail(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "security"}
+	settings := models.DefaultTeamSettings("security")
+	settings.ReviewerCount = 3
+	settings.UnderstaffedPolicy = models.UnderstaffedFail
+
+	reviewers := []*models.User{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "security").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "security", "u1", mock.Anything).Return(reviewers, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 0, "u3": 0}), nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.Nil(t, pr)
+	assert.Equal(t, apperrors.ErrNotEnoughReviewers, err)
+	mockPRRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreatePR_UnderstaffedPolicyFallback(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "docs"}
+	settings := models.DefaultTeamSettings("docs")
+	settings.UnderstaffedPolicy = models.UnderstaffedFallback
+	settings.FallbackTeams = []string{"frontend"}
+
+	teammate := &models.User{UserID: "u2", Username: "Bob", IsActive: true}
+	fallback := &models.User{UserID: "u5", Username: "Eve", IsActive: true}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "docs").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "docs", "u1", mock.Anything).Return([]*models.User{teammate}, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "frontend", "", mock.Anything).Return([]*models.User{fallback}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2"}).Return(openLoads(map[string]int{"u2": 0}), nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u5"}).Return(openLoads(map[string]int{"u5": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u5"}, pr.AssignedReviewers)
+	assert.Equal(t, []string{"u5"}, pr.FallbackReviewers)
+	assert.False(t, pr.Understaffed)
+}
+
+func TestCreatePR_FallbackTeamsInOrder(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "docs"}
+	settings := models.DefaultTeamSettings("docs")
+	settings.ReviewerCount = 3
+	settings.UnderstaffedPolicy = models.UnderstaffedFallback
+	settings.FallbackTeams = []string{"frontend", "backend"}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "docs").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "docs", "u1", mock.Anything).Return([]*models.User{}, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "frontend", "", mock.Anything).Return([]*models.User{{UserID: "u5"}}, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{{UserID: "u6"}, {UserID: "u7"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u5"}).Return(openLoads(map[string]int{"u5": 0}), nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u6", "u7"}).Return(openLoads(map[string]int{"u6": 4, "u7": 1}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u5", "u7", "u6"}, pr.AssignedReviewers)
+	assert.Equal(t, pr.AssignedReviewers, pr.FallbackReviewers)
+	assert.False(t, pr.Understaffed)
+}
+
+func TestReassignReviewer_FallbackPool(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	openPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2"},
+	}
+
+	oldReviewer := &models.User{UserID: "u2", TeamName: "backend"}
+	settings := models.DefaultTeamSettings("backend")
+	settings.UnderstaffedPolicy = models.UnderstaffedFallback
+	settings.FallbackTeams = []string{"platform"}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(oldReviewer, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{oldReviewer}, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "platform", "", mock.Anything).Return([]*models.User{{UserID: "u9"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u9"}).Return(openLoads(map[string]int{"u9": 0}), nil)
+	mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, replacedBy, err := service.ReassignReviewer(ctx, "pr-1", "u2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u9", replacedBy)
+	assert.Equal(t, []string{"u9"}, pr.AssignedReviewers)
+	assert.True(t, pr.IsFallbackReviewer("u9"))
+}
+
+func TestCreatePR_CodeOwnersFirst(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend"}
+	dbOwner := &models.User{UserID: "u7", Username: "Grace", TeamName: "platform", IsActive: true}
+	teammates := []*models.User{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	}
+
+	rules := []models.OwnershipRule{
+		{Pattern: "*", OwnerTeams: []string{"backend"}},
+		{Pattern: "/migrations/", OwnerUsers: []string{"u7"}},
+	}
+
+	req := createPRRequest("pr-1", "Test PR", "u1")
+	req.ChangedFiles = []string{"migrations/0002_add.sql"}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockTeamRepo.On("GetOwnershipRules", ctx, "backend").Return(rules, nil)
+	mockUserRepo.On("GetByID", ctx, "u7").Return(dbOwner, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u7"}).Return(openLoads(map[string]int{"u7": 9}), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(teammates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 3, "u3": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, req)
+
+	assert.NoError(t, err)
+	// Owner goes first despite the load, the team fills the remaining slot
+	assert.Equal(t, []string{"u7", "u3"}, pr.AssignedReviewers)
+	assert.Equal(t, req.ChangedFiles, pr.ChangedFiles)
+}
+
+func TestCreatePR_RequiredTagsCovered(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend"}
+	teammates := []*models.User{
+		{UserID: "u2", Username: "Bob", IsActive: true, Tags: []string{"sql"}},
+		{UserID: "u3", Username: "Charlie", IsActive: true, Tags: []string{"k8s", "sql"}},
+		{UserID: "u4", Username: "Dave", IsActive: true, Tags: []string{"k8s"}},
+		{UserID: "u5", Username: "Eve", IsActive: true},
+	}
+
+	req := createPRRequest("pr-1", "Test PR", "u1")
+	req.RequiredTags = []string{"SQL", "k8s"}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(teammates, nil)
+	// Charlie alone covers both tags, so he is the only tagged candidate despite the load
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 7}), nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u4", "u5"}).Return(openLoads(map[string]int{"u2": 3, "u4": 2, "u5": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u5"}, pr.AssignedReviewers)
+	assert.Equal(t, []string{"k8s", "sql"}, pr.RequiredTags)
+}
+
+func TestCreatePR_RequiredTagNotCovered(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", Tags: []string{"frontend"}}
+	teammates := []*models.User{
+		{UserID: "u2", Username: "Bob", IsActive: true, Tags: []string{"sql"}},
+	}
+
+	req := createPRRequest("pr-1", "Test PR", "u1")
+	req.RequiredTags = []string{"frontend"}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(teammates, nil)
+
+	pr, err := service.CreatePR(ctx, req)
+
+	assert.Nil(t, pr)
+	assert.ErrorIs(t, err, apperrors.ErrTagNotCovered)
+	mockPRRepo.AssertNotCalled(t, "Create")
+}
+
+func TestReassignReviewer_KeepsRequiredTags(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	openPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+		RequiredTags:      []string{"sql"},
+	}
+
+	oldReviewer := &models.User{UserID: "u2", TeamName: "backend", Tags: []string{"sql"}}
+	otherReviewer := &models.User{UserID: "u3", TeamName: "backend"}
+	teamMembers := []*models.User{
+		{UserID: "u1"}, oldReviewer, otherReviewer,
+		{UserID: "u4"},
+		{UserID: "u5", Tags: []string{"sql"}},
+	}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(oldReviewer, nil)
+	mockUserRepo.On("GetByID", ctx, "u3").Return(otherReviewer, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return(teamMembers, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u5"}).Return(openLoads(map[string]int{"u5": 5}), nil)
+	mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	_, replacedBy, err := service.ReassignReviewer(ctx, "pr-1", "u2")
+
+	assert.NoError(t, err)
+	// u4 is idle but cannot review sql
+	assert.Equal(t, "u5", replacedBy)
+}
+
+func TestCreatePR_PrefersReviewersInWorkingHours(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	// Monday 18:00 in Berlin
+	now := time.Date(2025, 7, 7, 16, 0, 0, 0, time.UTC)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now: now})
+
+	workday := models.WorkingHours{
+		{Day: "MON", Start: "09:00", End: "18:00"},
+		{Day: "TUE", Start: "09:00", End: "18:00"},
+	}
+
+	author := &models.User{UserID: "u1", TeamName: "backend"}
+	teammates := []*models.User{
+		// Day is over in Berlin
+		{UserID: "u2", Username: "Bob", IsActive: true, Timezone: "Europe/Berlin", WorkingHours: workday},
+		// 12:00 in New York
+		{UserID: "u3", Username: "Charlie", IsActive: true, Timezone: "America/New_York", WorkingHours: workday},
+		// 01:00 in Tokyo, starts in 8 hours
+		{UserID: "u4", Username: "Dave", IsActive: true, Timezone: "Asia/Tokyo", WorkingHours: workday},
+	}
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.WorkingHoursPolicy = models.WorkingHoursPrefer
+	settings.WorkingHoursWindow = 8
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(teammates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3", "u4"}).
+		Return(openLoads(map[string]int{"u2": 0, "u3": 4, "u4": 5}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	// Bob is idle but off work
+	assert.Equal(t, []string{"u3", "u4"}, pr.AssignedReviewers)
+}
+
+func TestCreatePR_RequireWorkingHours(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	// Monday 18:00 in Berlin
+	now := time.Date(2025, 7, 7, 16, 0, 0, 0, time.UTC)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now: now})
+
+	workday := models.WorkingHours{{Day: "MON", Start: "09:00", End: "18:00"}}
+
+	author := &models.User{UserID: "u1", TeamName: "backend"}
+	teammates := []*models.User{
+		{UserID: "u2", Username: "Bob", IsActive: true, Timezone: "Europe/Berlin", WorkingHours: workday},
+		{UserID: "u3", Username: "Charlie", IsActive: true, Timezone: "America/New_York", WorkingHours: workday},
+	}
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.WorkingHoursPolicy = models.WorkingHoursRequire
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(teammates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 0, "u3": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.AssignedReviewers)
+	assert.True(t, pr.Understaffed)
+}
+
+func TestReassignOpenReviews_ReportsEachPR(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	leaving := &models.User{UserID: "u2", TeamName: "backend"}
+
+	reviews := []*models.PullRequest{
+		{PullRequestID: "pr-1", Status: models.PRStatusOpen},
+		{PullRequestID: "pr-2", Status: models.PRStatusOpen},
+		{PullRequestID: "pr-3", Status: models.PRStatusMerged},
+	}
+
+	// pr-1 has a free teammate, in pr-2 everybody else is already a reviewer
+	pr1 := &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1", Status: models.PRStatusOpen, AssignedReviewers: []string{"u2"}}
+	pr2 := &models.PullRequest{PullRequestID: "pr-2", AuthorID: "u1", Status: models.PRStatusOpen, AssignedReviewers: []string{"u2", "u3"}}
+
+	team := []*models.User{{UserID: "u1"}, leaving, {UserID: "u3"}}
+
+	mockPRRepo.On("GetByReviewer", ctx, "u2").Return(reviews, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr1, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-2").Return(pr2, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(leaving, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return(team, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 1}), nil)
+	mockPRRepo.On("Update", ctx, pr1).Return(nil)
+
+	report, err := service.ReassignOpenReviews(ctx, "u2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(report.Results))
+	assert.Equal(t, "pr-1", report.Results[0].PullRequestID)
+	assert.Equal(t, "u3", report.Results[0].ReplacedBy)
+	assert.Equal(t, "pr-2", report.Results[1].PullRequestID)
+	assert.Equal(t, "NO_CANDIDATE", report.Results[1].ErrorCode)
+	assert.Equal(t, 1, report.Reassigned)
+	assert.Equal(t, 1, report.Failed)
+	assert.True(t, report.Partial)
+	mockPRRepo.AssertNotCalled(t, "GetByID", ctx, "pr-3")
+}
+
+func TestReassignReviewerTo_ExplicitChoice(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	openPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+	}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u4").Return(&models.User{UserID: "u4", TeamName: "backend", IsActive: true}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockPRRepo.On("Update", ctx, openPR).Return(nil)
+
+	pr, err := service.ReassignReviewerTo(ctx, "pr-1", "u2", "u4")
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u3", "u4"}, pr.AssignedReviewers)
+	mockUserRepo.AssertNotCalled(t, "GetActiveByTeam", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReassignReviewerTo_RejectsInvalidChoice(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name      string
+		newUserID string
+		newUser   *models.User
+		expected  error
+	}{
+		{"author", "u1", nil, apperrors.ErrInvalidReviewer},
+		{"already assigned", "u3", nil, apperrors.ErrAlreadyAssigned},
+		{"inactive", "u4", &models.User{UserID: "u4", TeamName: "backend"}, apperrors.ErrInvalidReviewer},
+		{"other team", "u4", &models.User{UserID: "u4", TeamName: "frontend", IsActive: true}, apperrors.ErrInvalidReviewer},
+		{"never pair", "u5", nil, apperrors.ErrInvalidReviewer},
+	}
+
+	// Never pair rules come from the author's team, not from the team of the old reviewer
+	authorSettings := models.DefaultTeamSettings("frontend")
+	authorSettings.NeverPair = []models.ReviewerPair{{UserA: "u5", UserB: "u1"}}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			mockPRRepo := new(MockPRRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockTeamRepo := new(MockTeamRepo)
+
+			prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+			openPR := &models.PullRequest{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            models.PRStatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			}
+
+			mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+			mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "frontend"}, nil)
+			mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "backend"}, nil)
+			mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+			mockTeamRepo.On("GetSettings", ctx, "frontend").Return(authorSettings, nil)
+
+			if tc.newUser != nil {
+				mockUserRepo.On("GetByID", ctx, tc.newUserID).Return(tc.newUser, nil)
+			}
+
+			pr, err := prService.ReassignReviewerTo(ctx, "pr-1", "u2", tc.newUserID)
+
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Nil(t, pr)
+			mockPRRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestManualReviewerChoice_RespectsReviewCap(t *testing.T) {
+	ctx := context.Background()
+
+	for _, policy := range []models.CapPolicy{models.CapQueue, models.CapFail, models.CapExceed} {
+		t.Run(string(policy), func(t *testing.T) {
+
+			mockPRRepo := new(MockPRRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockTeamRepo := new(MockTeamRepo)
+
+			prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+			openPR := &models.PullRequest{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            models.PRStatusOpen,
+				AssignedReviewers: []string{"u2"},
+				Understaffed:      true,
+			}
+
+			settings := models.DefaultTeamSettings("backend")
+			settings.MaxOpenReviews = 2
+			settings.CapPolicy = policy
+
+			mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+			mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+			mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2", TeamName: "backend"}, nil)
+			mockUserRepo.On("GetByID", ctx, "u4").Return(&models.User{UserID: "u4", TeamName: "backend", IsActive: true}, nil)
+			mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+			mockUserRepo.On("GetReviewerLoads", ctx, []string{"u4"}).Return(openLoads(map[string]int{"u4": 2}), nil)
+			mockPRRepo.On("Update", ctx, openPR).Return(nil)
+
+			added, addErr := prService.AddReviewer(ctx, "pr-1", "u4")
+
+			if policy != models.CapExceed {
+				assert.ErrorIs(t, addErr, apperrors.ErrReviewerCapReached)
+				assert.Nil(t, added)
+
+				reassigned, err := prService.ReassignReviewerTo(ctx, "pr-1", "u2", "u4")
+				assert.ErrorIs(t, err, apperrors.ErrReviewerCapReached)
+				assert.Nil(t, reassigned)
+
+				mockPRRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+				return
+			}
+
+			// EXCEED lets the choice through
+			require.NoError(t, addErr)
+			assert.Equal(t, []string{"u2", "u4"}, added.AssignedReviewers)
+		})
+	}
+}
+
+func TestAddReviewer_RespectsReviewerCount(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	understaffedPR := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2"},
+		Understaffed:      true,
+	}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(understaffedPR, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u3").Return(&models.User{UserID: "u3", TeamName: "backend", IsActive: true}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockPRRepo.On("Update", ctx, understaffedPR).Return(nil)
+
+	pr, err := service.AddReviewer(ctx, "pr-1", "u3")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u3"}, pr.AssignedReviewers)
+	assert.False(t, pr.Understaffed)
+
+	// The team's reviewer count is reached now
+	pr, err = service.AddReviewer(ctx, "pr-1", "u4")
+
+	assert.ErrorIs(t, err, apperrors.ErrReviewerLimit)
+	assert.Nil(t, pr)
+}
+
+func TestRemoveReviewer_UnderstaffedPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	for _, policy := range []models.UnderstaffedPolicy{models.UnderstaffedFail, models.UnderstaffedAllow} {
+		t.Run(string(policy), func(t *testing.T) {
+
+			mockPRRepo := new(MockPRRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockTeamRepo := new(MockTeamRepo)
+
+			prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+			openPR := &models.PullRequest{
+				PullRequestID:     "pr-1",
+				AuthorID:          "u1",
+				Status:            models.PRStatusOpen,
+				AssignedReviewers: []string{"u2", "u3"},
+			}
+
+			settings := models.DefaultTeamSettings("backend")
+			settings.UnderstaffedPolicy = policy
+
+			mockPRRepo.On("GetByID", ctx, "pr-1").Return(openPR, nil)
+			mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+			mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+			mockPRRepo.On("Update", ctx, openPR).Return(nil)
+
+			pr, err := prService.RemoveReviewer(ctx, "pr-1", "u2")
+
+			if policy == models.UnderstaffedFail {
+				assert.ErrorIs(t, err, apperrors.ErrNotEnoughReviewers)
+				assert.Nil(t, pr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"u3"}, pr.AssignedReviewers)
+			assert.True(t, pr.Understaffed)
+		})
+	}
+}
+
+func TestPreviewPR_DoesNotWrite(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	candidates := []*models.User{{UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}
+	exclusions := []models.TraceExclusion{{UserID: "u1", Reason: models.ExcludedAuthor}}
+
+	mockUserRepo.On("GetByID", mock.Anything, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", mock.Anything, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", mock.Anything, "backend", "u1", mock.Anything).Return(candidates, nil)
+	mockUserRepo.On("GetTeamExclusions", mock.Anything, "backend", "u1", mock.Anything).Return(exclusions, nil)
+	mockUserRepo.On("GetReviewerLoads", mock.Anything, []string{"u2", "u3", "u4"}).
+		Return(openLoads(map[string]int{"u2": 0, "u3": 5, "u4": 1}), nil)
+
+	pr, trace, err := service.PreviewPR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u2", "u4"}, pr.AssignedReviewers)
+	assert.Equal(t, pr.AssignedReviewers, trace.Selected)
+	assert.Equal(t, 1, len(trace.Rounds))
+
+	// Neither the PR nor its trace is stored
+	mockPRRepo.AssertNotCalled(t, "Exists", mock.Anything, mock.Anything)
+	mockPRRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreatePR_NeverPairRule(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	candidates := []*models.User{{UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"}}
+
+	// u2 is the least loaded but must never review u1
+	settings := models.DefaultTeamSettings("backend")
+	settings.NeverPair = []models.ReviewerPair{{UserA: "u2", UserB: "u1"}}
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(candidates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3", "u4"}).Return(openLoads(map[string]int{"u3": 3, "u4": 4}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"u3", "u4"}, pr.AssignedReviewers)
+}
+
+func TestCreatePR_AuthorSpreadPenalty(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	candidates := []*models.User{{UserID: "u2"}, {UserID: "u3"}}
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerCount = 1
+	settings.AuthorSpreadDays = 30
+	settings.AuthorSpreadPenalty = 2
+
+	// u2 has less open load, but reviewed u1 twice lately: 1 + 2*2 > 3
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(candidates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 1, "u3": 3}), nil)
+	mockUserRepo.On("GetAuthorReviewCounts", ctx, "u1", []string{"u2", "u3"}, now.AddDate(0, 0, -30)).
+		Return(map[string]int{"u2": 2, "u3": 0}, nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.AssignedReviewers)
+}
+
+func TestCreatePR_ReviewWeight(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerCount = 1
+	settings.LoadWeighting = models.LoadWeightingSize
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return([]*models.User{{UserID: "u2"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2"}).Return(openLoads(map[string]int{"u2": 0}), nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	req := createPRRequest("pr-1", "Big refactor", "u1")
+	req.Size = &models.PRSize{LinesAdded: 900, LinesRemoved: 200, FilesChanged: 3}
+
+	pr, err := service.CreatePR(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, pr.ReviewWeight)
+	assert.Equal(t, req.Size, pr.Size)
+
+	// Explicit weight out of bounds
+	weight := models.MaxReviewWeight + 1
+	req = createPRRequest("pr-2", "Typo", "u1")
+	req.ReviewWeight = &weight
+	mockPRRepo.On("Exists", ctx, "pr-2").Return(false, nil)
+
+	_, err = service.CreatePR(ctx, req)
+
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSize)
+}
+
+func TestCreatePR_FairnessWindow(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	candidates := []*models.User{{UserID: "u2"}, {UserID: "u3"}}
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerCount = 1
+	settings.FairnessWindowDays = 30
+	settings.FairnessWeight = 1
+
+	// Same open load, but u2 got four reviews this month and u3 one: 1 + 4 > 1 + 1
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(candidates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(openLoads(map[string]int{"u2": 1, "u3": 1}), nil)
+	mockUserRepo.On("GetAssignmentCounts", ctx, []string{"u2", "u3"}, now.AddDate(0, 0, -30)).
+		Return(map[string]int{"u2": 4, "u3": 1}, nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.AssignedReviewers)
+}
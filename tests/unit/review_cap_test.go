@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func cappedTeamSettings(policy models.CapPolicy) *models.TeamSettings {
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerCount = 1
+	settings.MaxOpenReviews = 2
+	settings.CapPolicy = policy
+
+	return settings
+}
+
+func TestCreatePR_SkipsCappedReviewers(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	limit := 5
+	candidates := []*models.User{{UserID: "u2"}, {UserID: "u3", MaxOpenReviews: &limit}}
+
+	// u2 has the lower weighted load but is at the team cap, u3 has a cap of its own
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(cappedTeamSettings(models.CapFail), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(candidates, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u2", "u3"}).Return(map[string]models.ReviewerLoad{
+		"u2": {Open: 2, Weighted: 2},
+		"u3": {Open: 4, Weighted: 6},
+	}, nil)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3"}, pr.AssignedReviewers)
+	assert.False(t, pr.AwaitingReviewer)
+	// Cap and strategy share one read of the loads
+	mockUserRepo.AssertNumberOfCalls(t, "GetReviewerLoads", 1)
+	mockUserRepo.AssertNotCalled(t, "GetReviewerLoad", mock.Anything, mock.Anything)
+}
+
+func TestCreatePR_EveryoneCapped(t *testing.T) {
+
+	tests := []struct {
+		policy    models.CapPolicy
+		reviewers []string
+		awaiting  bool
+		err       error
+	}{
+		{policy: models.CapQueue, reviewers: []string{}, awaiting: true},
+		{policy: models.CapExceed, reviewers: []string{"u3"}},
+		{policy: models.CapFail, err: apperrors.ErrReviewerCapReached},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+
+			ctx := context.Background()
+
+			mockPRRepo := new(MockPRRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockTeamRepo := new(MockTeamRepo)
+
+			prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+			author := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+
+			mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+			mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+			mockTeamRepo.On("GetSettings", ctx, "backend").Return(cappedTeamSettings(tt.policy), nil)
+
+			// QUEUE passes a derived context down the pipeline
+			mockUserRepo.On("GetActiveByTeam", mock.Anything, "backend", "u1", mock.Anything).
+				Return([]*models.User{{UserID: "u2"}, {UserID: "u3"}}, nil)
+			mockUserRepo.On("GetReviewerLoads", mock.Anything, []string{"u2", "u3"}).Return(map[string]models.ReviewerLoad{
+				"u2": {Open: 3, Weighted: 3},
+				"u3": {Open: 2, Weighted: 2},
+			}, nil)
+			mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+			pr, err := prService.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				mockPRRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.reviewers, pr.AssignedReviewers)
+			assert.Equal(t, tt.awaiting, pr.AwaitingReviewer)
+			assert.False(t, pr.Understaffed)
+			mockUserRepo.AssertNumberOfCalls(t, "GetReviewerLoads", 1)
+		})
+	}
+}
+
+func TestReassignOpenReviews_ReportsCapAndClosedPRs(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	leaving := &models.User{UserID: "u2", TeamName: "backend"}
+
+	// pr-2 was closed after the reviews of u2 were listed
+	reviews := []*models.PullRequest{
+		{PullRequestID: "pr-1", Status: models.PRStatusOpen},
+		{PullRequestID: "pr-2", Status: models.PRStatusOpen},
+	}
+
+	pr1 := &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1", Status: models.PRStatusOpen, AssignedReviewers: []string{"u2"}}
+	pr2 := &models.PullRequest{PullRequestID: "pr-2", AuthorID: "u1", Status: models.PRStatusClosed, AssignedReviewers: []string{"u2"}}
+
+	mockPRRepo.On("GetByReviewer", ctx, "u2").Return(reviews, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr1, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-2").Return(pr2, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(leaving, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(cappedTeamSettings(models.CapFail), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{{UserID: "u1"}, leaving, {UserID: "u3"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 2}), nil)
+
+	report, err := prService.ReassignOpenReviews(ctx, "u2")
+
+	assert.NoError(t, err)
+
+	if assert.Equal(t, 2, len(report.Results)) {
+		assert.Equal(t, "REVIEWER_CAP_REACHED", report.Results[0].ErrorCode)
+		assert.Equal(t, "PR_NOT_OPEN", report.Results[1].ErrorCode)
+	}
+
+	assert.Equal(t, 2, report.Failed)
+	mockPRRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,276 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSLARepo struct {
+	mock.Mock
+}
+
+func (m *MockSLARepo) ListAwaitedReviews(ctx context.Context, at time.Time) ([]*models.AwaitedReview, error) {
+	args := m.Called(ctx, at)
+	return args.Get(0).([]*models.AwaitedReview), args.Error(1)
+}
+
+func (m *MockSLARepo) CreateBreach(ctx context.Context, breach *models.SLABreach) (bool, error) {
+	args := m.Called(ctx, breach)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSLARepo) ResolveBreach(ctx context.Context, breachID int64, escalatedTo, escalationError string) error {
+	args := m.Called(ctx, breachID, escalatedTo, escalationError)
+	return args.Error(0)
+}
+
+func (m *MockSLARepo) ListBreaches(ctx context.Context, filter models.SLABreachFilter) ([]*models.SLABreach, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*models.SLABreach), args.Error(1)
+}
+
+type MockEscalator struct {
+	mock.Mock
+}
+
+func (m *MockEscalator) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*models.PullRequest, string, error) {
+	args := m.Called(ctx, prID, oldUserID)
+	return nil, args.String(0), args.Error(1)
+}
+
+func (m *MockEscalator) AddEscalationReviewer(ctx context.Context, prID, lateUserID string) (*models.PullRequest, string, error) {
+	args := m.Called(ctx, prID, lateUserID)
+	return nil, args.String(0), args.Error(1)
+}
+
+// keeps published events for assertions
+type recordingPublisher struct {
+	events []models.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event models.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func slaTeamSettings(teamName string, hours int, escalation models.SLAEscalation) *models.TeamSettings {
+	settings := models.DefaultTeamSettings(teamName)
+	settings.ReviewSLAHours = hours
+	settings.SLAEscalation = escalation
+	return settings
+}
+
+func TestCheckSLA_EscalatesLateReviews(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockSLARepo := new(MockSLARepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	escalator := new(MockEscalator)
+	publisher := &recordingPublisher{}
+
+	slaService := service.NewSLAService(mockSLARepo, mockUserRepo, mockTeamRepo, escalator, publisher, fixedClock{now: now})
+
+	// u2 is 5 hours late in calendar time, u3 counts business hours and has worked only 2 of 4
+	frontend := slaTeamSettings("frontend", 4, models.SLAEscalationNotify)
+	frontend.SLABusinessHours = true
+
+	parttimer := models.NewUser("u3", "Carol", "frontend", true)
+	parttimer.WorkingHours = models.WorkingHours{{Day: "TUE", Start: "10:00", End: "12:00"}}
+
+	mockSLARepo.On("ListAwaitedReviews", ctx, now).Return([]*models.AwaitedReview{
+		{PullRequestID: "pr-1", AuthorID: "u1", TeamName: "backend", UserID: "u2", AssignedAt: now.Add(-9 * time.Hour)},
+		{PullRequestID: "pr-2", AuthorID: "u4", TeamName: "frontend", UserID: "u3", AssignedAt: now.Add(-6 * time.Hour)},
+	}, nil)
+
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(slaTeamSettings("backend", 4, models.SLAEscalationReassign), nil)
+	mockTeamRepo.On("GetSettings", ctx, "frontend").Return(frontend, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(models.NewUser("u2", "Bob", "backend", true), nil)
+	mockUserRepo.On("GetByID", ctx, "u3").Return(parttimer, nil)
+
+	mockSLARepo.On("CreateBreach", ctx, mock.MatchedBy(func(b *models.SLABreach) bool {
+		b.BreachID = 7
+		return b.UserID == "u2" && b.Deadline.Equal(now.Add(-5*time.Hour)) && b.Escalation == models.SLAEscalationReassign
+	})).Return(true, nil)
+	escalator.On("ReassignReviewer", ctx, "pr-1", "u2").Return("u5", nil)
+	mockSLARepo.On("ResolveBreach", ctx, int64(7), "u5", "").Return(nil)
+
+	breached, err := slaService.CheckSLA(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, breached)
+
+	if assert.Len(t, publisher.events, 1) {
+		assert.Equal(t, models.EventReviewSLABreached, publisher.events[0].Type)
+		assert.Equal(t, "u5", publisher.events[0].Data.(*models.SLABreach).EscalatedTo)
+	}
+
+	mockSLARepo.AssertExpectations(t)
+	escalator.AssertExpectations(t)
+}
+
+func TestCheckSLA_FailedEscalationIsRecorded(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockSLARepo := new(MockSLARepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	escalator := new(MockEscalator)
+	publisher := &recordingPublisher{}
+
+	slaService := service.NewSLAService(mockSLARepo, mockUserRepo, mockTeamRepo, escalator, publisher, fixedClock{now: now})
+
+	mockSLARepo.On("ListAwaitedReviews", ctx, now).Return([]*models.AwaitedReview{
+		{PullRequestID: "pr-1", AuthorID: "u1", TeamName: "backend", UserID: "u2", AssignedAt: now.Add(-30 * time.Hour)},
+	}, nil)
+
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(slaTeamSettings("backend", 24, models.SLAEscalationAddReviewer), nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(models.NewUser("u2", "Bob", "backend", true), nil)
+	mockSLARepo.On("CreateBreach", ctx, mock.AnythingOfType("*models.SLABreach")).Return(true, nil)
+	mockSLARepo.On("ListBreaches", ctx, models.SLABreachFilter{PullRequestID: "pr-1"}).Return([]*models.SLABreach{}, nil)
+	escalator.On("AddEscalationReviewer", ctx, "pr-1", "u2").Return("", apperrors.ErrNoCandidate)
+	mockSLARepo.On("ResolveBreach", ctx, int64(0), "", apperrors.ErrNoCandidate.Error()).Return(nil)
+
+	breached, err := slaService.CheckSLA(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, breached)
+	assert.Len(t, publisher.events, 1)
+	mockSLARepo.AssertExpectations(t)
+}
+
+func TestCheckSLA_BreachAlreadyClaimed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockSLARepo := new(MockSLARepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	escalator := new(MockEscalator)
+	publisher := &recordingPublisher{}
+
+	slaService := service.NewSLAService(mockSLARepo, mockUserRepo, mockTeamRepo, escalator, publisher, fixedClock{now: now})
+
+	mockSLARepo.On("ListAwaitedReviews", ctx, now).Return([]*models.AwaitedReview{
+		{PullRequestID: "pr-1", AuthorID: "u1", TeamName: "backend", UserID: "u2", AssignedAt: now.Add(-30 * time.Hour)},
+	}, nil)
+
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(slaTeamSettings("backend", 24, models.SLAEscalationReassign), nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(models.NewUser("u2", "Bob", "backend", true), nil)
+
+	// Another instance recorded the breach first
+	mockSLARepo.On("CreateBreach", ctx, mock.AnythingOfType("*models.SLABreach")).Return(false, nil)
+
+	breached, err := slaService.CheckSLA(ctx)
+
+	assert.NoError(t, err)
+	assert.Zero(t, breached)
+	assert.Empty(t, publisher.events)
+	escalator.AssertNotCalled(t, "ReassignReviewer", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckSLA_AddsOneEscalationReviewerPerPR(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 8, 12, 0, 0, 0, time.UTC)
+
+	mockSLARepo := new(MockSLARepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+	escalator := new(MockEscalator)
+	publisher := &recordingPublisher{}
+
+	slaService := service.NewSLAService(mockSLARepo, mockUserRepo, mockTeamRepo, escalator, publisher, fixedClock{now: now})
+
+	first := &models.AwaitedReview{PullRequestID: "pr-1", AuthorID: "u1", TeamName: "backend", UserID: "u2", AssignedAt: now.Add(-30 * time.Hour)}
+	second := &models.AwaitedReview{PullRequestID: "pr-1", AuthorID: "u1", TeamName: "backend", UserID: "u3", AssignedAt: now.Add(-26 * time.Hour)}
+
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(slaTeamSettings("backend", 24, models.SLAEscalationAddReviewer), nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(models.NewUser("u2", "Bob", "backend", true), nil)
+	mockUserRepo.On("GetByID", ctx, "u3").Return(models.NewUser("u3", "Carol", "backend", true), nil)
+
+	// u2 is late first and u3 is brought in
+	mockSLARepo.On("ListAwaitedReviews", ctx, now).Return([]*models.AwaitedReview{first}, nil).Once()
+	mockSLARepo.On("CreateBreach", ctx, mock.MatchedBy(func(b *models.SLABreach) bool { return b.UserID == "u2" })).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.SLABreach).BreachID = 1
+	}).Return(true, nil)
+	mockSLARepo.On("ListBreaches", ctx, models.SLABreachFilter{PullRequestID: "pr-1"}).Return([]*models.SLABreach{
+		{BreachID: 1, PullRequestID: "pr-1", UserID: "u2", Escalation: models.SLAEscalationAddReviewer},
+	}, nil).Once()
+	escalator.On("AddEscalationReviewer", ctx, "pr-1", "u2").Return("u3", nil)
+	mockSLARepo.On("ResolveBreach", ctx, int64(1), "u3", "").Return(nil)
+
+	breached, err := slaService.CheckSLA(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, breached)
+
+	// u3 is late too, nobody else is piled on the PR
+	mockSLARepo.On("ListAwaitedReviews", ctx, now).Return([]*models.AwaitedReview{second}, nil).Once()
+	mockSLARepo.On("CreateBreach", ctx, mock.MatchedBy(func(b *models.SLABreach) bool { return b.UserID == "u3" })).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.SLABreach).BreachID = 2
+	}).Return(true, nil)
+	mockSLARepo.On("ListBreaches", ctx, models.SLABreachFilter{PullRequestID: "pr-1"}).Return([]*models.SLABreach{
+		{BreachID: 2, PullRequestID: "pr-1", UserID: "u3", Escalation: models.SLAEscalationAddReviewer},
+		{BreachID: 1, PullRequestID: "pr-1", UserID: "u2", Escalation: models.SLAEscalationAddReviewer, EscalatedTo: "u3"},
+	}, nil).Once()
+	mockSLARepo.On("ResolveBreach", ctx, int64(2), "", mock.AnythingOfType("string")).Return(nil)
+
+	breached, err = slaService.CheckSLA(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, breached)
+
+	escalator.AssertNumberOfCalls(t, "AddEscalationReviewer", 1)
+	escalator.AssertNotCalled(t, "AddEscalationReviewer", ctx, "pr-1", "u3")
+
+	require.Len(t, publisher.events, 2)
+	late := publisher.events[1].Data.(*models.SLABreach)
+	assert.Empty(t, late.EscalatedTo)
+	assert.NotEmpty(t, late.EscalationError)
+	mockSLARepo.AssertExpectations(t)
+}
+
+func TestAddEscalationReviewer_BeyondReviewerCount(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	pr := &models.PullRequest{
+		PullRequestID:     "pr-1",
+		AuthorID:          "u1",
+		Status:            models.PRStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+	}
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{
+		{UserID: "u1"}, {UserID: "u2"}, {UserID: "u3"}, {UserID: "u4"},
+	}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u4"}).Return(openLoads(map[string]int{"u4": 0}), nil)
+	mockPRRepo.On("Update", ctx, pr).Return(nil)
+
+	_, added, err := prService.AddEscalationReviewer(ctx, "pr-1", "u2")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "u4", added)
+	assert.Equal(t, []string{"u2", "u3", "u4"}, pr.AssignedReviewers)
+
+	_, _, err = prService.AddEscalationReviewer(ctx, "pr-1", "u9")
+	assert.ErrorIs(t, err, apperrors.ErrNotAssigned)
+}
@@ -0,0 +1,180 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func reviewedPR(states map[string]models.ReviewState) *models.PullRequest {
+
+	pr := models.NewPullRequest("pr-1", "Test PR", "u1")
+
+	for _, reviewerID := range []string{"u2", "u3"} {
+		pr.AddReviewer(reviewerID)
+
+		if state, ok := states[reviewerID]; ok {
+			pr.SetReview(reviewerID, state, nil)
+		}
+	}
+
+	return pr
+}
+
+func TestSubmitReview(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, fixedClock{now: now})
+
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(reviewedPR(nil), nil)
+	mockPRRepo.On("SubmitReview", ctx, "pr-1", "u2", models.ReviewChangesRequested, now).Return(nil)
+
+	pr, err := prService.SubmitReview(ctx, "pr-1", "u2", models.ReviewChangesRequested)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReviewChangesRequested, pr.ReviewState("u2"))
+	assert.Equal(t, &now, pr.ReviewOf("u2").SubmittedAt)
+	assert.Equal(t, models.ReviewPending, pr.ReviewState("u3"))
+
+	// Only assigned reviewers submit, and PENDING is not a review
+	_, err = prService.SubmitReview(ctx, "pr-1", "u4", models.ReviewApproved)
+	assert.ErrorIs(t, err, apperrors.ErrNotAssigned)
+
+	_, err = prService.SubmitReview(ctx, "pr-1", "u2", models.ReviewPending)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidReviewState)
+
+	mockPRRepo.AssertNumberOfCalls(t, "SubmitReview", 1)
+}
+
+func TestMergePR_RequiresApprovals(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		approvals models.MergeApprovals
+		quorum    int
+		states    map[string]models.ReviewState
+		unstaffed bool
+		err       error
+	}{
+		{name: "none", approvals: models.MergeApprovalsNone, quorum: 1},
+		{name: "all missing one", approvals: models.MergeApprovalsAll, quorum: 1,
+			states: map[string]models.ReviewState{"u2": models.ReviewApproved, "u3": models.ReviewCommented},
+			err:    apperrors.ErrNotApproved},
+		{name: "all approved", approvals: models.MergeApprovalsAll, quorum: 1,
+			states: map[string]models.ReviewState{"u2": models.ReviewApproved, "u3": models.ReviewApproved}},
+		{name: "quorum reached", approvals: models.MergeApprovalsQuorum, quorum: 1,
+			states: map[string]models.ReviewState{"u2": models.ReviewApproved, "u3": models.ReviewChangesRequested}},
+		{name: "quorum missing", approvals: models.MergeApprovalsQuorum, quorum: 2,
+			states: map[string]models.ReviewState{"u2": models.ReviewApproved},
+			err:    apperrors.ErrNotApproved},
+		{name: "quorum above reviewers", approvals: models.MergeApprovalsQuorum, quorum: 3,
+			states: map[string]models.ReviewState{"u2": models.ReviewApproved, "u3": models.ReviewApproved}},
+		{name: "all without reviewers", approvals: models.MergeApprovalsAll, quorum: 1, unstaffed: true,
+			err: apperrors.ErrNoApprovers},
+		{name: "quorum without reviewers", approvals: models.MergeApprovalsQuorum, quorum: 1, unstaffed: true,
+			err: apperrors.ErrNoApprovers},
+		{name: "none without reviewers", approvals: models.MergeApprovalsNone, quorum: 1, unstaffed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			ctx := context.Background()
+
+			mockPRRepo := new(MockPRRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockTeamRepo := new(MockTeamRepo)
+
+			prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+			settings := models.DefaultTeamSettings("backend")
+			settings.MergeApprovals = tt.approvals
+			settings.ApprovalQuorum = tt.quorum
+
+			pr := reviewedPR(tt.states)
+
+			if tt.unstaffed {
+				pr = models.NewPullRequest("pr-1", "Test PR", "u1")
+			}
+
+			mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr, nil)
+			mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+			mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+			mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+			merged, err := prService.MergePR(ctx, "pr-1")
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				mockPRRepo.AssertNotCalled(t, "Update", ctx, mock.Anything)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, models.PRStatusMerged, merged.Status)
+		})
+	}
+}
+
+func TestRecordExternalMerge_SkipsApprovals(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.MergeApprovals = models.MergeApprovalsAll
+
+	// Merged on the code host before anyone approved
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(reviewedPR(nil), nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockPRRepo.On("Update", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := prService.RecordExternalMerge(ctx, "pr-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PRStatusMerged, pr.Status)
+	mockPRRepo.AssertCalled(t, "Update", ctx, pr)
+
+	if assert.Len(t, pr.Events(), 1) {
+		assert.Equal(t, models.EventPRMerged, pr.Events()[0].Type)
+	}
+}
+
+func TestUserService_GetUserReviews_FiltersByState(t *testing.T) {
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+
+	userService := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
+
+	approved := &models.PullRequest{PullRequestID: "pr-1", Reviews: []models.Review{{UserID: "u2", State: models.ReviewApproved}}}
+	pending := &models.PullRequest{PullRequestID: "pr-2", Reviews: []models.Review{{UserID: "u2", State: models.ReviewPending}}}
+
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2"}, nil)
+	mockPRRepo.On("GetByReviewer", ctx, "u2").Return([]*models.PullRequest{approved, pending}, nil)
+
+	prs, err := userService.GetUserReviews(ctx, "u2", models.ReviewPending)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.PullRequest{pending}, prs)
+
+	_, err = userService.GetUserReviews(ctx, "u2", "LGTM")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidReviewState)
+}
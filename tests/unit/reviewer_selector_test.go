@@ -0,0 +1,168 @@
+package unit
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func selectorCandidates() []*models.User {
+	return []*models.User{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "Dave", IsActive: true},
+	}
+}
+
+func selectedIDs(users []*models.User) []string {
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+	return ids
+}
+
+func TestLeastLoadedSelector_PicksLowestLoad(t *testing.T) {
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetReviewerLoad", ctx, []string{"u2", "u3", "u4"}).Return(
+		map[string]int{"u2": 3, "u3": 0, "u4": 1}, nil,
+	)
+
+	selector := service.NewLeastLoadedSelector(mockUserRepo, rand.New(rand.NewSource(1)))
+
+	selected, err := selector.Select(ctx, selectorCandidates(), 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u3", "u4"}, selectedIDs(selected))
+}
+
+func TestRoundRobinSelector_OldestAssignmentFirst(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetReviewerHistory", ctx, []string{"u2", "u3", "u4"}).Return(
+		map[string]models.ReviewerHistory{
+			"u2": {TotalAssigned: 1, LastAssignedAt: &now},
+			"u3": {TotalAssigned: 5, LastAssignedAt: &earlier},
+			"u4": {},
+		}, nil,
+	)
+
+	selector := service.NewRoundRobinSelector(mockUserRepo)
+
+	selected, err := selector.Select(ctx, selectorCandidates(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u3", "u2"}, selectedIDs(selected))
+}
+
+func TestWeightedRandomSelector_NoDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetReviewerLoad", ctx, mock.Anything).Return(
+		map[string]int{"u2": 10, "u3": 0, "u4": 2}, nil,
+	)
+
+	selector := service.NewWeightedRandomSelector(mockUserRepo, rand.New(rand.NewSource(42)))
+
+	for range 20 {
+		selected, err := selector.Select(ctx, selectorCandidates(), 2)
+
+		assert.NoError(t, err)
+		assert.Len(t, selected, 2)
+		assert.NotEqual(t, selected[0].UserID, selected[1].UserID)
+	}
+}
+
+func TestWeightedRandomSelector_PrefersLowLoad(t *testing.T) {
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetReviewerLoad", ctx, mock.Anything).Return(
+		map[string]int{"u2": 99, "u3": 0, "u4": 99}, nil,
+	)
+
+	selector := service.NewWeightedRandomSelector(mockUserRepo, rand.New(rand.NewSource(7)))
+
+	hits := 0
+
+	for range 100 {
+		selected, err := selector.Select(ctx, selectorCandidates(), 1)
+		assert.NoError(t, err)
+
+		if selected[0].UserID == "u3" {
+			hits++
+		}
+	}
+
+	assert.Greater(t, hits, 80)
+}
+
+func TestHistoricalFairnessSelector_FewestTotalFirst(t *testing.T) {
+	ctx := context.Background()
+
+	mockUserRepo := new(MockUserRepo)
+	mockUserRepo.On("GetReviewerHistory", ctx, []string{"u2", "u3", "u4"}).Return(
+		map[string]models.ReviewerHistory{
+			"u2": {TotalAssigned: 2},
+			"u3": {TotalAssigned: 9},
+			"u4": {TotalAssigned: 2},
+		}, nil,
+	)
+	mockUserRepo.On("GetReviewerLoad", ctx, []string{"u2", "u3", "u4"}).Return(
+		map[string]int{"u2": 1, "u3": 0, "u4": 0}, nil,
+	)
+
+	selector := service.NewHistoricalFairnessSelector(mockUserRepo, rand.New(rand.NewSource(1)))
+
+	selected, err := selector.Select(ctx, selectorCandidates(), 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u2"}, selectedIDs(selected))
+}
+
+func TestCreatePR_UsesTeamStrategy(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	service := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+
+	author := &models.User{UserID: "u1", TeamName: "backend"}
+	settings := models.DefaultTeamSettings("backend")
+	settings.ReviewerStrategy = models.StrategyRoundRobin
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+
+	mockPRRepo.On("Exists", ctx, "pr-1").Return(false, nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(author, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "u1", mock.Anything).Return(selectorCandidates(), nil)
+	mockUserRepo.On("GetReviewerHistory", ctx, []string{"u2", "u3", "u4"}).Return(
+		map[string]models.ReviewerHistory{
+			"u2": {TotalAssigned: 1, LastAssignedAt: &lastWeek},
+			"u3": {TotalAssigned: 1, LastAssignedAt: &lastWeek},
+			"u4": {},
+		}, nil,
+	)
+	mockPRRepo.On("Create", ctx, mock.AnythingOfType("*models.PullRequest")).Return(nil)
+
+	pr, err := service.CreatePR(ctx, createPRRequest("pr-1", "Test PR", "u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u4", "u2"}, pr.AssignedReviewers)
+	mockUserRepo.AssertNotCalled(t, "GetReviewerLoad", mock.Anything, mock.Anything)
+}
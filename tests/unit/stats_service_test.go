@@ -3,6 +3,7 @@ package unit
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
@@ -14,8 +15,9 @@ func TestStatsService_GetAssignmentStats_Success(t *testing.T) {
 
 	mockPRRepo := new(MockPRRepo)
 	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
 
-	statsService := service.NewStatsService(mockPRRepo, mockUserRepo)
+	statsService := service.NewStatsService(mockPRRepo, mockUserRepo, mockTeamRepo, service.SystemClock{})
 
 	// Mock data
 	totalStats := map[string]int{
@@ -23,21 +25,22 @@ func TestStatsService_GetAssignmentStats_Success(t *testing.T) {
 		"user2": 3,
 	}
 
-	activeStats := map[string]int{
-		"user1": 2,
-		"user2": 1,
+	activeStats := map[string]models.ReviewerLoad{
+		"user1": {Open: 2, Weighted: 2},
+		"user2": {Open: 1, Weighted: 1},
 	}
 
 	users := map[string]*models.User{
-		"user1": {UserID: "user1", Username: "alice"},
-		"user2": {UserID: "user2", Username: "bob"},
+		"user1": {UserID: "user1", Username: "alice", TeamName: "backend"},
+		"user2": {UserID: "user2", Username: "bob", TeamName: "backend"},
 	}
 
 	// Setup expectations
 	mockPRRepo.On("GetAssignmentStats", ctx).Return(totalStats, nil)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{"user1", "user2"}).Return(activeStats, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"user1", "user2"}).Return(activeStats, nil)
 	mockUserRepo.On("GetByID", ctx, "user1").Return(users["user1"], nil)
 	mockUserRepo.On("GetByID", ctx, "user2").Return(users["user2"], nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil).Once()
 
 	// Execute
 	result, err := statsService.GetAssignmentStats(ctx)
@@ -62,6 +65,7 @@ func TestStatsService_GetAssignmentStats_Success(t *testing.T) {
 	assert.Equal(t, "alice", user1Stats.Username)
 	assert.Equal(t, 5, user1Stats.TotalAssigned)
 	assert.Equal(t, 2, user1Stats.ActiveReviews)
+	assert.Equal(t, 2, user1Stats.FairnessScore)
 
 	assert.Equal(t, "user2", user2Stats.UserID)
 	assert.Equal(t, "bob", user2Stats.Username)
@@ -70,6 +74,7 @@ func TestStatsService_GetAssignmentStats_Success(t *testing.T) {
 
 	mockPRRepo.AssertExpectations(t)
 	mockUserRepo.AssertExpectations(t)
+	mockTeamRepo.AssertExpectations(t)
 }
 
 func TestStatsService_GetAssignmentStats_UserNotFound(t *testing.T) {
@@ -77,8 +82,9 @@ func TestStatsService_GetAssignmentStats_UserNotFound(t *testing.T) {
 
 	mockPRRepo := new(MockPRRepo)
 	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
 
-	statsService := service.NewStatsService(mockPRRepo, mockUserRepo)
+	statsService := service.NewStatsService(mockPRRepo, mockUserRepo, mockTeamRepo, service.SystemClock{})
 
 	// Mock data
 	totalStats := map[string]int{
@@ -87,23 +93,24 @@ func TestStatsService_GetAssignmentStats_UserNotFound(t *testing.T) {
 		"user3": 2, // This user will not be found
 	}
 
-	activeStats := map[string]int{
-		"user1": 2,
-		"user2": 1,
-		"user3": 0,
+	activeStats := map[string]models.ReviewerLoad{
+		"user1": {Open: 2, Weighted: 2},
+		"user2": {Open: 1, Weighted: 1},
+		"user3": {},
 	}
 
 	users := map[string]*models.User{
-		"user1": {UserID: "user1", Username: "alice"},
-		"user2": {UserID: "user2", Username: "bob"},
+		"user1": {UserID: "user1", Username: "alice", TeamName: "backend"},
+		"user2": {UserID: "user2", Username: "bob", TeamName: "backend"},
 		// user3 is missing - will return error
 	}
 
 	// Setup expectations
 	mockPRRepo.On("GetAssignmentStats", ctx).Return(totalStats, nil)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{"user1", "user2", "user3"}).Return(activeStats, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"user1", "user2", "user3"}).Return(activeStats, nil)
 	mockUserRepo.On("GetByID", ctx, "user1").Return(users["user1"], nil)
 	mockUserRepo.On("GetByID", ctx, "user2").Return(users["user2"], nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil).Once()
 	mockUserRepo.On("GetByID", ctx, "user3").Return(nil, assert.AnError) // user3 not found
 
 	// Execute
@@ -125,6 +132,7 @@ func TestStatsService_GetAssignmentStats_UserNotFound(t *testing.T) {
 
 	mockPRRepo.AssertExpectations(t)
 	mockUserRepo.AssertExpectations(t)
+	mockTeamRepo.AssertExpectations(t)
 }
 
 func TestStatsService_GetAssignmentStats_EmptyResults(t *testing.T) {
@@ -132,16 +140,17 @@ func TestStatsService_GetAssignmentStats_EmptyResults(t *testing.T) {
 
 	mockPRRepo := new(MockPRRepo)
 	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
 
-	statsService := service.NewStatsService(mockPRRepo, mockUserRepo)
+	statsService := service.NewStatsService(mockPRRepo, mockUserRepo, mockTeamRepo, service.SystemClock{})
 
 	// Mock empty data
 	totalStats := map[string]int{}
-	activeStats := map[string]int{}
+	activeStats := map[string]models.ReviewerLoad{}
 
 	// Setup expectations
 	mockPRRepo.On("GetAssignmentStats", ctx).Return(totalStats, nil)
-	mockUserRepo.On("GetReviewerLoad", ctx, []string{}).Return(activeStats, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{}).Return(activeStats, nil)
 
 	// Execute
 	result, err := statsService.GetAssignmentStats(ctx)
@@ -155,3 +164,50 @@ func TestStatsService_GetAssignmentStats_EmptyResults(t *testing.T) {
 	mockUserRepo.AssertExpectations(t)
 	mockUserRepo.AssertNotCalled(t, "GetByID")
 }
+
+func TestStatsService_GetAssignmentStats_FairnessWindow(t *testing.T) {
+	ctx := context.Background()
+
+	mockPRRepo := new(MockPRRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+	statsService := service.NewStatsService(mockPRRepo, mockUserRepo, mockTeamRepo, fixedClock{now})
+
+	settings := models.DefaultTeamSettings("backend")
+	settings.FairnessWindowDays = 30
+	settings.FairnessWeight = 2
+
+	mockPRRepo.On("GetAssignmentStats", ctx).Return(map[string]int{"user1": 9, "user2": 3}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"user1", "user2"}).Return(map[string]models.ReviewerLoad{
+		"user1": {Open: 1, Weighted: 3},
+		"user2": {Open: 1, Weighted: 1},
+	}, nil)
+	mockUserRepo.On("GetByID", ctx, "user1").Return(&models.User{UserID: "user1", Username: "alice", TeamName: "backend"}, nil)
+	mockUserRepo.On("GetByID", ctx, "user2").Return(&models.User{UserID: "user2", Username: "bob", TeamName: "frontend"}, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(settings, nil)
+	mockTeamRepo.On("GetSettings", ctx, "frontend").Return(models.DefaultTeamSettings("frontend"), nil)
+	mockUserRepo.On("GetAssignmentCounts", ctx, []string{"user1"}, now.AddDate(0, 0, -30)).
+		Return(map[string]int{"user1": 3}, nil)
+
+	result, err := statsService.GetAssignmentStats(ctx)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	// Sorted by user id
+	assert.Equal(t, "user1", result[0].UserID)
+	assert.Equal(t, 30, result[0].FairnessWindowDays)
+	assert.Equal(t, 3, result[0].WindowAssigned)
+	assert.Equal(t, 1, result[0].ActiveReviews)
+	assert.Equal(t, 3, result[0].WeightedLoad)
+	assert.Equal(t, 9, result[0].FairnessScore)
+
+	// Without a window the score is the weighted load
+	assert.Equal(t, "user2", result[1].UserID)
+	assert.Equal(t, 0, result[1].WindowAssigned)
+	assert.Equal(t, 1, result[1].FairnessScore)
+
+	mockUserRepo.AssertExpectations(t)
+}
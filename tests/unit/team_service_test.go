@@ -18,7 +18,7 @@ func TestTeamService_CreateTeam_Success(t *testing.T) {
 	mockTeamRepo := new(MockTeamRepo)
 	mockUserRepo := new(MockUserRepo)
 
-	service := service.NewTeamService(mockTeamRepo, mockUserRepo)
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
 
 	members := []models.TeamMember{
 		{UserID: "u1", Username: "Alice", IsActive: true},
@@ -27,9 +27,10 @@ func TestTeamService_CreateTeam_Success(t *testing.T) {
 
 	mockTeamRepo.On("Exists", ctx, "backend").Return(false, nil)
 	mockTeamRepo.On("Create", ctx, mock.AnythingOfType("*models.Team")).Return(nil)
+	mockUserRepo.On("GetByID", ctx, mock.Anything).Return(nil, apperrors.ErrUserNotFound).Twice()
 	mockUserRepo.On("Create", ctx, mock.AnythingOfType("*models.User")).Return(nil).Twice()
 
-	team, err := service.CreateTeam(ctx, "backend", members)
+	team, err := service.CreateTeam(ctx, "backend", members, "")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, team)
@@ -47,11 +48,11 @@ func TestTeamService_CreateTeam_AlreadyExists(t *testing.T) {
 	mockTeamRepo := new(MockTeamRepo)
 	mockUserRepo := new(MockUserRepo)
 
-	service := service.NewTeamService(mockTeamRepo, mockUserRepo)
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
 
 	mockTeamRepo.On("Exists", ctx, "backend").Return(true, nil)
 
-	team, err := service.CreateTeam(ctx, "backend", []models.TeamMember{})
+	team, err := service.CreateTeam(ctx, "backend", []models.TeamMember{}, "")
 
 	assert.Error(t, err)
 	assert.Nil(t, team)
@@ -65,7 +66,7 @@ func TestTeamService_GetTeam(t *testing.T) {
 	mockTeamRepo := new(MockTeamRepo)
 	mockUserRepo := new(MockUserRepo)
 
-	service := service.NewTeamService(mockTeamRepo, mockUserRepo)
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
 
 	expectedTeam := &models.Team{
 		TeamName: "backend",
@@ -81,3 +82,201 @@ func TestTeamService_GetTeam(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTeam, team)
 }
+
+func TestTeamService_CreateTeam_InvalidStrategy(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	team, err := service.CreateTeam(ctx, "backend", []models.TeamMember{}, "FASTEST")
+
+	assert.Error(t, err)
+	assert.Nil(t, team)
+	assert.Equal(t, apperrors.ErrInvalidStrategy, err)
+	mockTeamRepo.AssertNotCalled(t, "Create")
+}
+
+func TestTeamService_SetStrategy(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	updatedTeam := &models.Team{TeamName: "backend", ReviewerStrategy: models.StrategyRoundRobin}
+
+	mockTeamRepo.On("UpdateStrategy", ctx, "backend", models.StrategyRoundRobin).Return(nil)
+	mockTeamRepo.On("GetByName", ctx, "backend").Return(updatedTeam, nil)
+
+	team, err := service.SetStrategy(ctx, "backend", models.StrategyRoundRobin)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StrategyRoundRobin, team.ReviewerStrategy)
+
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestTeamService_UpdateSettings_Success(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	teamService := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	count := 3
+	policy := models.UnderstaffedFallback
+	fallbackTeams := []string{"backend"}
+
+	mockTeamRepo.On("GetSettings", ctx, "security").Return(models.DefaultTeamSettings("security"), nil)
+	mockTeamRepo.On("Exists", ctx, "backend").Return(true, nil)
+	mockTeamRepo.On("SaveSettings", ctx, mock.AnythingOfType("*models.TeamSettings")).Return(nil)
+
+	settings, err := teamService.UpdateSettings(ctx, "security", service.TeamSettingsUpdate{
+		ReviewerCount:      &count,
+		UnderstaffedPolicy: &policy,
+		FallbackTeams:      &fallbackTeams,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, settings.ReviewerCount)
+	assert.Equal(t, models.UnderstaffedFallback, settings.UnderstaffedPolicy)
+	assert.Equal(t, []string{"backend"}, settings.FallbackTeams)
+	assert.Equal(t, models.DefaultReviewerStrategy, settings.ReviewerStrategy)
+
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestTeamService_UpdateSettings_Invalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	teamService := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	mockTeamRepo.On("GetSettings", ctx, "docs").Return(models.DefaultTeamSettings("docs"), nil)
+
+	zero := 0
+	_, err := teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{ReviewerCount: &zero})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	policy := models.UnderstaffedFallback
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{UnderstaffedPolicy: &policy})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	self := []string{"docs"}
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{FallbackTeams: &self})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	workingHoursPolicy := models.WorkingHoursPolicy("SOMETIMES")
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{WorkingHoursPolicy: &workingHoursPolicy})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	window := models.MaxWorkingHoursWindow + 1
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{WorkingHoursWindow: &window})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	penalty := -1
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{AuthorSpreadPenalty: &penalty})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	fairnessWindow := models.MaxFairnessWindowDays + 1
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{FairnessWindowDays: &fairnessWindow})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	slaHours := models.MaxReviewSLAHours + 1
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{ReviewSLAHours: &slaHours})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	escalation := models.SLAEscalation("PAGE")
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{SLAEscalation: &escalation})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	selfPair := []models.ReviewerPair{{UserA: "u1", UserB: "u1"}}
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{NeverPair: &selfPair})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	quorum, approvals := 3, models.MergeApprovalsQuorum
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{ApprovalQuorum: &quorum, MergeApprovals: &approvals})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	blankLabel := []models.LabelRule{{Label: " ", Team: "security"}}
+	_, err = teamService.UpdateSettings(ctx, "docs", service.TeamSettingsUpdate{LabelRules: &blankLabel})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSettings)
+
+	mockTeamRepo.AssertNotCalled(t, "SaveSettings", mock.Anything, mock.Anything)
+}
+
+func TestTeamService_UpdateSettings_NeverPair(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	teamService := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1"}, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(&models.User{UserID: "u2"}, nil)
+	mockTeamRepo.On("SaveSettings", ctx, mock.AnythingOfType("*models.TeamSettings")).Return(nil)
+
+	// The same pair given twice in both orders is stored once
+	pairs := []models.ReviewerPair{{UserA: "u2", UserB: "u1"}, {UserA: "u1", UserB: "u2"}}
+	settings, err := teamService.UpdateSettings(ctx, "backend", service.TeamSettingsUpdate{NeverPair: &pairs})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ReviewerPair{{UserA: "u1", UserB: "u2"}}, settings.NeverPair)
+	assert.True(t, settings.NeverPaired("u2", "u1"))
+}
+
+func TestTeamService_SetOwnershipRules(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	mockTeamRepo.On("Exists", ctx, "backend").Return(true, nil)
+	mockTeamRepo.On("Exists", ctx, "dba").Return(true, nil)
+	mockUserRepo.On("GetByID", ctx, "u7").Return(&models.User{UserID: "u7"}, nil)
+	mockTeamRepo.On("ReplaceOwnershipRules", ctx, "backend", mock.Anything).Return(nil)
+
+	rules, err := service.SetOwnershipRules(ctx, "backend", "*.sql @u7 @acme/dba\n/migrations/ @u7")
+
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	mockTeamRepo.AssertExpectations(t)
+	mockUserRepo.AssertNumberOfCalls(t, "GetByID", 1)
+}
+
+func TestTeamService_SetOwnershipRules_UnknownTeam(t *testing.T) {
+
+	ctx := context.Background()
+
+	mockTeamRepo := new(MockTeamRepo)
+	mockUserRepo := new(MockUserRepo)
+
+	service := service.NewTeamService(mockTeamRepo, mockUserRepo, nil, service.SystemClock{})
+
+	mockTeamRepo.On("Exists", ctx, "backend").Return(true, nil)
+	mockTeamRepo.On("Exists", ctx, "ghosts").Return(false, nil)
+
+	rules, err := service.SetOwnershipRules(ctx, "backend", "* @acme/ghosts")
+
+	assert.Nil(t, rules)
+	assert.Equal(t, apperrors.ErrTeamNotFound, err)
+	mockTeamRepo.AssertNotCalled(t, "ReplaceOwnershipRules", mock.Anything, mock.Anything, mock.Anything)
+}
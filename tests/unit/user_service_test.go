@@ -8,6 +8,8 @@ import (
 	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
 	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUserService_SetIsActive_Success(t *testing.T) {
@@ -16,7 +18,7 @@ func TestUserService_SetIsActive_Success(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockPRRepo := new(MockPRRepo)
 
-	service := service.NewUserService(mockUserRepo, mockPRRepo)
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
 
 	existingUser := &models.User{
 		UserID:   "u1",
@@ -43,7 +45,7 @@ func TestUserService_SetIsActive_UserNotFound(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockPRRepo := new(MockPRRepo)
 
-	service := service.NewUserService(mockUserRepo, mockPRRepo)
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
 
 	mockUserRepo.On("GetByID", ctx, "u99").Return(nil, apperrors.ErrUserNotFound)
 
@@ -53,3 +55,211 @@ func TestUserService_SetIsActive_UserNotFound(t *testing.T) {
 	assert.Nil(t, user)
 	assert.Equal(t, apperrors.ErrUserNotFound, err)
 }
+
+func TestUserService_SetIsActive_PublishesDeactivation(t *testing.T) {
+
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+	publisher := &recordingPublisher{}
+
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, publisher, service.SystemClock{})
+
+	activeUser := &models.User{UserID: "u1", TeamName: "backend", IsActive: true}
+	inactiveUser := &models.User{UserID: "u2", TeamName: "backend", IsActive: false}
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(activeUser, nil)
+	mockUserRepo.On("GetByID", ctx, "u2").Return(inactiveUser, nil)
+	mockUserRepo.On("Update", ctx, mock.AnythingOfType("*models.User")).Return(nil)
+
+	_, err := service.SetIsActive(ctx, "u1", false)
+	assert.NoError(t, err)
+
+	// Switching off a user who is already inactive is not a deactivation
+	_, err = service.SetIsActive(ctx, "u2", false)
+	assert.NoError(t, err)
+
+	if assert.Len(t, publisher.events, 1) {
+		assert.Equal(t, models.EventUserDeactivated, publisher.events[0].Type)
+		assert.Equal(t, models.UserEventData{User: activeUser}, publisher.events[0].Data)
+	}
+}
+
+func TestUserService_AddTags_Normalized(t *testing.T) {
+
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1"}, nil)
+	mockUserRepo.On("AddTags", ctx, "u1", []string{"k8s", "sql"}).Return(nil)
+	mockUserRepo.On("GetTags", ctx, "u1").Return([]string{"frontend", "k8s", "sql"}, nil)
+
+	tags, err := service.AddTags(ctx, "u1", []string{" SQL", "k8s", "sql "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"frontend", "k8s", "sql"}, tags)
+
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestUserService_SetTags_InvalidTag(t *testing.T) {
+
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
+
+	tags, err := service.SetTags(ctx, "u1", []string{"sql", "back end"})
+
+	assert.Nil(t, tags)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidTag)
+	mockUserRepo.AssertNotCalled(t, "ReplaceTags")
+}
+
+func TestUserService_SetWorkingHours(t *testing.T) {
+
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
+
+	hours := models.WorkingHours{{Day: "MON", Start: "09:00", End: "17:30"}}
+	existingUser := &models.User{UserID: "u1", Timezone: models.DefaultTimezone}
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(existingUser, nil)
+	mockUserRepo.On("Update", ctx, existingUser).Return(nil)
+
+	user, err := service.SetWorkingHours(ctx, "u1", "Europe/Berlin", hours)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", user.Timezone)
+	assert.Equal(t, hours, user.WorkingHours)
+
+	_, err = service.SetWorkingHours(ctx, "u1", "Mars/Olympus", hours)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidWorkingHours)
+}
+
+func TestUserService_SetReviewCap(t *testing.T) {
+
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+
+	service := service.NewUserService(mockUserRepo, mockPRRepo, nil, nil, nil, service.SystemClock{})
+
+	existingUser := &models.User{UserID: "u1"}
+	limit := 3
+
+	mockUserRepo.On("GetByID", ctx, "u1").Return(existingUser, nil)
+	mockUserRepo.On("Update", ctx, existingUser).Return(nil)
+
+	user, err := service.SetReviewCap(ctx, "u1", &limit)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *user.MaxOpenReviews)
+
+	// nil clears the user's cap
+	user, err = service.SetReviewCap(ctx, "u1", nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, user.MaxOpenReviews)
+
+	zero := 0
+	_, err = service.SetReviewCap(ctx, "u1", &zero)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidReviewCap)
+}
+
+type MockReassigner struct {
+	mock.Mock
+}
+
+func (m *MockReassigner) ReassignOpenReviews(ctx context.Context, userID string) (*service.ReassignmentReport, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.ReassignmentReport), args.Error(1)
+}
+
+func TestUserService_Deactivate_ReassignsOpenReviews(t *testing.T) {
+
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+	mockReassigner := new(MockReassigner)
+
+	userService := service.NewUserService(mockUserRepo, mockPRRepo, mockReassigner, nil, nil, service.SystemClock{})
+
+	existingUser := &models.User{UserID: "u2", TeamName: "backend", IsActive: true}
+	report := &service.ReassignmentReport{
+		UserID:     "u2",
+		Results:    []service.ReassignmentResult{{PullRequestID: "pr-1", ReplacedBy: "u3"}},
+		Reassigned: 1,
+	}
+
+	mockUserRepo.On("GetByID", ctx, "u2").Return(existingUser, nil)
+	mockUserRepo.On("Update", ctx, existingUser).Return(nil)
+	mockReassigner.On("ReassignOpenReviews", ctx, "u2").Return(report, nil)
+
+	user, result, err := userService.Deactivate(ctx, "u2")
+
+	assert.NoError(t, err)
+	assert.False(t, user.IsActive)
+	assert.Equal(t, report, result)
+	mockReassigner.AssertExpectations(t)
+}
+
+func TestUserService_Deactivate_KeepsPartialReportWhenReassignmentStops(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockUserRepo := new(MockUserRepo)
+	mockPRRepo := new(MockPRRepo)
+	mockTeamRepo := new(MockTeamRepo)
+
+	prService := service.NewPRService(mockPRRepo, mockUserRepo, mockTeamRepo, nil, nil, service.SystemClock{})
+	userService := service.NewUserService(mockUserRepo, mockPRRepo, prService, nil, nil, service.SystemClock{})
+
+	leaving := &models.User{UserID: "u2", TeamName: "backend", IsActive: true}
+
+	reviews := []*models.PullRequest{
+		{PullRequestID: "pr-1", Status: models.PRStatusOpen},
+		{PullRequestID: "pr-2", Status: models.PRStatusOpen},
+		{PullRequestID: "pr-3", Status: models.PRStatusOpen},
+	}
+
+	pr1 := &models.PullRequest{PullRequestID: "pr-1", AuthorID: "u1", Status: models.PRStatusOpen, AssignedReviewers: []string{"u2"}}
+
+	mockUserRepo.On("GetByID", ctx, "u2").Return(leaving, nil)
+	mockUserRepo.On("Update", ctx, leaving).Return(nil)
+	mockPRRepo.On("GetByReviewer", ctx, "u2").Return(reviews, nil)
+	mockPRRepo.On("GetByID", ctx, "pr-1").Return(pr1, nil)
+	mockTeamRepo.On("GetSettings", ctx, "backend").Return(models.DefaultTeamSettings("backend"), nil)
+	mockUserRepo.On("GetActiveByTeam", ctx, "backend", "", mock.Anything).Return([]*models.User{{UserID: "u1"}, leaving, {UserID: "u3"}}, nil)
+	mockUserRepo.On("GetReviewerLoads", ctx, []string{"u3"}).Return(openLoads(map[string]int{"u3": 1}), nil)
+	mockPRRepo.On("Update", ctx, pr1).Return(nil)
+
+	// The request goes away while the second PR is being handed over
+	mockPRRepo.On("GetByID", ctx, "pr-2").Run(func(mock.Arguments) { cancel() }).Return(nil, context.Canceled)
+
+	user, report, err := userService.Deactivate(ctx, "u2")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, user)
+	assert.False(t, user.IsActive)
+	require.NotNil(t, report)
+	require.Equal(t, 3, len(report.Results))
+	assert.Equal(t, "u3", report.Results[0].ReplacedBy)
+	assert.Equal(t, "INTERNAL_ERROR", report.Results[1].ErrorCode)
+	assert.Equal(t, "INTERNAL_ERROR", report.Results[2].ErrorCode)
+	assert.Equal(t, 1, report.Reassigned)
+	assert.Equal(t, 2, report.Failed)
+	assert.True(t, report.Partial)
+	mockPRRepo.AssertNotCalled(t, "GetByID", mock.Anything, "pr-3")
+}
@@ -0,0 +1,337 @@
+package unit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SashaMalcev/pr-reviewer-service/internal/codehost"
+	apperrors "github.com/SashaMalcev/pr-reviewer-service/internal/errors"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/models"
+	"github.com/SashaMalcev/pr-reviewer-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const webhookSecret = "It's a Secret to Everybody"
+
+type MockLoginRepo struct {
+	mock.Mock
+}
+
+func (m *MockLoginRepo) Save(ctx context.Context, login *models.CodeHostLogin) error {
+	args := m.Called(ctx, login)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepo) Delete(ctx context.Context, host models.CodeHost, login string) error {
+	args := m.Called(ctx, host, login)
+	return args.Error(0)
+}
+
+func (m *MockLoginRepo) FindUserID(ctx context.Context, host models.CodeHost, login string) (string, error) {
+	args := m.Called(ctx, host, login)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockLoginRepo) List(ctx context.Context, host models.CodeHost) ([]*models.CodeHostLogin, error) {
+	args := m.Called(ctx, host)
+	return args.Get(0).([]*models.CodeHostLogin), args.Error(1)
+}
+
+type MockPRAutomation struct {
+	mock.Mock
+}
+
+func (m *MockPRAutomation) CreatePR(ctx context.Context, req service.CreatePRRequest) (*models.PullRequest, error) {
+	args := m.Called(ctx, req)
+	return nil, args.Error(0)
+}
+
+func (m *MockPRAutomation) UpdateMetadata(ctx context.Context, prID string, update service.PRMetadataUpdate) (*models.PullRequest, error) {
+	args := m.Called(ctx, prID, update)
+	return nil, args.Error(0)
+}
+
+func (m *MockPRAutomation) MarkReady(ctx context.Context, prID string) (*models.PullRequest, error) {
+	args := m.Called(ctx, prID)
+	return nil, args.Error(0)
+}
+
+func (m *MockPRAutomation) RecordExternalMerge(ctx context.Context, prID string) (*models.PullRequest, error) {
+	args := m.Called(ctx, prID)
+	return nil, args.Error(0)
+}
+
+func (m *MockPRAutomation) ClosePR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	args := m.Called(ctx, prID)
+	return nil, args.Error(0)
+}
+
+func (m *MockPRAutomation) ReopenPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	args := m.Called(ctx, prID)
+	return nil, args.Error(0)
+}
+
+// reads a recorded webhook delivery of the code host
+func webhookFixture(t *testing.T, host, name string) []byte {
+
+	body, err := os.ReadFile(filepath.Join("testdata", host, name))
+	require.NoError(t, err)
+
+	return body
+}
+
+func hmacHex(secret string, body []byte) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// reads a recorded GitHub delivery and signs it the way GitHub does
+func githubDelivery(t *testing.T, event, name string) (http.Header, []byte) {
+
+	body := webhookFixture(t, "github", name)
+
+	header := http.Header{}
+	header.Set("X-GitHub-Event", event)
+	header.Set("X-Hub-Signature-256", "sha256="+hmacHex(webhookSecret, body))
+
+	return header, body
+}
+
+func newGitHubWebhookService(loginRepo *MockLoginRepo, userRepo *MockUserRepo, prs *MockPRAutomation) *service.WebhookService {
+	return service.NewWebhookService(loginRepo, userRepo, prs, service.SystemClock{}, codehost.NewGitHub(webhookSecret))
+}
+
+func TestWebhookService_RejectsUnverifiedDeliveries(t *testing.T) {
+
+	ctx := context.Background()
+
+	prs := new(MockPRAutomation)
+	webhookService := newGitHubWebhookService(new(MockLoginRepo), new(MockUserRepo), prs)
+
+	header, body := githubDelivery(t, "pull_request", "pull_request_closed.json")
+	header.Set("X-Hub-Signature-256", "sha256="+hmacHex("another secret", body))
+
+	_, err := webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidSignature)
+
+	// Code hosts without an adapter accept nothing
+	header, body = githubDelivery(t, "pull_request", "pull_request_closed.json")
+	_, err = webhookService.HandleDelivery(ctx, models.CodeHostGitLab, header, body)
+	assert.ErrorIs(t, err, apperrors.ErrCodeHostNotFound)
+
+	prs.AssertNotCalled(t, "ClosePR", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_OpenedCreatesPR(t *testing.T) {
+
+	ctx := context.Background()
+
+	loginRepo := new(MockLoginRepo)
+	prs := new(MockPRAutomation)
+	webhookService := newGitHubWebhookService(loginRepo, new(MockUserRepo), prs)
+
+	expected := service.CreatePRRequest{
+		PullRequestID:   "acme/api#42",
+		PullRequestName: "Rotate signing keys",
+		AuthorID:        "u1",
+		PRMetadata: models.PRMetadata{
+			Repository:  "acme/api",
+			URL:         "https://github.com/acme/api/pull/42",
+			BaseBranch:  "main",
+			Labels:      []string{"Security"},
+			Description: "Moves token signing to the new KMS key.\r\n\r\nCloses #40",
+		},
+	}
+
+	loginRepo.On("FindUserID", ctx, models.CodeHostGitHub, "alice-dev").Return("u1", nil)
+	prs.On("CreatePR", ctx, expected).Return(nil).Once()
+
+	header, body := githubDelivery(t, "pull_request", "pull_request_opened.json")
+
+	result, err := webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &models.WebhookResult{
+		Event: "pull_request", Action: "opened", PullRequestID: "acme/api#42", Outcome: models.WebhookCreated,
+	}, result)
+
+	// A redelivery finds the PR registered already
+	prs.On("CreatePR", ctx, expected).Return(apperrors.ErrPRExists)
+
+	result, err = webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookIgnored, result.Outcome)
+	prs.AssertNumberOfCalls(t, "CreatePR", 2)
+}
+
+func TestWebhookService_UnmappedAuthor(t *testing.T) {
+
+	ctx := context.Background()
+
+	loginRepo := new(MockLoginRepo)
+	prs := new(MockPRAutomation)
+	webhookService := newGitHubWebhookService(loginRepo, new(MockUserRepo), prs)
+
+	loginRepo.On("FindUserID", ctx, models.CodeHostGitHub, "alice-dev").Return("", apperrors.ErrLoginNotMapped)
+
+	header, body := githubDelivery(t, "pull_request", "pull_request_opened.json")
+
+	_, err := webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+	assert.ErrorIs(t, err, apperrors.ErrLoginNotMapped)
+	prs.AssertNotCalled(t, "CreatePR", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_Lifecycle(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := []struct {
+		fixture string
+		method  string
+		outcome models.WebhookOutcome
+	}{
+		{"pull_request_closed_merged.json", "RecordExternalMerge", models.WebhookMerged},
+		{"pull_request_closed.json", "ClosePR", models.WebhookClosed},
+		{"pull_request_reopened.json", "ReopenPR", models.WebhookReopened},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+
+			prs := new(MockPRAutomation)
+			webhookService := newGitHubWebhookService(new(MockLoginRepo), new(MockUserRepo), prs)
+
+			prs.On(tt.method, ctx, "acme/api#42").Return(nil)
+
+			header, body := githubDelivery(t, "pull_request", tt.fixture)
+
+			result, err := webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.outcome, result.Outcome)
+			prs.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWebhookService_EditedUpdatesMetadata(t *testing.T) {
+
+	ctx := context.Background()
+
+	prs := new(MockPRAutomation)
+	webhookService := newGitHubWebhookService(new(MockLoginRepo), new(MockUserRepo), prs)
+
+	prs.On("UpdateMetadata", ctx, "acme/api#42", mock.MatchedBy(func(update service.PRMetadataUpdate) bool {
+		return update.Labels != nil && assert.ObjectsAreEqual([]string{"Security", "bug"}, *update.Labels) &&
+			*update.PullRequestName == "Rotate signing keys" && *update.BaseBranch == "main"
+	})).Return(nil)
+
+	header, body := githubDelivery(t, "pull_request", "pull_request_labeled.json")
+
+	result, err := webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookUpdated, result.Outcome)
+	prs.AssertExpectations(t)
+}
+
+func TestWebhookService_IgnoredDeliveries(t *testing.T) {
+
+	ctx := context.Background()
+
+	prs := new(MockPRAutomation)
+	webhookService := newGitHubWebhookService(new(MockLoginRepo), new(MockUserRepo), prs)
+
+	header, body := githubDelivery(t, "ping", "ping.json")
+
+	result, err := webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookIgnored, result.Outcome)
+
+	// Closing a PR the service never saw
+	prs.On("ClosePR", ctx, "acme/api#42").Return(apperrors.ErrPRNotFound)
+
+	header, body = githubDelivery(t, "pull_request", "pull_request_closed.json")
+
+	result, err = webhookService.HandleDelivery(ctx, models.CodeHostGitHub, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookIgnored, result.Outcome)
+	assert.Equal(t, "acme/api#42", result.PullRequestID)
+}
+
+func TestWebhookService_SaveLogin(t *testing.T) {
+
+	ctx := context.Background()
+
+	loginRepo := new(MockLoginRepo)
+	userRepo := new(MockUserRepo)
+	webhookService := newGitHubWebhookService(loginRepo, userRepo, new(MockPRAutomation))
+
+	userRepo.On("GetByID", ctx, "u1").Return(&models.User{UserID: "u1", TeamName: "backend"}, nil)
+	userRepo.On("GetByID", ctx, "ghost").Return(nil, apperrors.ErrUserNotFound)
+	loginRepo.On("Save", ctx, mock.AnythingOfType("*models.CodeHostLogin")).Return(nil)
+
+	login, err := webhookService.SaveLogin(ctx, models.CodeHostGitHub, " Alice-Dev ", "u1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice-dev", login.Login)
+	assert.Equal(t, "u1", login.UserID)
+
+	_, err = webhookService.SaveLogin(ctx, models.CodeHostGitHub, " ", "u1")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidLogin)
+
+	_, err = webhookService.SaveLogin(ctx, models.CodeHost("BITBUCKET"), "alice", "u1")
+	assert.ErrorIs(t, err, apperrors.ErrCodeHostNotFound)
+
+	_, err = webhookService.SaveLogin(ctx, models.CodeHostGitHub, "bob", "ghost")
+	assert.ErrorIs(t, err, apperrors.ErrUserNotFound)
+
+	loginRepo.AssertNumberOfCalls(t, "Save", 1)
+}
+
+func TestWebhookService_GitLabDraftMergeRequest(t *testing.T) {
+
+	ctx := context.Background()
+
+	loginRepo := new(MockLoginRepo)
+	prs := new(MockPRAutomation)
+	webhookService := service.NewWebhookService(loginRepo, new(MockUserRepo), prs, service.SystemClock{},
+		codehost.NewGitHub(webhookSecret), codehost.NewGitLab(webhookSecret))
+
+	// Logins are mapped per code host
+	loginRepo.On("FindUserID", ctx, models.CodeHostGitLab, "carol.smith").Return("u3", nil)
+	prs.On("CreatePR", ctx, mock.MatchedBy(func(req service.CreatePRRequest) bool {
+		return req.PullRequestID == "platform/billing!17" && req.AuthorID == "u3" && req.Draft
+	})).Return(nil)
+	prs.On("MarkReady", ctx, "platform/billing!17").Return(nil)
+
+	header, body := gitlabDelivery(t, "merge_request_open.json")
+
+	result, err := webhookService.HandleDelivery(ctx, models.CodeHostGitLab, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookCreated, result.Outcome)
+
+	header, body = gitlabDelivery(t, "merge_request_update_ready.json")
+
+	result, err = webhookService.HandleDelivery(ctx, models.CodeHostGitLab, header, body)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookReady, result.Outcome)
+	prs.AssertExpectations(t)
+}